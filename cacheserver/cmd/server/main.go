@@ -3,23 +3,36 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"net"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/althk/ganache/cacheserver/internal/config"
 	"github.com/althk/ganache/cacheserver/internal/server"
+	"github.com/althk/ganache/cacheserver/internal/strategy"
 	pb "github.com/althk/ganache/cacheserver/proto"
+	"github.com/althk/ganache/utils/lifecycle"
 	"github.com/althk/goeasy/grpcutils"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 var listenAddr = flag.String("listen_addr", ":0", "cache server port, defaults to 0 which means any available port")
 var shard = flag.Int("shard", 0, "shard number for key distribution")
 var csmSpec = flag.String("csm_server", "", "address of CSM service in the form host:port")
 var etcdSpec = flag.String("etcd_server", "localhost:2379", "address of etcd service in the form host:port")
+var replicationMode = flag.String("replication_mode", config.ReplicationEtcd, fmt.Sprintf("how writes reach the shard's other replicas, %q through etcd or %q directly", config.ReplicationEtcd, config.ReplicationPeer))
+var csResolverPrefix = flag.String("cacheserver_resolver_prefix", "ganache/cacheserver", "key prefix for cache service resolver, where peers are found with -replication_mode=peer")
+var antiEntropyInterval = flag.Duration("anti_entropy_interval", time.Minute, "how often to compare the cache with a random replica of the shard and repair diverged keys, 0 disables it")
 var debug = flag.Bool("debug", false, "enable debug logging")
 var maxCacheBytes = flag.Int64("max_cache_bytes", 1000000000, "max size oftotal cache in bytes, defaults to 1GiB")
+var evictionPolicy = flag.String("eviction_policy", strategy.LRU, fmt.Sprintf("cache eviction policy, one of %v", strategy.Policies))
+var expirySweepInterval = flag.Duration("expiry_sweep_interval", time.Second, "how often expired keys are removed from the cache")
+var shutdownTimeout = flag.Duration("shutdown_timeout", 30*time.Second, "how long shutting down may take, RPCs still running after that are cancelled")
+var drainDelay = flag.Duration("drain_delay", 2*time.Second, "how long to keep serving after deregistering, so that CFEs stop sending requests first")
 var clientCAPath = flag.String("client_ca_file", "", "Path to CA cert file that can verify client certs")
 var rootCAPath = flag.String("root_ca_file", "", "Path to CA cert file that can verify server/peer certs")
 var tlsCrtPath = flag.String("tls_cert_file", "", "Path to server's TLS cert file")
@@ -55,32 +68,70 @@ func main() {
 			SkipTLS:          *skipTLS,
 			RootCAFilePath:   *rootCAPath,
 		},
-		KeepAliveConfig: &grpcutils.KeepAliveConfig{}, // use defaults
+		KeepAliveConfig:  &grpcutils.KeepAliveConfig{}, // use defaults
+		SkipHealthServer: true,                         // registered below, drained on shutdown
 	}
 	csConfig := &config.CSConfig{
-		CSMSpec:       *csmSpec,
-		ETCDSpec:      *etcdSpec,
-		MaxCacheBytes: *maxCacheBytes,
-		Shard:         int32(*shard),
-		Addr:          lis.Addr().String(),
-		ServerConfig:  grpcCfg,
+		CSMSpec:             *csmSpec,
+		ETCDSpec:            *etcdSpec,
+		MaxCacheBytes:       *maxCacheBytes,
+		EvictionPolicy:      *evictionPolicy,
+		Shard:               int32(*shard),
+		Addr:                lis.Addr().String(),
+		ServerConfig:        grpcCfg,
+		ExpirySweepInterval: *expirySweepInterval,
+		ReplicationMode:     *replicationMode,
+		CSResolverPrefix:    *csResolverPrefix,
+		AntiEntropyInterval: *antiEntropyInterval,
 	}
-	cacheServer, err := server.New(csConfig)
+	// cancelled when the server starts shutting down
+	ctx, stop := context.WithCancel(context.Background())
+	cacheServer, err := server.New(ctx, csConfig)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Cache server initialization failed.")
 	}
 
-	// cache server has been registered with CSM and synced the shard locally
-	// proceed with serving.
 	s, err := grpcCfg.NewGRPCServer()
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to load grpc server opts.")
 	}
 	// register cacheserver
 	pb.RegisterCacheServer(s, cacheServer)
+	// not ready until the shard has been synced locally, CFE skips the
+	// server until then.
+	hs := health.NewServer()
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	hs.SetServingStatus(pb.Cache_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(s, hs)
 
-	log.Info().Msgf("Running cache server on %v", lis.Addr().String())
-	s.Serve(lis)
+	go func() {
+		log.Info().Msgf("Running cache server on %v", lis.Addr().String())
+		if err := s.Serve(lis); err != nil {
+			log.Fatal().Err(err).Msg("Cache server failed.")
+		}
+	}()
+
+	reg, err := server.Start(ctx, csConfig, cacheServer)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Cache server initialization failed.")
+	}
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus(pb.Cache_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	log.Info().Msg("Cache server is ready")
+
+	sig := lifecycle.WaitForSignal()
+	log.Info().Msgf("Received %v, shutting down", sig)
+	d := &lifecycle.Drainer{
+		Server:     s,
+		Health:     hs,
+		DrainDelay: *drainDelay,
+		Drain: []lifecycle.Step{reg.Deregister, cacheServer.StopWatches, func(context.Context) error {
+			stop()
+			return nil
+		}},
+		Flush: []lifecycle.Step{cacheServer.WaitForPendingWrites},
+	}
+	d.Shutdown(*shutdownTimeout)
 }
 
 // Get preferred outbound addr (ip:port) of this server
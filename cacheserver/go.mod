@@ -6,6 +6,7 @@ require (
 	github.com/althk/ganache/csm v0.0.0-20220706175043-8ffe22299080
 	github.com/althk/ganache/utils v0.0.0-20220706175043-8ffe22299080
 	github.com/rs/zerolog v1.27.0
+	go.etcd.io/etcd/api/v3 v3.5.4
 	go.etcd.io/etcd/client/v3 v3.5.4
 	google.golang.org/grpc v1.47.0
 	google.golang.org/protobuf v1.28.0
@@ -26,7 +27,6 @@ require (
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/objx v0.4.0 // indirect
-	go.etcd.io/etcd/api/v3 v3.5.4 // indirect
 	go.etcd.io/etcd/client/pkg/v3 v3.5.4 // indirect
 	go.opencensus.io v0.23.0 // indirect
 	go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc v0.33.0 // indirect
@@ -44,7 +44,6 @@ require (
 )
 
 require (
-	github.com/althk/dmap v0.0.0-20220707164922-cdbb55a2943f
 	github.com/althk/goeasy/grpcutils v0.0.0-20220712184942-d7de7754eb7f
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/grpc-ecosystem/go-grpc-middleware/providers/zerolog/v2 v2.0.0-rc.2 // indirect
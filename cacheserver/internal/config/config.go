@@ -1,14 +1,30 @@
 package config
 
-import "github.com/althk/goeasy/grpcutils"
+import (
+	"time"
+
+	"github.com/althk/goeasy/grpcutils"
+)
 
 // Cache Server config
 type CSConfig struct {
-	Port          int32
-	CSMSpec       string
-	ETCDSpec      string
-	MaxCacheBytes int64
-	Shard         int32
-	Addr          string
-	ServerConfig  *grpcutils.GRPCServerConfig
+	Port                int32
+	CSMSpec             string
+	ETCDSpec            string
+	MaxCacheBytes       int64
+	EvictionPolicy      string
+	Shard               int32
+	Addr                string
+	ServerConfig        *grpcutils.GRPCServerConfig
+	ExpirySweepInterval time.Duration // how often expired keys are removed
+	ReplicationMode     string        // ReplicationEtcd or ReplicationPeer
+	CSResolverPrefix    string        // where CSM registers the shard's servers
+	AntiEntropyInterval time.Duration // how often to repair from a peer, 0 disables it
 }
+
+// Replication modes, how a cache server's writes reach the other replicas of
+// its shard.
+const (
+	ReplicationEtcd = "etcd" // through the shard's etcd prefix
+	ReplicationPeer = "peer" // over the Replicate RPC of each replica
+)
@@ -0,0 +1,88 @@
+// Package hlc implements hybrid logical clocks, which order writes made on
+// different replicas consistently with causality even when their wall
+// clocks disagree.
+package hlc
+
+import (
+	"sync"
+	"time"
+
+	pb "github.com/althk/ganache/cacheserver/proto"
+)
+
+// Clock issues HLC timestamps for one node, the zero value is ready to use.
+type Clock struct {
+	// Node breaks ties between timestamps of different nodes.
+	Node string
+	// Wall returns the physical time, time.Now if nil.
+	Wall func() time.Time
+
+	l        sync.Mutex
+	physical int64
+	logical  uint32
+}
+
+// Now returns a timestamp later than every timestamp the clock has issued
+// or observed.
+func (c *Clock) Now() *pb.HLC {
+	c.l.Lock()
+	defer c.l.Unlock()
+	pt := c.WallTime().UnixNano()
+	if pt > c.physical {
+		c.physical, c.logical = pt, 0
+	} else {
+		c.logical++
+	}
+	return &pb.HLC{Physical: c.physical, Logical: c.logical, Node: c.Node}
+}
+
+// Observe moves the clock past ts, a timestamp from another node, so that
+// the clock's next timestamps are later than it.
+func (c *Clock) Observe(ts *pb.HLC) {
+	if ts == nil {
+		return
+	}
+	c.l.Lock()
+	defer c.l.Unlock()
+	switch {
+	case ts.Physical > c.physical:
+		c.physical, c.logical = ts.Physical, ts.Logical
+	case ts.Physical == c.physical && ts.Logical > c.logical:
+		c.logical = ts.Logical
+	}
+}
+
+// WallTime returns the physical time the clock is based on.
+func (c *Clock) WallTime() time.Time {
+	if c.Wall != nil {
+		return c.Wall()
+	}
+	return time.Now()
+}
+
+// Compare returns -1, 0 or 1 as a is before, equal to or after b. A nil
+// timestamp is before every other.
+func Compare(a, b *pb.HLC) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	case a.Physical != b.Physical:
+		return cmp(a.Physical < b.Physical)
+	case a.Logical != b.Logical:
+		return cmp(a.Logical < b.Logical)
+	case a.Node != b.Node:
+		return cmp(a.Node < b.Node)
+	}
+	return 0
+}
+
+func cmp(less bool) int {
+	if less {
+		return -1
+	}
+	return 1
+}
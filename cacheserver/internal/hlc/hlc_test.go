@@ -0,0 +1,62 @@
+package hlc
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/althk/ganache/cacheserver/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNowMonotonic(t *testing.T) {
+	wall := time.Unix(100, 0)
+	c := &Clock{Node: "a", Wall: func() time.Time { return wall }}
+	t1 := c.Now()
+	t2 := c.Now()
+	require.Equal(t, -1, Compare(t1, t2))
+	require.EqualValues(t, 1, t2.Logical)
+
+	// the wall clock going back does not move the clock back
+	wall = time.Unix(50, 0)
+	t3 := c.Now()
+	require.Equal(t, -1, Compare(t2, t3))
+	require.Equal(t, wall.Add(50*time.Second).UnixNano(), t3.Physical)
+
+	wall = time.Unix(200, 0)
+	t4 := c.Now()
+	require.Equal(t, wall.UnixNano(), t4.Physical)
+	require.Zero(t, t4.Logical)
+}
+
+func TestObserveSkewedClock(t *testing.T) {
+	ahead := &Clock{Node: "ahead", Wall: func() time.Time { return time.Unix(1000, 0) }}
+	behind := &Clock{Node: "behind", Wall: func() time.Time { return time.Unix(10, 0) }}
+
+	remote := ahead.Now()
+	require.Equal(t, -1, Compare(behind.Now(), remote))
+	// a write made after seeing remote is later, however far behind the
+	// wall clock is
+	behind.Observe(remote)
+	require.Equal(t, 1, Compare(behind.Now(), remote))
+	behind.Observe(nil)
+}
+
+func TestCompare(t *testing.T) {
+	a := &pb.HLC{Physical: 1, Logical: 2, Node: "a"}
+	for _, tc := range []struct {
+		b    *pb.HLC
+		want int
+	}{
+		{&pb.HLC{Physical: 1, Logical: 2, Node: "a"}, 0},
+		{&pb.HLC{Physical: 2}, -1},
+		{&pb.HLC{Physical: 1, Logical: 3}, -1},
+		{&pb.HLC{Physical: 1, Logical: 1, Node: "z"}, 1},
+		// ties go to the node, the same way on every replica
+		{&pb.HLC{Physical: 1, Logical: 2, Node: "b"}, -1},
+		{nil, 1},
+	} {
+		require.Equal(t, tc.want, Compare(a, tc.b), "%v", tc.b)
+		require.Equal(t, -tc.want, Compare(tc.b, a), "%v", tc.b)
+	}
+	require.Zero(t, Compare(nil, nil))
+}
@@ -0,0 +1,94 @@
+package replication
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/althk/ganache/cacheserver/internal/service"
+	pb "github.com/althk/ganache/cacheserver/proto"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+)
+
+// AntiEntropy periodically repairs a cache server from a random other
+// replica of its shard, which catches the writes that replication lost,
+// e.g. watch events missed around a restart or writes that failed for a peer
+// that fell behind.
+type AntiEntropy struct {
+	CS *service.CacheServer
+	// Peers lists the addresses of the shard's replicas, CS's own address
+	// is skipped.
+	Peers func(ctx context.Context) ([]string, error)
+	// Dial connects to a peer.
+	Dial     func(addr string) (*grpc.ClientConn, error)
+	Interval time.Duration
+
+	conns map[string]*grpc.ClientConn
+}
+
+// Run repairs CS every Interval until ctx is done.
+func (a *AntiEntropy) Run(ctx context.Context) {
+	t := time.NewTicker(a.Interval)
+	defer func() {
+		t.Stop()
+		for _, c := range a.conns {
+			c.Close()
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			a.round(ctx)
+		}
+	}
+}
+
+func (a *AntiEntropy) round(ctx context.Context) {
+	addrs, err := a.Peers(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list anti-entropy peers")
+		return
+	}
+	if a.conns == nil {
+		a.conns = make(map[string]*grpc.ClientConn)
+	}
+	peers := make(map[string]bool, len(addrs))
+	var others []string
+	for _, addr := range addrs {
+		if addr != a.CS.Addr {
+			peers[addr] = true
+			others = append(others, addr)
+		}
+	}
+	for addr, c := range a.conns {
+		if !peers[addr] {
+			c.Close()
+			delete(a.conns, addr)
+		}
+	}
+	if len(others) == 0 {
+		return
+	}
+	addr := others[rand.Intn(len(others))]
+	conn, ok := a.conns[addr]
+	if !ok {
+		if conn, err = a.Dial(addr); err != nil {
+			log.Warn().Err(err).Str("peer", addr).Msg("Failed to connect to anti-entropy peer")
+			return
+		}
+		a.conns[addr] = conn
+	}
+	rctx, cancel := context.WithTimeout(ctx, a.Interval)
+	defer cancel()
+	n, err := a.CS.AntiEntropy(rctx, pb.NewCacheClient(conn))
+	if err != nil {
+		log.Warn().Err(err).Str("peer", addr).Msg("Anti-entropy failed")
+		return
+	}
+	if n > 0 {
+		log.Info().Str("peer", addr).Int("keys", n).Msg("Anti-entropy repaired diverged keys")
+	}
+}
@@ -0,0 +1,78 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	pb "github.com/althk/ganache/cacheserver/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestAntiEntropy(t *testing.T) {
+	// no peers are set, so the replicas only converge through anti-entropy
+	a := testServer(t)
+	b := testServer(t)
+	ctx := context.Background()
+	set := func(r *testReplica, k, v string) {
+		data, _ := anypb.New(wrapperspb.String(v))
+		_, err := r.cs.Set(ctx, &pb.SetRequest{Namespace: "ns", Key: k, Data: data})
+		require.NoError(t, err)
+	}
+	for i := 0; i < 100; i++ {
+		k := fmt.Sprint("k", i)
+		set(a, k, "v1")
+		v, _ := a.cs.Cache.Get(ctx, "ns"+k)
+		b.cs.Sync("ns"+k, v)
+	}
+	set(a, "k1", "v2")  // a has the latest write
+	set(b, "k2", "v2")  // b has the latest write
+	set(b, "new", "v1") // only on b
+	_, err := a.cs.Delete(ctx, &pb.DeleteRequest{Namespace: "ns", Key: "k3"})
+	require.NoError(t, err)
+
+	n, err := a.cs.AntiEntropy(ctx, client(t, b.cs.Addr))
+	require.NoError(t, err)
+	require.Equal(t, 2, n) // k2 and new, k1 and k3 are newer on a
+	n, err = b.cs.AntiEntropy(ctx, client(t, a.cs.Addr))
+	require.NoError(t, err)
+	require.Equal(t, 2, n) // k1 and the delete of k3
+
+	get := func(r *testReplica, k string) string {
+		resp, err := r.cs.Get(ctx, &pb.GetRequest{Namespace: "ns", Key: k})
+		if err != nil {
+			return ""
+		}
+		v := &wrapperspb.StringValue{}
+		require.NoError(t, resp.Data.UnmarshalTo(v))
+		return v.Value
+	}
+	for _, r := range []*testReplica{a, b} {
+		require.Equal(t, "v2", get(r, "k1"))
+		require.Equal(t, "v2", get(r, "k2"))
+		require.Equal(t, "", get(r, "k3"))
+		require.Equal(t, "v1", get(r, "new"))
+	}
+
+	// converged replicas have the same tree
+	n, err = a.cs.AntiEntropy(ctx, client(t, b.cs.Addr))
+	require.NoError(t, err)
+	require.Zero(t, n)
+	s, err := a.cs.Stats(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, s.AntiEntropyRounds)
+	require.EqualValues(t, 2, s.AntiEntropyRepairedKeys)
+	require.NotZero(t, s.AntiEntropyDivergedLeaves)
+}
+
+func client(t *testing.T, addr string) pb.CacheClient {
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewCacheClient(conn)
+}
@@ -0,0 +1,126 @@
+package replication
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/althk/ganache/cacheserver/internal/service"
+	pb "github.com/althk/ganache/cacheserver/proto"
+	"github.com/althk/ganache/utils/sharding"
+	"github.com/rs/zerolog/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/naming/endpoints"
+	"google.golang.org/protobuf/proto"
+)
+
+// PeerSetter is told the addresses of the shard's replicas whenever they
+// change.
+type PeerSetter interface {
+	SetPeers(addrs []string)
+}
+
+// WatchPeers keeps each of ps in sync with the servers registered in the
+// shard's resolver prefix, e.g. ganache/cacheserver/<shard>, until ctx is
+// done.
+func WatchPeers(ctx context.Context, etcdc *clientv3.Client, shardPrefix string, ps ...PeerSetter) {
+	em, err := endpoints.NewManager(etcdc, shardPrefix)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to watch replication peers")
+		return
+	}
+	for ctx.Err() == nil {
+		wch, err := em.NewWatchChannel(ctx)
+		if err != nil {
+			log.Error().Err(err).Str("prefix", shardPrefix).Msg("Failed to watch replication peers")
+		} else {
+			// the first update lists the servers already registered
+			addrs := make(map[string]string) // by resolver key
+			for ups := range wch {
+				for _, up := range ups {
+					switch up.Op {
+					case endpoints.Add:
+						addrs[up.Key] = up.Endpoint.Addr
+					case endpoints.Delete:
+						delete(addrs, up.Key)
+					}
+				}
+				list := make([]string, 0, len(addrs))
+				for _, a := range addrs {
+					list = append(list, a)
+				}
+				for _, p := range ps {
+					p.SetPeers(list)
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryWait):
+		}
+	}
+}
+
+// ListPeers returns the addresses of the servers registered in the shard's
+// resolver prefix.
+func ListPeers(ctx context.Context, etcdc *clientv3.Client, shardPrefix string) ([]string, error) {
+	em, err := endpoints.NewManager(etcdc, shardPrefix)
+	if err != nil {
+		return nil, err
+	}
+	eps, err := em.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, 0, len(eps))
+	for _, ep := range eps {
+		addrs = append(addrs, ep.Addr)
+	}
+	return addrs, nil
+}
+
+// SyncFromPeer copies every entry of another replica of the shard into cs,
+// trying the current peers until one succeeds. It returns false if none
+// did, e.g. because cs is the shard's first replica.
+func SyncFromPeer(ctx context.Context, cs *service.CacheServer, shard int32, p *Peers) bool {
+	for _, pr := range p.snapshot() {
+		n, err := transferAll(ctx, cs, shard, pb.NewCacheClient(pr.conn))
+		if err != nil {
+			log.Warn().Err(err).Str("peer", pr.addr).Msg("Failed to sync from peer")
+			continue
+		}
+		log.Info().Str("peer", pr.addr).Int("keys", n).Msg("Synced from peer")
+		return true
+	}
+	return false
+}
+
+// transferAll streams the whole cache of a peer through Transfer, with a
+// shard map that maps every key to the shard, until cs's cache is full.
+func transferAll(ctx context.Context, cs *service.CacheServer, shard int32, c pb.CacheClient) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.Transfer(ctx, &pb.TransferRequest{
+		Shard:   shard,
+		Picker:  sharding.Mod,
+		Weights: map[int32]int32{shard: 1},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	for {
+		m, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return n, nil
+			}
+			return n, err
+		}
+		if !cs.Load(m.Key, m.Value, proto.Size(m.Value)) {
+			log.Warn().Int("keys", n).Msg("Cache is full, stopped syncing from peer")
+			return n, nil
+		}
+		n++
+	}
+}
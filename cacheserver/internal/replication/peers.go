@@ -0,0 +1,239 @@
+// Package replication replicates a cache server's writes directly to the
+// other replicas of its shard, instead of through etcd.
+package replication
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/althk/ganache/cacheserver/proto"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// MaxBatchEntries is the most writes sent to a peer in one batch.
+	MaxBatchEntries = 500
+	// MaxPendingEntries is the most writes queued for a peer by default.
+	// Once a peer falls this far behind, writes wait for room.
+	MaxPendingEntries = 10000
+	// QueueTimeout is how long a write waits for room by default before it
+	// fails with Unavailable.
+	QueueTimeout     = 2 * time.Second
+	retryWait        = time.Second
+	waitPollInterval = 10 * time.Millisecond
+)
+
+var errStreamClosed = errors.New("replication stream closed")
+
+// Peers sends writes to every known peer over its Replicate stream. Each
+// peer has its own queue and stream, so a slow peer does not hold back the
+// others.
+type Peers struct {
+	// Addr is this server's address, used as the source of the batches.
+	Addr string
+	// Dial connects to a peer.
+	Dial func(addr string) (*grpc.ClientConn, error)
+	// QueueSize is the most writes queued for a peer, MaxPendingEntries if
+	// 0.
+	QueueSize int
+	// Timeout is how long a write waits for room in a peer's queue,
+	// QueueTimeout if 0.
+	Timeout time.Duration
+
+	l     sync.Mutex
+	peers map[string]*peer
+}
+
+type peer struct {
+	addr   string
+	conn   *grpc.ClientConn
+	queue  chan *pb.ReplicationEntry
+	queued int32 // entries queued or being sent, updated atomically
+	cancel context.CancelFunc
+	done   chan struct{}
+	unsent *pb.ReplicationBatch // sent again on the next stream, only used by run
+}
+
+// Replicate queues v, the new value of key k, for every peer. It waits for
+// room in the queues of peers that are behind, and fails with Unavailable
+// if a queue stays full for Timeout: the peers queued before may get the
+// write, the others have to be repaired by anti-entropy.
+func (p *Peers) Replicate(ctx context.Context, k string, v *pb.CacheValue, _ time.Duration) error {
+	return p.enqueue(ctx, &pb.ReplicationEntry{Key: k, Value: v})
+}
+
+// Delete queues the deletion of k at ts for every peer, like Replicate.
+func (p *Peers) Delete(ctx context.Context, k string, ts *pb.HLC) error {
+	return p.enqueue(ctx, &pb.ReplicationEntry{Key: k, Deleted: true, DeletedAt: ts})
+}
+
+func (p *Peers) enqueue(ctx context.Context, e *pb.ReplicationEntry) error {
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = QueueTimeout
+	}
+	qctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for _, pr := range p.snapshot() {
+		atomic.AddInt32(&pr.queued, 1)
+		select {
+		case pr.queue <- e:
+		case <-pr.done:
+			atomic.AddInt32(&pr.queued, -1)
+		case <-qctx.Done():
+			atomic.AddInt32(&pr.queued, -1)
+			if err := ctx.Err(); err != nil {
+				return status.FromContextError(err).Err()
+			}
+			log.Warn().Str("peer", pr.addr).Msg("Replication queue full")
+			return status.Errorf(codes.Unavailable, "Replication queue of peer %v is full", pr.addr)
+		}
+	}
+	return nil
+}
+
+// Wait waits until the writes queued for the current peers have been sent.
+func (p *Peers) Wait(ctx context.Context) error {
+	for {
+		var n int
+		for _, pr := range p.snapshot() {
+			n += int(atomic.LoadInt32(&pr.queued))
+		}
+		if n == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(waitPollInterval):
+		}
+	}
+}
+
+// SetPeers replaces the set of peers with addrs, this server's own address
+// is skipped. Streams to removed peers are closed and their queued writes
+// dropped.
+func (p *Peers) SetPeers(addrs []string) {
+	want := make(map[string]bool, len(addrs))
+	for _, a := range addrs {
+		if a != p.Addr {
+			want[a] = true
+		}
+	}
+	p.l.Lock()
+	defer p.l.Unlock()
+	if p.peers == nil {
+		p.peers = make(map[string]*peer)
+	}
+	for a, pr := range p.peers {
+		if !want[a] {
+			log.Info().Str("peer", a).Msg("Replication peer removed")
+			delete(p.peers, a)
+			pr.cancel()
+		}
+	}
+	for a := range want {
+		if _, ok := p.peers[a]; ok {
+			continue
+		}
+		conn, err := p.Dial(a)
+		if err != nil {
+			log.Error().Err(err).Str("peer", a).Msg("Failed to connect to replication peer")
+			continue
+		}
+		size := p.QueueSize
+		if size == 0 {
+			size = MaxPendingEntries
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		pr := &peer{
+			addr:   a,
+			conn:   conn,
+			queue:  make(chan *pb.ReplicationEntry, size),
+			cancel: cancel,
+			done:   make(chan struct{}),
+		}
+		p.peers[a] = pr
+		log.Info().Str("peer", a).Msg("Replication peer added")
+		go p.run(ctx, pr)
+	}
+}
+
+func (p *Peers) snapshot() []*peer {
+	p.l.Lock()
+	defer p.l.Unlock()
+	peers := make([]*peer, 0, len(p.peers))
+	for _, pr := range p.peers {
+		peers = append(peers, pr)
+	}
+	return peers
+}
+
+// run streams pr's queue to it until ctx is done, opening a new stream
+// whenever one fails. A batch that fails to send is sent again on the next
+// stream.
+func (p *Peers) run(ctx context.Context, pr *peer) {
+	defer func() {
+		close(pr.done)
+		pr.conn.Close()
+	}()
+	c := pb.NewCacheClient(pr.conn)
+	for ctx.Err() == nil {
+		err := p.stream(ctx, c, pr)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warn().Err(err).Str("peer", pr.addr).Msg("Replication stream failed")
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryWait):
+		}
+	}
+}
+
+func (p *Peers) stream(ctx context.Context, c pb.CacheClient, pr *peer) error {
+	stream, err := c.Replicate(ctx)
+	if err != nil {
+		return err
+	}
+	for {
+		if pr.unsent == nil {
+			var e *pb.ReplicationEntry
+			select {
+			case <-ctx.Done():
+				_, err := stream.CloseAndRecv()
+				return err
+			case e = <-pr.queue:
+			}
+			pr.unsent = &pb.ReplicationBatch{Source: p.Addr, Entries: []*pb.ReplicationEntry{e}}
+		fill:
+			for len(pr.unsent.Entries) < MaxBatchEntries {
+				select {
+				case e = <-pr.queue:
+					pr.unsent.Entries = append(pr.unsent.Entries, e)
+				default:
+					break fill
+				}
+			}
+		}
+		// blocks while the peer is not keeping up, the queue fills meanwhile
+		if err := stream.Send(pr.unsent); err != nil {
+			// the peer's error, not io.EOF, comes from CloseAndRecv
+			if _, rerr := stream.CloseAndRecv(); rerr != nil {
+				err = rerr
+			}
+			if status.Code(err) == codes.OK {
+				err = errStreamClosed
+			}
+			return err
+		}
+		atomic.AddInt32(&pr.queued, -int32(len(pr.unsent.Entries)))
+		pr.unsent = nil
+	}
+}
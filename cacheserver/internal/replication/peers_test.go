@@ -0,0 +1,180 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/althk/ganache/cacheserver/internal/service"
+	"github.com/althk/ganache/cacheserver/internal/strategy"
+	pb "github.com/althk/ganache/cacheserver/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestPeersReplicate(t *testing.T) {
+	a := testServer(t)
+	b := testServer(t)
+	a.peers.SetPeers([]string{a.cs.Addr, b.cs.Addr})
+	b.peers.SetPeers([]string{a.cs.Addr, b.cs.Addr})
+	ctx := context.Background()
+
+	data, _ := anypb.New(wrapperspb.String("v1"))
+	_, err := a.cs.Set(ctx, &pb.SetRequest{Namespace: "ns", Key: "k1", Data: data})
+	require.NoError(t, err)
+	require.NoError(t, a.cs.WaitForPendingWrites(ctx))
+	require.Eventually(t, func() bool {
+		_, err := b.cs.Get(ctx, &pb.GetRequest{Namespace: "ns", Key: "k1"})
+		return err == nil
+	}, time.Second, time.Millisecond)
+
+	_, err = b.cs.Delete(ctx, &pb.DeleteRequest{Namespace: "ns", Key: "k1"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		_, err := a.cs.Get(ctx, &pb.GetRequest{Namespace: "ns", Key: "k1"})
+		return err != nil
+	}, time.Second, time.Millisecond)
+
+	// removed peers no longer get writes
+	a.peers.SetPeers([]string{a.cs.Addr})
+	_, err = a.cs.Set(ctx, &pb.SetRequest{Namespace: "ns", Key: "k2", Data: data})
+	require.NoError(t, err)
+	require.NoError(t, a.cs.WaitForPendingWrites(ctx))
+	time.Sleep(10 * time.Millisecond)
+	_, err = b.cs.Get(ctx, &pb.GetRequest{Namespace: "ns", Key: "k2"})
+	require.Error(t, err)
+}
+
+func TestSyncFromPeer(t *testing.T) {
+	a := testServer(t)
+	ctx := context.Background()
+	data, _ := anypb.New(wrapperspb.String("v1"))
+	for _, k := range []string{"k1", "k2", "k3"} {
+		_, err := a.cs.Set(ctx, &pb.SetRequest{Namespace: "ns", Key: k, Data: data})
+		require.NoError(t, err)
+	}
+
+	b := testServer(t)
+	require.False(t, SyncFromPeer(ctx, b.cs, 0, b.peers))
+	b.peers.SetPeers([]string{a.cs.Addr})
+	require.True(t, SyncFromPeer(ctx, b.cs, 0, b.peers))
+	require.EqualValues(t, 3, b.cs.Cache.Count(ctx))
+}
+
+func TestPeersReplicateWaitsForRoom(t *testing.T) {
+	a := testServer(t, func(p *Peers) { p.QueueSize = 1 })
+	b := testServer(t)
+	a.peers.SetPeers([]string{a.cs.Addr, b.cs.Addr})
+	ctx := context.Background()
+	data, _ := anypb.New(wrapperspb.String("v1"))
+
+	// far more writes than fit in the queue, from several goroutines
+	var l sync.Mutex
+	var acked []string
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				k := fmt.Sprintf("k%d_%d", g, i)
+				if _, err := a.cs.Set(ctx, &pb.SetRequest{Namespace: "ns", Key: k, Data: data}); err != nil {
+					t.Errorf("Set %v: %v", k, err)
+					continue
+				}
+				l.Lock()
+				acked = append(acked, k)
+				l.Unlock()
+			}
+		}(g)
+	}
+	wg.Wait()
+	require.NoError(t, a.cs.WaitForPendingWrites(ctx))
+	require.Eventually(t, func() bool {
+		return b.cs.Cache.Count(ctx) == int64(len(acked))
+	}, time.Second, time.Millisecond)
+	for _, k := range acked {
+		_, err := b.cs.Get(ctx, &pb.GetRequest{Namespace: "ns", Key: k})
+		require.NoError(t, err, "acknowledged write of %v is missing from the peer", k)
+	}
+}
+
+func TestPeersReplicateFailsWhenPeerIsDown(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	down := lis.Addr().String()
+	require.NoError(t, lis.Close())
+	a := testServer(t, func(p *Peers) {
+		p.QueueSize = 1
+		p.Timeout = 50 * time.Millisecond
+	})
+	a.peers.SetPeers([]string{a.cs.Addr, down})
+	ctx := context.Background()
+	data, _ := anypb.New(wrapperspb.String("v1"))
+
+	_, err = a.cs.Set(ctx, &pb.SetRequest{Namespace: "ns", Key: "k1", Data: data})
+	require.NoError(t, err)
+	_, err = a.cs.Set(ctx, &pb.SetRequest{Namespace: "ns", Key: "k2", Data: data})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = a.cs.Delete(ctx, &pb.DeleteRequest{Namespace: "ns", Key: "k2"})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	// the queued write reaches the peer once it is up
+	lis, err = net.Listen("tcp", down)
+	require.NoError(t, err)
+	b := serveReplica(t, lis)
+	require.Eventually(t, func() bool {
+		_, err := b.cs.Get(ctx, &pb.GetRequest{Namespace: "ns", Key: "k1"})
+		return err == nil
+	}, 5*retryWait, 10*time.Millisecond)
+}
+
+type testReplica struct {
+	cs      *service.CacheServer
+	peers   *Peers
+	primary *Primary
+}
+
+// testServer starts a cache server with peer replication on a local port,
+// configured by opts.
+func testServer(t *testing.T, opts ...func(p *Peers)) *testReplica {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	return serveReplica(t, lis, opts...)
+}
+
+func serveReplica(t *testing.T, lis net.Listener, opts ...func(p *Peers)) *testReplica {
+	peers := &Peers{
+		Addr: lis.Addr().String(),
+		Dial: func(addr string) (*grpc.ClientConn, error) {
+			return grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		},
+	}
+	for _, opt := range opts {
+		opt(peers)
+	}
+	primary := &Primary{Addr: peers.Addr, Dial: peers.Dial}
+	cs := &service.CacheServer{
+		Cache:      strategy.NewLRUCache(1 << 20),
+		Replicator: peers,
+		Primary:    primary,
+		Addr:       lis.Addr().String(),
+	}
+	s := grpc.NewServer()
+	pb.RegisterCacheServer(s, cs)
+	go s.Serve(lis)
+	t.Cleanup(func() {
+		peers.SetPeers(nil)
+		primary.SetPeers(nil)
+		s.Stop()
+	})
+	return &testReplica{cs: cs, peers: peers, primary: primary}
+}
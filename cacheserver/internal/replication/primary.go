@@ -0,0 +1,69 @@
+package replication
+
+import (
+	"sync"
+
+	pb "github.com/althk/ganache/cacheserver/proto"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Primary picks the shard's primary for CompareAndSet: the replica with the
+// lowest address among the registered ones and this server. Replicas that
+// have not seen the same membership change yet may briefly pick different
+// primaries.
+type Primary struct {
+	// Addr is this server's address.
+	Addr string
+	// Dial connects to the primary.
+	Dial func(addr string) (*grpc.ClientConn, error)
+
+	l    sync.Mutex
+	addr string // of the current primary
+	conn *grpc.ClientConn
+}
+
+// SetPeers picks the primary among the replicas at addrs and this server.
+func (p *Primary) SetPeers(addrs []string) {
+	primary := p.Addr
+	for _, a := range addrs {
+		if a < primary {
+			primary = a
+		}
+	}
+	p.l.Lock()
+	defer p.l.Unlock()
+	if primary == p.addr {
+		return
+	}
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+	}
+	p.addr = primary
+	log.Info().Str("primary", primary).Msg("Shard primary changed")
+	if primary == p.Addr {
+		return
+	}
+	conn, err := p.Dial(primary)
+	if err != nil {
+		log.Error().Err(err).Str("primary", primary).Msg("Failed to connect to shard primary")
+		return
+	}
+	p.conn = conn
+}
+
+// Client returns a client for the primary, nil if it is this server.
+func (p *Primary) Client() (pb.CacheClient, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+	switch {
+	case p.addr == "" || p.addr == p.Addr:
+		return nil, nil
+	case p.conn == nil:
+		return nil, status.Errorf(codes.Unavailable, "Not connected to shard primary %v", p.addr)
+	}
+	return pb.NewCacheClient(p.conn), nil
+}
@@ -0,0 +1,49 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	pb "github.com/althk/ganache/cacheserver/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestPrimaryCompareAndSet(t *testing.T) {
+	a := testServer(t)
+	b := testServer(t)
+	addrs := []string{a.cs.Addr, b.cs.Addr}
+	for _, r := range []*testReplica{a, b} {
+		r.peers.SetPeers(addrs)
+		r.primary.SetPeers(addrs)
+	}
+	ctx := context.Background()
+	data, _ := anypb.New(wrapperspb.String("v1"))
+
+	// swaps of the same key sent to both replicas at once
+	for i := 0; i < 20; i++ {
+		req := &pb.CompareAndSetRequest{Namespace: "ns", Key: fmt.Sprintf("k%d", i), Data: data}
+		var wg sync.WaitGroup
+		var swapped int32
+		for _, r := range []*testReplica{a, b} {
+			wg.Add(1)
+			go func(r *testReplica) {
+				defer wg.Done()
+				_, err := r.cs.CompareAndSet(ctx, req)
+				if err == nil {
+					atomic.AddInt32(&swapped, 1)
+				} else if status.Code(err) != codes.Aborted {
+					t.Errorf("CompareAndSet on %v: %v", r.cs.Addr, err)
+				}
+			}(r)
+		}
+		wg.Wait()
+		require.EqualValues(t, 1, swapped, "key %v", req.Key)
+	}
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/althk/ganache/cacheserver/internal/config"
+	csmpb "github.com/althk/ganache/csm/proto"
+	"github.com/rs/zerolog/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+)
+
+const reregisterWait = time.Second
+
+// Registration is the cache server's entry in its shard's resolver. CSM
+// binds it to an etcd lease which is kept alive here, so the entry goes
+// away on its own if the server dies without deregistering.
+type Registration struct {
+	cscfg  *config.CSConfig
+	etcd   *clientv3.Client
+	conn   *grpc.ClientConn
+	csm    csmpb.ShardManagerClient
+	lease  clientv3.LeaseID // owned by keepAlive until done is closed
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func registerWithCSM(cscfg *config.CSConfig, etcdc *clientv3.Client) (*Registration, error) {
+	opts, err := cscfg.ServerConfig.GetGRPCDialOpts()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.Dial(cscfg.CSMSpec, opts...)
+	if err != nil {
+		return nil, err
+	}
+	r := &Registration{
+		cscfg: cscfg,
+		etcd:  etcdc,
+		conn:  conn,
+		csm:   csmpb.NewShardManagerClient(conn),
+		done:  make(chan struct{}),
+	}
+	if r.lease, err = r.register(context.Background()); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	go r.keepAlive(ctx)
+	return r, nil
+}
+
+func (r *Registration) register(ctx context.Context) (clientv3.LeaseID, error) {
+	resp, err := r.csm.RegisterCacheServer(ctx, &csmpb.RegisterCacheServerRequest{
+		ServerSpec:      r.cscfg.Addr,
+		Shard:           int64(r.cscfg.Shard),
+		ReplicationMode: r.cscfg.ReplicationMode,
+	})
+	if err != nil {
+		return clientv3.NoLease, err
+	}
+	log.Info().
+		Int64("lease_ttl", resp.LeaseTtlSeconds).
+		Msgf("cache service.CacheServer registered at path %v", resp.RegisteredPath)
+	return clientv3.LeaseID(resp.LeaseId), nil
+}
+
+// keepAlive keeps the registration lease alive until ctx is done. If the
+// lease is lost, e.g. because etcd could not be reached for longer than its
+// TTL, the server registers again.
+func (r *Registration) keepAlive(ctx context.Context) {
+	defer close(r.done)
+	for ctx.Err() == nil {
+		ch, err := r.etcd.KeepAlive(ctx, r.lease)
+		if err == nil {
+			for range ch {
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warn().Err(err).Msg("Registration lease lost, registering again")
+		for ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(reregisterWait):
+			}
+			lease, err := r.register(ctx)
+			if err == nil {
+				r.lease = lease
+				break
+			}
+			log.Error().Err(err).Msg("Failed to register with CSM")
+		}
+	}
+}
+
+// Deregister removes the server from its shard's resolver, new requests
+// stop being routed to it once CFE's resolver catches up.
+func (r *Registration) Deregister(ctx context.Context) error {
+	r.cancel()
+	<-r.done
+	defer r.conn.Close()
+	_, err := r.csm.DeregisterCacheServer(ctx, &csmpb.DeregisterCacheServerRequest{
+		ServerSpec: r.cscfg.Addr,
+		Shard:      int64(r.cscfg.Shard),
+	})
+	// revoking the lease removes the entry even if CSM could not be reached
+	if _, rerr := r.etcd.Revoke(ctx, r.lease); rerr != nil && err == nil {
+		err = rerr
+	}
+	if err == nil {
+		log.Info().Msg("Deregistered from CSM")
+	}
+	return err
+}
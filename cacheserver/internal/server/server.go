@@ -2,54 +2,134 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/althk/ganache/cacheserver/internal/config"
+	"github.com/althk/ganache/cacheserver/internal/replication"
 	"github.com/althk/ganache/cacheserver/internal/service"
 	"github.com/althk/ganache/cacheserver/internal/strategy"
 	csync "github.com/althk/ganache/cacheserver/internal/sync"
-	csmpb "github.com/althk/ganache/csm/proto"
 	etcdutils "github.com/althk/ganache/utils/etcd"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
 )
 
-func registerWithCSM(cscfg *config.CSConfig) error {
-	opts, err := cscfg.ServerConfig.GetGRPCDialOpts()
+// cache is a caching strategy that supports background expiry.
+type cache interface {
+	service.CachingStrategy
+	strategy.Expirer
+}
+
+func newCache(policy string, maxBytes int64) (cache, error) {
+	switch policy {
+	case strategy.LRU:
+		return strategy.NewLRUCache(maxBytes), nil
+	case strategy.LFU:
+		return strategy.NewLFUCache(maxBytes), nil
+	case strategy.TinyLFU:
+		return strategy.NewTinyLFUCache(maxBytes), nil
+	case strategy.ARC:
+		return strategy.NewARCCache(maxBytes), nil
+	}
+	return nil, strategy.ErrUnknownPolicy
+}
+
+// New returns a cache server with an empty cache, Start must be called
+// before it is ready to serve. Its background work stops when ctx is done.
+func New(ctx context.Context, cscfg *config.CSConfig) (*service.CacheServer, error) {
+	c, err := newCache(cscfg.EvictionPolicy, cscfg.MaxCacheBytes)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	conn, err := grpc.Dial(cscfg.CSMSpec, opts...)
+	log.Info().Msgf("Using %v eviction policy", cscfg.EvictionPolicy)
+	etcdc, err := etcdutils.V3Client(cscfg.ETCDSpec)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	cli := csmpb.NewShardManagerClient(conn)
-	resp, err := cli.RegisterCacheServer(context.Background(), &csmpb.RegisterCacheServerRequest{
-		ServerSpec: cscfg.Addr,
-		Shard:      int64(cscfg.Shard),
-	})
+	cacheServer, err := service.NewCacheServer(cscfg, c, etcdc)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	switch cscfg.ReplicationMode {
+	case config.ReplicationEtcd, "":
+	case config.ReplicationPeer:
+		cacheServer.Replicator = &replication.Peers{
+			Addr: cscfg.Addr,
+			Dial: dialer(cscfg),
+		}
+	default:
+		return nil, fmt.Errorf("unknown replication mode %q", cscfg.ReplicationMode)
+	}
+	cacheServer.Primary = &replication.Primary{
+		Addr: cscfg.Addr,
+		Dial: dialer(cscfg),
 	}
-	log.Info().Msgf("cache service.CacheServer registered at path %v", resp.RegisteredPath)
-	defer conn.Close()
-	return nil
+	log.Info().Msgf("Using %v replication", cscfg.ReplicationMode)
+	go strategy.RunExpirySweeper(ctx, c, cscfg.ExpirySweepInterval, cacheServer.KeysExpired)
+	return cacheServer, nil
 }
 
-func New(cscfg *config.CSConfig) (*service.CacheServer, error) {
-	lru := strategy.NewLRUCache(cscfg.MaxCacheBytes)
-	etcdc, err := etcdutils.V3Client(cscfg.ETCDSpec)
+// dialer connects to the other replicas of the shard.
+func dialer(cscfg *config.CSConfig) func(addr string) (*grpc.ClientConn, error) {
+	return func(addr string) (*grpc.ClientConn, error) {
+		opts, err := cscfg.ServerConfig.GetGRPCDialOpts()
+		if err != nil {
+			return nil, err
+		}
+		return grpc.Dial(addr, opts...)
+	}
+}
+
+// Start syncs the shard from etcd and then registers the server with CSM,
+// the registration must be removed with Deregister when the server stops.
+// The server is ready once Start returns.
+//
+// With peer replication the server registers first, so that the other
+// replicas start sending it their writes, and then copies the shard from
+// one of them. Either way it then follows the shard's membership to know
+// which replica is the primary for CompareAndSet.
+//
+// Anti-entropy, if enabled, starts repairing the server from its peers
+// after the first interval and stops when ctx is done.
+func Start(ctx context.Context, cscfg *config.CSConfig, cacheServer *service.CacheServer) (*Registration, error) {
+	prefix := strings.Join([]string{cscfg.CSResolverPrefix, fmt.Sprint(cscfg.Shard)}, "/")
+	if cscfg.AntiEntropyInterval > 0 {
+		ae := &replication.AntiEntropy{
+			CS: cacheServer,
+			Peers: func(ctx context.Context) ([]string, error) {
+				return replication.ListPeers(ctx, cacheServer.Etcd, prefix)
+			},
+			Dial:     dialer(cscfg),
+			Interval: cscfg.AntiEntropyInterval,
+		}
+		go ae.Run(ctx)
+	}
+	peers, ok := cacheServer.Replicator.(*replication.Peers)
+	if !ok {
+		if err := csync.InitWatchAndSync(cacheServer); err != nil {
+			return nil, err
+		}
+	}
+	reg, err := registerWithCSM(cscfg, cacheServer.Etcd)
 	if err != nil {
 		return nil, err
 	}
-	cacheServer, err := service.NewCacheServer(cscfg, lru, etcdc)
+	addrs, err := replication.ListPeers(context.Background(), cacheServer.Etcd, prefix)
 	if err != nil {
+		reg.Deregister(context.Background())
 		return nil, err
 	}
-	if err = csync.InitWatchAndSync(cacheServer); err != nil {
-		return nil, err
+	setters := []replication.PeerSetter{cacheServer.Primary.(*replication.Primary)}
+	if ok {
+		setters = append(setters, peers)
 	}
-	if err = registerWithCSM(cscfg); err != nil {
-		return nil, err
+	for _, p := range setters {
+		p.SetPeers(addrs)
 	}
-	return cacheServer, nil
+	go replication.WatchPeers(context.Background(), cacheServer.Etcd, prefix, setters...)
+	if ok && !replication.SyncFromPeer(context.Background(), cacheServer, cscfg.Shard, peers) {
+		log.Info().Msg("No peer to sync from, starting with an empty cache")
+	}
+	return reg, nil
 }
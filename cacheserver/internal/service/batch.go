@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+
+	pb "github.com/althk/ganache/cacheserver/proto"
+	"google.golang.org/grpc/status"
+)
+
+// MGet looks up every key in the batch, a miss is reported as NotFound in
+// the key's result.
+func (s *CacheServer) MGet(ctx context.Context, in *pb.MGetRequest) (*pb.MGetResponse, error) {
+	resp := &pb.MGetResponse{Results: make([]*pb.MGetResult, len(in.Keys))}
+	for i, k := range in.Keys {
+		r, err := s.Get(ctx, k)
+		resp.Results[i] = &pb.MGetResult{
+			Data:    r.GetData(),
+			Status:  keyStatus(err),
+			Version: r.GetVersion(),
+		}
+	}
+	return resp, nil
+}
+
+// MSet caches every item in the batch, each is replicated like a single Set.
+func (s *CacheServer) MSet(ctx context.Context, in *pb.MSetRequest) (*pb.MSetResponse, error) {
+	resp := &pb.MSetResponse{Statuses: make([]*pb.KeyStatus, len(in.Items))}
+	for i, it := range in.Items {
+		_, err := s.Set(ctx, it)
+		resp.Statuses[i] = keyStatus(err)
+	}
+	return resp, nil
+}
+
+// MDelete deletes every key in the batch.
+func (s *CacheServer) MDelete(ctx context.Context, in *pb.MDeleteRequest) (*pb.MDeleteResponse, error) {
+	resp := &pb.MDeleteResponse{Statuses: make([]*pb.KeyStatus, len(in.Keys))}
+	for i, k := range in.Keys {
+		_, err := s.Delete(ctx, k)
+		resp.Statuses[i] = keyStatus(err)
+	}
+	return resp, nil
+}
+
+func keyStatus(err error) *pb.KeyStatus {
+	st := status.Convert(err)
+	return &pb.KeyStatus{
+		Code:    int32(st.Code()),
+		Message: st.Message(),
+	}
+}
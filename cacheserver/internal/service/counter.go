@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/althk/ganache/cacheserver/internal/strategy"
+	pb "github.com/althk/ganache/cacheserver/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Increment adds in.Delta to the key's Int64Value. The change is recorded
+// in the entry's counter under this server's address, replicas merge the
+// counters they sync instead of keeping the last write, so increments on
+// different replicas of the shard all count. Global keys cannot be
+// incremented.
+func (s *CacheServer) Increment(ctx context.Context, in *pb.IncrementRequest) (*pb.IncrementResponse, error) {
+	atomic.AddUint64(&s.counters.sets, 1)
+	k := s.key(in.Namespace, in.Key)
+	if in.Ttl != nil {
+		if err := in.Ttl.CheckValid(); err != nil || in.Ttl.AsDuration() <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid ttl %v for key %v", in.Ttl, in.Key)
+		}
+	}
+	var v *pb.CacheValue
+	_, err := s.Cache.Update(ctx, k, func(curr *pb.CacheValue) (*pb.CacheValue, error) {
+		now := s.clock.WallTime()
+		if strategy.Expired(curr, now) {
+			curr = nil
+		}
+		if curr.GetGlobal() {
+			return nil, globalError(in.Key)
+		}
+		if curr == nil && in.MustExist {
+			return nil, status.Errorf(codes.NotFound, "Cache miss for key %v", in.Key)
+		}
+		c, err := counterOf(curr, in.Initial)
+		if err != nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "Key %v does not hold an int64: %v", in.Key, err)
+		}
+		val, _ := counterValue(c)
+		if (in.Delta > 0 && val > math.MaxInt64-in.Delta) || (in.Delta < 0 && val < math.MinInt64-in.Delta) {
+			return nil, status.Errorf(codes.OutOfRange, "Incrementing key %v by %d overflows int64", in.Key, in.Delta)
+		}
+		if in.Delta >= 0 {
+			c.Incs[s.Addr] += uint64(in.Delta)
+		} else {
+			// also right for math.MinInt64, whose negation wraps to itself
+			c.Decs[s.Addr] += uint64(-in.Delta)
+		}
+		v = &pb.CacheValue{
+			SourceTs:  timestamppb.New(now),
+			ExpiresAt: curr.GetExpiresAt(),
+			Version:   nextVersion(curr, now),
+			Counter:   c,
+			Hlc:       s.clock.Now(),
+		}
+		if curr == nil && in.Ttl != nil {
+			v.ExpiresAt = timestamppb.New(now.Add(in.Ttl.AsDuration()))
+		}
+		v.Data, err = anypb.New(wrapperspb.Int64(val + in.Delta))
+		return v, err
+	})
+	if err != nil {
+		return nil, cacheError(in.Key, err)
+	}
+	s.watchers.publish(k)
+	if err := s.replicate(ctx, k, v, ttlOf(v)); err != nil {
+		return nil, err
+	}
+	val, _ := counterValue(v.Counter)
+	return &pb.IncrementResponse{Value: val}, nil
+}
+
+// counterOf returns a copy of curr's counter, or a new counter starting at
+// curr's value, or at initial if curr is nil. It fails if curr does not
+// hold an Int64Value.
+func counterOf(curr *pb.CacheValue, initial int64) (*pb.Counter, error) {
+	c := &pb.Counter{Base: initial}
+	switch {
+	case curr == nil:
+	case curr.Counter != nil:
+		c = proto.Clone(curr.Counter).(*pb.Counter)
+	default:
+		n := &wrapperspb.Int64Value{}
+		if err := curr.GetData().UnmarshalTo(n); err != nil {
+			return nil, err
+		}
+		c.Epoch, c.Base = curr.Version, n.Value
+	}
+	if c.Incs == nil {
+		c.Incs = make(map[string]uint64)
+	}
+	if c.Decs == nil {
+		c.Decs = make(map[string]uint64)
+	}
+	return c, nil
+}
+
+// counterValue returns the value of c, clamped to the int64 range. The
+// value can only leave the range when concurrent increments on different
+// replicas add up past it, in which case ok is false.
+func counterValue(c *pb.Counter) (v int64, ok bool) {
+	sum := big.NewInt(c.Base)
+	for _, n := range c.Incs {
+		sum.Add(sum, new(big.Int).SetUint64(n))
+	}
+	for _, n := range c.Decs {
+		sum.Sub(sum, new(big.Int).SetUint64(n))
+	}
+	switch {
+	case sum.IsInt64():
+		return sum.Int64(), true
+	case sum.Sign() > 0:
+		return math.MaxInt64, false
+	default:
+		return math.MinInt64, false
+	}
+}
+
+// mergeCounters returns the value holding the merge of a's and b's
+// counters, or nil if they do not have counters of the same epoch. The
+// merge keeps the highest total of each replica, so it does not matter in
+// which order or how often replicas sync each other's values.
+func mergeCounters(a, b *pb.CacheValue) *pb.CacheValue {
+	if a.GetCounter() == nil || b.GetCounter() == nil || a.Counter.Epoch != b.Counter.Epoch {
+		return nil
+	}
+	latest := a
+	if order(b, a) > 0 {
+		latest = b
+	}
+	c := &pb.Counter{
+		Epoch: a.Counter.Epoch,
+		// counters created concurrently from different initial values
+		Base: max64(a.Counter.Base, b.Counter.Base),
+		Incs: mergeTotals(a.Counter.Incs, b.Counter.Incs),
+		Decs: mergeTotals(a.Counter.Decs, b.Counter.Decs),
+	}
+	val, _ := counterValue(c)
+	data, _ := anypb.New(wrapperspb.Int64(val))
+	ver := a.Version
+	if b.Version > ver {
+		ver = b.Version
+	}
+	return &pb.CacheValue{
+		Data:      data,
+		SourceTs:  latest.SourceTs,
+		Hlc:       latest.Hlc,
+		ExpiresAt: latest.ExpiresAt,
+		Version:   ver,
+		Counter:   c,
+	}
+}
+
+func mergeTotals(a, b map[string]uint64) map[string]uint64 {
+	m := make(map[string]uint64, len(a))
+	for r, n := range a {
+		m[r] = n
+	}
+	for r, n := range b {
+		if n > m[r] {
+			m[r] = n
+		}
+	}
+	return m
+}
+
+// ttlOf returns how long v has left to live, 0 if it does not expire.
+func ttlOf(v *pb.CacheValue) time.Duration {
+	if v.GetExpiresAt() == nil {
+		return 0
+	}
+	if ttl := time.Until(v.ExpiresAt.AsTime()); ttl > 0 {
+		return ttl
+	}
+	// about to expire, but a lease needs a positive ttl
+	return time.Second
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -3,54 +3,208 @@ package service
 import (
 	"context"
 	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/althk/ganache/cacheserver/internal/config"
+	"github.com/althk/ganache/cacheserver/internal/hlc"
+	"github.com/althk/ganache/cacheserver/internal/strategy"
 	pb "github.com/althk/ganache/cacheserver/proto"
+	etcdutils "github.com/althk/ganache/utils/etcd"
+	"github.com/althk/ganache/utils/sharding"
+	"github.com/rs/zerolog/log"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-const etcdCachePrefix = "ganache/cache"
-
 type CachingStrategy interface {
 	Get(ctx context.Context, key string) (*pb.CacheValue, bool)
 	Set(ctx context.Context, key string, val *pb.CacheValue) (int64, error)
+	Delete(ctx context.Context, key string) bool
 	Count(ctx context.Context) int64
 	CurrSize(ctx context.Context) int64 // size of current cache in bytes
+	// Update caches the value f returns for the current value of key (nil if
+	// not cached), atomically with respect to other writes to the cache. If
+	// f returns a nil value the cache is left as is, if it returns
+	// strategy.Remove the key is deleted.
+	Update(ctx context.Context, key string, f func(curr *pb.CacheValue) (*pb.CacheValue, error)) (int64, error)
+	// Range calls f for every cached entry until f returns false.
+	Range(ctx context.Context, f func(key string, val *pb.CacheValue) bool)
 }
 
 type CacheServer struct {
 	pb.UnimplementedCacheServer
-	Cache    CachingStrategy // cache store
-	Etcd     *clientv3.Client
-	Addr     string
-	shardNum int32
+	Cache CachingStrategy // cache store
+	Etcd  *clientv3.Client
+	// Replicator sends writes to the other replicas of the shard, nil to
+	// replicate through etcd.
+	Replicator Replicator
+	// Primary forwards CompareAndSet to the shard's primary, nil to serve
+	// it on every replica.
+	Primary   Primary
+	Addr      string
+	shardNum  int32
+	counters  counters
+	pending   sync.WaitGroup // replication writes to etcd still in flight
+	etcdOrder keyOrder       // orders the etcd writes of each key
+	clock     hlc.Clock      // orders the writes of the shard's replicas
+	deleted   tombstones     // recent deletes, for anti-entropy
+	trees     merkleTrees    // of the peers' anti-entropy rounds
+	maxBytes  int64          // cache size Load stops at, 0 for no limit
+	watchers  keyWatchers    // WatchKeys streams
+}
+
+// counters tracks request counts, updated atomically.
+type counters struct {
+	gets    uint64
+	sets    uint64
+	deletes uint64
+	hits    uint64
+	misses  uint64
+	// anti-entropy rounds, leaves that differed from the peer's and keys
+	// repaired
+	aeRounds   uint64
+	aeLeaves   uint64
+	aeRepaired uint64
+	loaded     uint64 // keys applied by Load
+	truncated  uint32 // 1 once Load stopped at maxBytes
 }
 
 func (s *CacheServer) Get(ctx context.Context, in *pb.GetRequest) (*pb.GetResponse, error) {
+	atomic.AddUint64(&s.counters.gets, 1)
 	v, e := s.Cache.Get(ctx, s.key(in.Namespace, in.Key))
-	if !e {
+	if !e || strategy.Expired(v, time.Now()) {
+		atomic.AddUint64(&s.counters.misses, 1)
 		return nil, status.Errorf(codes.NotFound, "Cache miss for key %v", in.Key)
 	}
+	atomic.AddUint64(&s.counters.hits, 1)
 	resp := &pb.GetResponse{
-		Data: v.GetData(),
+		Data:    v.GetData(),
+		Version: v.Version,
 	}
 	return resp, nil
 }
 
+// Set caches the value if in.Mode allows it. The mode is checked and the
+// value written under the cache's lock, so of concurrent IF_ABSENT writes to
+// a replica only one succeeds. A global key is only replaced by another
+// global write.
 func (s *CacheServer) Set(ctx context.Context, in *pb.SetRequest) (*emptypb.Empty, error) {
+	atomic.AddUint64(&s.counters.sets, 1)
 	k := s.key(in.Namespace, in.Key)
-	ts := timestamppb.Now()
-	v := &pb.CacheValue{
-		SourceTs: ts,
-		Data:     in.GetData(),
+	v, err := newValue(in.Key, in.GetData(), in.Ttl, s.clock.WallTime())
+	if err != nil {
+		return nil, err
+	}
+	if in.ExpiresAt != nil {
+		if err := in.ExpiresAt.CheckValid(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid expiry %v for key %v", in.ExpiresAt, in.Key)
+		}
+		v.ExpiresAt = in.ExpiresAt
+	}
+	v.Global = in.Global
+	_, err = s.Cache.Update(ctx, k, func(curr *pb.CacheValue) (*pb.CacheValue, error) {
+		now := v.SourceTs.AsTime()
+		exists := curr != nil && !strategy.Expired(curr, now)
+		switch {
+		case exists && curr.Global && !in.Global:
+			return nil, globalError(in.Key)
+		case in.Mode == pb.WriteMode_IF_ABSENT && exists:
+			return nil, status.Errorf(codes.AlreadyExists, "Key %v already exists", in.Key)
+		case in.Mode == pb.WriteMode_IF_PRESENT && !exists:
+			return nil, status.Errorf(codes.NotFound, "Cache miss for key %v", in.Key)
+		}
+		v.Version = nextVersion(curr, now)
+		v.Hlc = s.clock.Now()
+		return v, nil
+	})
+	if err != nil {
+		return nil, cacheError(in.Key, err)
+	}
+	s.watchers.publish(k)
+	if err := s.replicate(ctx, k, v, in.Ttl.AsDuration()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// CompareAndSet sets the key if its version is the expected one. The swap
+// is forwarded to the shard's primary, where the check is atomic, and the
+// other replicas converge on it like they do for Set. Global keys cannot be
+// swapped, only their owner shard would see the swap.
+func (s *CacheServer) CompareAndSet(ctx context.Context, in *pb.CompareAndSetRequest) (*pb.CompareAndSetResponse, error) {
+	if s.Primary != nil && !in.Forwarded {
+		c, err := s.Primary.Client()
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			fwd := proto.Clone(in).(*pb.CompareAndSetRequest)
+			fwd.Forwarded = true
+			return c.CompareAndSet(ctx, fwd)
+		}
+	}
+	atomic.AddUint64(&s.counters.sets, 1)
+	k := s.key(in.Namespace, in.Key)
+	v, err := newValue(in.Key, in.GetData(), in.Ttl, s.clock.WallTime())
+	if err != nil {
+		return nil, err
+	}
+	now := v.SourceTs.AsTime()
+	_, err = s.Cache.Update(ctx, k, func(curr *pb.CacheValue) (*pb.CacheValue, error) {
+		var ver uint64
+		if !strategy.Expired(curr, now) {
+			if curr.GetGlobal() {
+				return nil, globalError(in.Key)
+			}
+			ver = curr.GetVersion()
+		}
+		if ver == 0 && in.MustExist {
+			return nil, status.Errorf(codes.NotFound, "Cache miss for key %v", in.Key)
+		}
+		if ver != in.ExpectedVersion {
+			return nil, status.Errorf(codes.Aborted, "Key %v is at version %d, not %d", in.Key, ver, in.ExpectedVersion)
+		}
+		v.Version = nextVersion(curr, now)
+		v.Hlc = s.clock.Now()
+		return v, nil
+	})
+	if err != nil {
+		return nil, cacheError(in.Key, err)
+	}
+	s.watchers.publish(k)
+	if err := s.replicate(ctx, k, v, in.Ttl.AsDuration()); err != nil {
+		return nil, err
+	}
+	return &pb.CompareAndSetResponse{Version: v.Version}, nil
+}
+
+// replicate writes v to the shard's etcd prefix in the background, after
+// the key's earlier etcd writes, for the other replicas to sync. A ttl above zero puts it under a lease.
+// With a Replicator it sends v to the replicas instead, and fails if some
+// replica cannot take it.
+func (s *CacheServer) replicate(ctx context.Context, k string, v *pb.CacheValue, ttl time.Duration) error {
+	if s.Replicator != nil {
+		if err := s.Replicator.Replicate(ctx, k, v, ttl); err != nil {
+			return status.Errorf(codes.Unavailable, "Error replicating key %v: %v", k, err)
+		}
+		return nil
 	}
-	s.Cache.Set(ctx, k, v)
+	s.pending.Add(1)
+	wait, done := s.etcdOrder.next(k)
 	go func() {
+		defer s.pending.Done()
+		defer done()
+		<-wait
 		rk := s.fullKeyPath(k)
 		m := &pb.CacheKeyMetadata{
 			Source: s.Addr,
@@ -58,29 +212,356 @@ func (s *CacheServer) Set(ctx context.Context, in *pb.SetRequest) (*emptypb.Empt
 			Value:  v,
 		}
 		data, _ := proto.Marshal(m)
-		s.Etcd.Put(context.Background(), rk, string(data))
+		var opts []clientv3.OpOption
+		if ttl > 0 {
+			// the replicated copy goes away with the lease
+			lease, err := s.Etcd.Grant(context.Background(), leaseTTL(ttl))
+			if err != nil {
+				log.Error().Err(err).Msgf("Error granting etcd lease for key %v", rk)
+				return
+			}
+			opts = append(opts, clientv3.WithLease(lease.ID))
+		}
+		s.Etcd.Put(context.Background(), rk, string(data), opts...)
 	}()
+	return nil
+}
+
+// Delete removes the key, a global key only with a global delete.
+func (s *CacheServer) Delete(ctx context.Context, in *pb.DeleteRequest) (*emptypb.Empty, error) {
+	atomic.AddUint64(&s.counters.deletes, 1)
+	k := s.key(in.Namespace, in.Key)
+	ts := s.clock.Now()
+	_, err := s.Cache.Update(ctx, k, func(curr *pb.CacheValue) (*pb.CacheValue, error) {
+		if curr != nil && curr.Global && !in.Global && !strategy.Expired(curr, time.Now()) {
+			return nil, globalError(in.Key)
+		}
+		s.deleted.add(k, ts)
+		return strategy.Remove, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.watchers.publish(k)
+	if s.Replicator != nil {
+		if err := s.Replicator.Delete(ctx, k, ts); err != nil {
+			return nil, status.Errorf(codes.Unavailable, "Error deleting key %v from replicas: %v", in.Key, err)
+		}
+		return &emptypb.Empty{}, nil
+	}
+	// unlike Set, the etcd delete is not fire-and-forget: if it is lost,
+	// replicas (and restarts via sync) would bring the key back. It waits
+	// for the key's earlier Puts, which would bring it back too.
+	// It is written as a tombstone carrying ts rather than an etcd delete,
+	// which has no HLC, so that replicas order it against their own writes.
+	wait, done := s.etcdOrder.next(k)
+	select {
+	case <-wait:
+	case <-ctx.Done():
+		// later writes of the key must still wait for the earlier ones
+		go func() {
+			<-wait
+			done()
+		}()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	defer done()
+	if err := s.putTombstone(ctx, k, ts); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Error deleting key %v from replicas: %v", in.Key, err)
+	}
 	return &emptypb.Empty{}, nil
 }
 
+func (s *CacheServer) Stats(ctx context.Context, _ *emptypb.Empty) (*pb.StatsResponse, error) {
+	gets := atomic.LoadUint64(&s.counters.gets)
+	sets := atomic.LoadUint64(&s.counters.sets)
+	deletes := atomic.LoadUint64(&s.counters.deletes)
+	hits := atomic.LoadUint64(&s.counters.hits)
+	misses := atomic.LoadUint64(&s.counters.misses)
+	var ratio uint64
+	if hits+misses > 0 {
+		ratio = hits * 100 / (hits + misses)
+	}
+	return &pb.StatsResponse{
+		GetReqCount:               gets,
+		SetReqCount:               sets,
+		DeleteReqCount:            deletes,
+		TotalReqCount:             gets + sets + deletes,
+		CacheHitCount:             hits,
+		CacheMissCount:            misses,
+		CacheHitRatio:             ratio,
+		TotalCacheSizeBytes:       uint64(s.Cache.CurrSize(ctx)),
+		TotalKeysCount:            uint64(s.Cache.Count(ctx)),
+		ShardNumber:               s.shardNum,
+		AntiEntropyRounds:         atomic.LoadUint64(&s.counters.aeRounds),
+		AntiEntropyDivergedLeaves: atomic.LoadUint64(&s.counters.aeLeaves),
+		AntiEntropyRepairedKeys:   atomic.LoadUint64(&s.counters.aeRepaired),
+		LoadedKeys:                atomic.LoadUint64(&s.counters.loaded),
+		LoadTruncated:             atomic.LoadUint32(&s.counters.truncated) == 1,
+	}, nil
+}
+
+func (s *CacheServer) Transfer(in *pb.TransferRequest, stream pb.Cache_TransferServer) error {
+	w := make(map[int]int, len(in.Weights))
+	for n, wt := range in.Weights {
+		w[int(n)] = int(wt)
+	}
+	p, err := sharding.NewPicker(in.Picker, w, int(in.Vnodes))
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid shard map: %v", err)
+	}
+	log.Info().Int32("shard", in.Shard).Msg("Transferring keys owned by shard")
+	now := time.Now()
+	var n int
+	s.Cache.Range(stream.Context(), func(k string, v *pb.CacheValue) bool {
+		if strategy.Expired(v, now) || (!v.Global && p.Shard(k) != int(in.Shard)) {
+			return true
+		}
+		err = stream.Send(&pb.CacheKeyMetadata{
+			Source: s.Addr,
+			Key:    k,
+			Value:  v,
+		})
+		n++
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if err = stream.Context().Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	log.Info().Int32("shard", in.Shard).Int("keys", n).Msg("Transfer completed")
+	return nil
+}
+
+// WaitForPendingWrites waits until the etcd writes started by Set are done,
+// so that a server shutting down does not lose them.
+func (s *CacheServer) WaitForPendingWrites(ctx context.Context) error {
+	if s.Replicator != nil {
+		return s.Replicator.Wait(ctx)
+	}
+	done := make(chan struct{})
+	go func() {
+		s.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (s *CacheServer) key(ns, key string) string {
 	return fmt.Sprintf("%s%s", ns, key)
 }
 
 func (s *CacheServer) EtcdShardPrefix() string {
-	return fmt.Sprintf("%s/%d", etcdCachePrefix, s.shardNum)
+	return etcdutils.CacheShardPrefix(int(s.shardNum))
 }
 
 func (s *CacheServer) fullKeyPath(k string) string {
-	return fmt.Sprintf("%s/%s", s.EtcdShardPrefix(), k)
+	return etcdutils.CacheKeyPath(int(s.shardNum), k)
+}
+
+// newValue returns the value to cache for a write of data with an optional
+// ttl, stamped with now.
+func newValue(key string, data *anypb.Any, ttl *durationpb.Duration, now time.Time) (*pb.CacheValue, error) {
+	ts := timestamppb.New(now)
+	v := &pb.CacheValue{
+		SourceTs: ts,
+		Data:     data,
+	}
+	if ttl != nil {
+		if err := ttl.CheckValid(); err != nil || ttl.AsDuration() <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid ttl %v for key %v", ttl, key)
+		}
+		v.ExpiresAt = timestamppb.New(ts.AsTime().Add(ttl.AsDuration()))
+	}
+	return v, nil
+}
+
+// nextVersion returns the version of a value replacing curr (nil if the key
+// is not cached). Versions come from the clock so that a key that is
+// deleted and set again does not reuse them, and are bumped past curr's if
+// the clock is behind.
+func nextVersion(curr *pb.CacheValue, now time.Time) uint64 {
+	v := uint64(now.UnixNano())
+	if curr.GetVersion() >= v {
+		v = curr.GetVersion() + 1
+	}
+	return v
+}
+
+// cacheError returns the status for an error from a cache update, errors
+// that already are statuses come from the update itself.
+func cacheError(key string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Errorf(codes.InvalidArgument, "Error caching key %v: %v", key, err)
+}
+
+// globalError is returned for writes to a global key that would only reach
+// one shard.
+func globalError(key string) error {
+	return status.Errorf(codes.FailedPrecondition, "Key %v is global, it can only be changed by global writes", key)
+}
+
+// order returns -1, 0 or 1 as a was written before, at the same time as or
+// after b.
+func order(a, b *pb.CacheValue) int {
+	return hlc.Compare(hlcOf(a), hlcOf(b))
+}
+
+// hlcOf returns the HLC timestamp of v, values from replicas that predate
+// HLCs only have their wall clock time.
+func hlcOf(v *pb.CacheValue) *pb.HLC {
+	if v.Hlc != nil {
+		return v.Hlc
+	}
+	return &pb.HLC{Physical: v.SourceTs.AsTime().UnixNano()}
+}
+
+// putTombstone writes the tombstone of k, deleted at ts, in place of its
+// etcd key. It goes away with its lease, once replicas forget the delete too.
+func (s *CacheServer) putTombstone(ctx context.Context, k string, ts *pb.HLC) error {
+	data, _ := proto.Marshal(&pb.CacheKeyMetadata{
+		Source:    s.Addr,
+		Key:       k,
+		DeletedAt: ts,
+	})
+	lease, err := s.Etcd.Grant(ctx, leaseTTL(TombstoneTTL))
+	if err != nil {
+		return err
+	}
+	_, err = s.Etcd.Put(ctx, s.fullKeyPath(k), string(data), clientv3.WithLease(lease.ID))
+	return err
+}
+
+// leaseTTL returns the etcd lease TTL in seconds covering d.
+func leaseTTL(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+// CacheKey returns the cache key for the given etcd key path.
+func (s *CacheServer) CacheKey(path string) string {
+	return strings.TrimPrefix(path, s.EtcdShardPrefix()+"/")
+}
+
+// Sync applies a value written by another replica. Counters of the same
+// epoch are merged, otherwise the value written last by the replicas'
+// hybrid logical clocks wins, so every replica keeps the same value no
+// matter how far their wall clocks are apart. If the merged
+// counter has increments the other replica's value was missing, it is
+// replicated back so that etcd, and new replicas syncing from it, have
+// them too. It reports whether the cache changed.
+func (s *CacheServer) Sync(k string, v *pb.CacheValue) bool {
+	merged, changed := s.apply(k, v)
+	if merged != nil && !proto.Equal(merged.Counter, v.Counter) {
+		// replicas that miss the merge are repaired by anti-entropy
+		s.replicate(context.Background(), k, merged, ttlOf(merged))
+	}
+	return changed
+}
+
+// Import applies entries moved to the shard from other shards while
+// resharding. They are ordered against the shard's own writes like Sync
+// orders another replica's, so that writes made on the shard since the
+// migration started, and deletes, win. The entries that changed the cache
+// are replicated to the other replicas.
+func (s *CacheServer) Import(ctx context.Context, in *pb.ImportRequest) (*emptypb.Empty, error) {
+	for _, m := range in.Entries {
+		if m.Value == nil {
+			continue
+		}
+		merged, changed := s.apply(m.Key, m.Value)
+		if !changed {
+			continue
+		}
+		v := m.Value
+		if merged != nil {
+			v = merged
+		}
+		if err := s.replicate(ctx, m.Key, v, ttlOf(v)); err != nil {
+			return nil, err
+		}
+	}
+	return &emptypb.Empty{}, nil
 }
 
-func (s *CacheServer) Sync(k string, v *pb.CacheValue) {
-	curr, exists := s.Cache.Get(context.Background(), k)
-	if exists && curr.SourceTs.AsTime().After(v.SourceTs.AsTime()) {
-		return // local cache has newer value
+// apply caches v, written elsewhere, for key k like Sync does. It returns
+// the merged value if v's counter was merged with the cached one, and
+// whether the cache changed.
+func (s *CacheServer) apply(k string, v *pb.CacheValue) (merged *pb.CacheValue, changed bool) {
+	now := time.Now()
+	if strategy.Expired(v, now) {
+		return nil, false
 	}
-	s.Cache.Set(context.Background(), k, v)
+	// later local writes must win over v
+	s.clock.Observe(v.Hlc)
+	if hlc.Compare(s.deleted.get(k, now), hlcOf(v)) >= 0 {
+		return nil, false // deleted after v was written
+	}
+	s.Cache.Update(context.Background(), k, func(curr *pb.CacheValue) (*pb.CacheValue, error) {
+		if curr == nil {
+			changed = true
+			return v, nil
+		}
+		if merged = mergeCounters(curr, v); merged != nil {
+			changed = !proto.Equal(merged, curr)
+			return merged, nil
+		}
+		if order(curr, v) >= 0 {
+			return nil, nil // local cache has the same or a newer value
+		}
+		changed = true
+		return v, nil
+	})
+	if changed {
+		s.watchers.publish(k)
+	}
+	return merged, changed
+}
+
+// Load applies an entry of the shard while the server starts, size is
+// roughly how many bytes it takes. It returns false, without applying it,
+// once the entry would not fit in the cache, so that loading a shard larger
+// than the cache does not evict the entries loaded before it.
+func (s *CacheServer) Load(k string, v *pb.CacheValue, size int) bool {
+	if s.maxBytes > 0 && s.Cache.CurrSize(context.Background())+int64(size) > s.maxBytes {
+		atomic.StoreUint32(&s.counters.truncated, 1)
+		return false
+	}
+	if s.Sync(k, v) {
+		atomic.AddUint64(&s.counters.loaded, 1)
+	}
+	return true
+}
+
+// SyncDelete removes a key that was deleted on another replica at ts,
+// unless it was written again since. It reports whether the key was cached.
+// A delete without a time cannot be ordered against local writes and is
+// ignored.
+func (s *CacheServer) SyncDelete(k string, ts *pb.HLC) bool {
+	if ts == nil {
+		return false
+	}
+	s.clock.Observe(ts)
+	s.deleted.add(k, ts)
+	// a near cache may hold the key even if this replica does not
+	s.watchers.publish(k)
+	var deleted bool
+	s.Cache.Update(context.Background(), k, func(curr *pb.CacheValue) (*pb.CacheValue, error) {
+		if curr == nil || order(curr, &pb.CacheValue{Hlc: ts}) > 0 {
+			return nil, nil
+		}
+		deleted = true
+		return strategy.Remove, nil
+	})
+	return deleted
 }
 
 func NewCacheServer(cscfg *config.CSConfig, cs CachingStrategy, etcdc *clientv3.Client) (*CacheServer, error) {
@@ -89,5 +570,7 @@ func NewCacheServer(cscfg *config.CSConfig, cs CachingStrategy, etcdc *clientv3.
 		Etcd:     etcdc,
 		Addr:     cscfg.Addr,
 		shardNum: cscfg.Shard,
+		maxBytes: cscfg.MaxCacheBytes,
+		clock:    hlc.Clock{Node: cscfg.Addr},
 	}, nil
 }
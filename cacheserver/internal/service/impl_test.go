@@ -2,18 +2,28 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/althk/ganache/cacheserver/internal/strategy"
 	pb "github.com/althk/ganache/cacheserver/proto"
+	"github.com/althk/ganache/utils/sharding"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 var cache CachingStrategy
@@ -22,6 +32,7 @@ var ts = timestamppb.Now()
 var val = "some value"
 var cs *CacheServer
 var kv *mockkv
+var lease *mocklease
 
 func TestGetExistingItem(t *testing.T) {
 	cache = strategy.NewLRUCache(100)
@@ -70,6 +81,734 @@ func TestSet(t *testing.T) {
 	require.EqualValues(t, []byte(val), got.Data.Value)
 }
 
+func TestGetExpiredItem(t *testing.T) {
+	cache = strategy.NewLRUCache(100)
+	v := cacheValue(val, ts)
+	v.ExpiresAt = timestamppb.New(time.Now().Add(-time.Second))
+	cache.Set(ctx, "ns1key1", v)
+	cs = &CacheServer{Cache: cache}
+
+	resp, err := cs.Get(ctx, &pb.GetRequest{
+		Namespace: "ns1",
+		Key:       "key1",
+	})
+	require.Nil(t, resp)
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestSetWithTTL(t *testing.T) {
+	cache = strategy.NewLRUCache(100)
+	mockEtcd := mockETCD()
+	cs = &CacheServer{Cache: cache, Etcd: mockEtcd}
+
+	_, err := cs.Set(ctx, &pb.SetRequest{
+		Namespace: "ns1",
+		Key:       "key1",
+		Data: &anypb.Any{
+			Value: []byte(val),
+		},
+		Ttl: durationpb.New(1500 * time.Millisecond),
+	})
+	require.NoError(t, err)
+	got, e := cache.Get(ctx, "ns1key1")
+	require.True(t, e)
+	require.WithinDuration(t, time.Now().Add(1500*time.Millisecond), got.ExpiresAt.AsTime(), time.Second)
+	require.Eventually(t, func() bool {
+		return lease.granted() == 2 // rounded up to whole seconds
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSetInvalidTTL(t *testing.T) {
+	cache = strategy.NewLRUCache(100)
+	cs = &CacheServer{Cache: cache}
+
+	_, err := cs.Set(ctx, &pb.SetRequest{
+		Namespace: "ns1",
+		Key:       "key1",
+		Data: &anypb.Any{
+			Value: []byte(val),
+		},
+		Ttl: durationpb.New(-time.Second),
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, e := cache.Get(ctx, "ns1key1")
+	require.False(t, e)
+}
+
+func TestStats(t *testing.T) {
+	cache = strategy.NewLRUCache(100)
+	cache.Set(ctx, "ns1key1", cacheValue(val, ts))
+	cs = &CacheServer{Cache: cache, Etcd: mockETCD(), shardNum: 2}
+
+	cs.Get(ctx, &pb.GetRequest{Namespace: "ns1", Key: "key1"})
+	cs.Get(ctx, &pb.GetRequest{Namespace: "ns1", Key: "key1"})
+	cs.Get(ctx, &pb.GetRequest{Namespace: "ns1", Key: "key1"})
+	cs.Get(ctx, &pb.GetRequest{Namespace: "ns1", Key: "nonexistent"})
+	cs.Delete(ctx, &pb.DeleteRequest{Namespace: "ns1", Key: "nonexistent"})
+
+	got, err := cs.Stats(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.EqualValues(t, 4, got.GetReqCount)
+	require.EqualValues(t, 0, got.SetReqCount)
+	require.EqualValues(t, 1, got.DeleteReqCount)
+	require.EqualValues(t, 5, got.TotalReqCount)
+	require.EqualValues(t, 3, got.CacheHitCount)
+	require.EqualValues(t, 1, got.CacheMissCount)
+	require.EqualValues(t, 75, got.CacheHitRatio)
+	require.EqualValues(t, len("ns1key1")+len(val), got.TotalCacheSizeBytes)
+	require.EqualValues(t, 1, got.TotalKeysCount)
+	require.EqualValues(t, 2, got.ShardNumber)
+}
+
+func TestSetTooLarge(t *testing.T) {
+	cache = strategy.NewLRUCache(10)
+	cs = &CacheServer{Cache: cache, Etcd: mockETCD()}
+
+	_, err := cs.Set(ctx, &pb.SetRequest{
+		Namespace: "ns1",
+		Key:       "key1",
+		Data: &anypb.Any{
+			Value: []byte(val),
+		},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestDelete(t *testing.T) {
+	cache = strategy.NewLRUCache(100)
+	cache.Set(ctx, "ns1key1", cacheValue(val, ts))
+	mockEtcd := mockETCD()
+	cs = &CacheServer{Cache: cache, Etcd: mockEtcd, shardNum: 1}
+
+	_, err := cs.Delete(ctx, &pb.DeleteRequest{
+		Namespace: "ns1",
+		Key:       "key1",
+	})
+	require.NoError(t, err)
+	_, e := cache.Get(ctx, "ns1key1")
+	require.False(t, e)
+	require.Equal(t, []string{"ganache/cache/1/ns1key1"}, kv.deleted)
+	// replicas order the delete by the time in its tombstone
+	require.True(t, proto.Equal(cs.deleted.get("ns1key1", time.Now()), kv.tombstones[0].DeletedAt))
+	require.Equal(t, "ns1key1", kv.tombstones[0].Key)
+	require.Nil(t, kv.tombstones[0].Value)
+	require.EqualValues(t, TombstoneTTL.Seconds(), lease.granted())
+}
+
+func TestCacheKey(t *testing.T) {
+	cs = &CacheServer{shardNum: 1}
+	require.Equal(t, "ns1key1", cs.CacheKey("ganache/cache/1/ns1key1"))
+}
+
+func TestTransfer(t *testing.T) {
+	cache = strategy.NewLRUCache(1000)
+	keys := []string{"ns1key1", "ns1key2", "ns1key3", "ns1key4", "ns1key5", "ns1key6"}
+	for _, k := range keys {
+		cache.Set(ctx, k, cacheValue(val, ts))
+	}
+	expiredVal := cacheValue(val, ts)
+	expiredVal.ExpiresAt = timestamppb.New(time.Now().Add(-time.Second))
+	cache.Set(ctx, "ns1expired", expiredVal)
+	cs = &CacheServer{Cache: cache, Addr: "cs1"}
+	weights := map[int]int{0: 1, 1: 1}
+	p, _ := sharding.NewPicker(sharding.Ring, weights, sharding.DefaultVNodes)
+	var want []string
+	for _, k := range keys {
+		if p.Shard(k) == 1 {
+			want = append(want, k)
+		}
+	}
+
+	stream := &mockTransferStream{ctx: ctx}
+	err := cs.Transfer(&pb.TransferRequest{
+		Shard:   1,
+		Picker:  sharding.Ring,
+		Vnodes:  sharding.DefaultVNodes,
+		Weights: map[int32]int32{0: 1, 1: 1},
+	}, stream)
+	require.NoError(t, err)
+	var got []string
+	for _, m := range stream.sent {
+		require.Equal(t, "cs1", m.Source)
+		got = append(got, m.Key)
+	}
+	require.ElementsMatch(t, want, got)
+
+	err = cs.Transfer(&pb.TransferRequest{Shard: 1, Picker: "nope"}, stream)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGlobalKeys(t *testing.T) {
+	cs := &CacheServer{Cache: strategy.NewLRUCache(1000), Etcd: mockETCD(), Addr: "cs1"}
+	exp := timestamppb.New(time.Now().Add(time.Hour).Truncate(time.Second))
+	_, err := cs.Set(ctx, &pb.SetRequest{
+		Namespace: "ns1", Key: "key1", Data: &anypb.Any{Value: []byte("v1")},
+		Global: true, Ttl: durationpb.New(time.Minute), ExpiresAt: exp,
+	})
+	require.NoError(t, err)
+	v, _ := cs.Cache.Get(ctx, "ns1key1")
+	require.True(t, v.Global)
+	require.True(t, proto.Equal(exp, v.ExpiresAt)) // the absolute expiry wins over ttl
+
+	// writes that only reach one shard fail
+	_, err = cs.Set(ctx, &pb.SetRequest{Namespace: "ns1", Key: "key1", Data: &anypb.Any{Value: []byte("v2")}})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = cs.CompareAndSet(ctx, &pb.CompareAndSetRequest{Namespace: "ns1", Key: "key1", ExpectedVersion: v.Version})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = cs.Increment(ctx, &pb.IncrementRequest{Namespace: "ns1", Key: "key1", Delta: 1})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = cs.Delete(ctx, &pb.DeleteRequest{Namespace: "ns1", Key: "key1"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.Nil(t, cs.deleted.get("ns1key1", time.Now())) // nor is the delete remembered
+
+	// global keys are transferred to every shard
+	stream := &mockTransferStream{ctx: ctx}
+	for shard := int32(0); shard < 2; shard++ {
+		err = cs.Transfer(&pb.TransferRequest{Shard: shard, Picker: sharding.Mod, Weights: map[int32]int32{0: 1, 1: 1}}, stream)
+		require.NoError(t, err)
+	}
+	require.Len(t, stream.sent, 2)
+
+	_, err = cs.Delete(ctx, &pb.DeleteRequest{Namespace: "ns1", Key: "key1", Global: true})
+	require.NoError(t, err)
+	_, err = cs.Get(ctx, &pb.GetRequest{Namespace: "ns1", Key: "key1"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	require.NoError(t, cs.WaitForPendingWrites(ctx))
+}
+
+func TestDeleteAfterPendingSet(t *testing.T) {
+	cs := &CacheServer{Cache: strategy.NewLRUCache(1000), Etcd: mockETCD(), shardNum: 1}
+	kv.putGate = make(chan struct{})
+	_, err := cs.Set(ctx, &pb.SetRequest{Namespace: "ns1", Key: "key1", Data: &anypb.Any{Value: []byte(val)}})
+	require.NoError(t, err)
+
+	deleted := make(chan error)
+	go func() {
+		_, err := cs.Delete(ctx, &pb.DeleteRequest{Namespace: "ns1", Key: "key1"})
+		deleted <- err
+	}()
+	// the delete waits for the put, which would otherwise store the key again
+	time.Sleep(20 * time.Millisecond)
+	require.Empty(t, kv.recorded())
+	close(kv.putGate)
+	require.NoError(t, <-deleted)
+	require.Equal(t, []string{"put ganache/cache/1/ns1key1", "delete ganache/cache/1/ns1key1"}, kv.recorded())
+
+	// a cancelled delete keeps later writes behind the pending put
+	kv.putGate = make(chan struct{})
+	_, err = cs.Set(ctx, &pb.SetRequest{Namespace: "ns1", Key: "key1", Data: &anypb.Any{Value: []byte(val)}})
+	require.NoError(t, err)
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = cs.Delete(cctx, &pb.DeleteRequest{Namespace: "ns1", Key: "key1"})
+	require.Equal(t, codes.Canceled, status.Code(err))
+	go func() {
+		_, err := cs.Delete(ctx, &pb.DeleteRequest{Namespace: "ns1", Key: "key1"})
+		deleted <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	require.Len(t, kv.recorded(), 2)
+	close(kv.putGate)
+	require.NoError(t, <-deleted)
+	require.Equal(t, "delete ganache/cache/1/ns1key1", kv.recorded()[3])
+}
+
+func TestWaitForPendingWrites(t *testing.T) {
+	cache = strategy.NewLRUCache(100)
+	cs = &CacheServer{Cache: cache, Etcd: mockETCD()}
+	kv.putGate = make(chan struct{})
+
+	_, err := cs.Set(ctx, &pb.SetRequest{
+		Namespace: "ns1",
+		Key:       "key1",
+		Data:      &anypb.Any{Value: []byte(val)},
+	})
+	require.NoError(t, err)
+	tctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, cs.WaitForPendingWrites(tctx), context.DeadlineExceeded)
+
+	close(kv.putGate)
+	require.NoError(t, cs.WaitForPendingWrites(ctx))
+}
+
+func TestSetVersion(t *testing.T) {
+	cache = strategy.NewLRUCache(100)
+	cs = &CacheServer{Cache: cache, Etcd: mockETCD()}
+	req := &pb.SetRequest{Namespace: "ns1", Key: "key1", Data: &anypb.Any{Value: []byte(val)}}
+
+	_, err := cs.Set(ctx, req)
+	require.NoError(t, err)
+	r1, err := cs.Get(ctx, &pb.GetRequest{Namespace: "ns1", Key: "key1"})
+	require.NoError(t, err)
+	require.NotZero(t, r1.Version)
+
+	// the clock going back must not make the version go back
+	v := cacheValue(val, ts)
+	v.Version = math.MaxUint64 - 1
+	cache.Set(ctx, "ns1key1", v)
+	_, err = cs.Set(ctx, req)
+	require.NoError(t, err)
+	r2, _ := cs.Get(ctx, &pb.GetRequest{Namespace: "ns1", Key: "key1"})
+	require.EqualValues(t, uint64(math.MaxUint64), r2.Version)
+	require.NoError(t, cs.WaitForPendingWrites(ctx))
+}
+
+func TestCompareAndSet(t *testing.T) {
+	cache = strategy.NewLRUCache(100)
+	cs = &CacheServer{Cache: cache, Etcd: mockETCD()}
+	req := &pb.CompareAndSetRequest{Namespace: "ns1", Key: "key1", Data: &anypb.Any{Value: []byte(val)}}
+
+	// version 0 only matches a missing key
+	r1, err := cs.CompareAndSet(ctx, req)
+	require.NoError(t, err)
+	_, err = cs.CompareAndSet(ctx, req)
+	require.Equal(t, codes.Aborted, status.Code(err))
+
+	req.ExpectedVersion = r1.Version
+	req.Data = &anypb.Any{Value: []byte("v2")}
+	r2, err := cs.CompareAndSet(ctx, req)
+	require.NoError(t, err)
+	require.Greater(t, r2.Version, r1.Version)
+
+	// a stale version loses and the value is unchanged
+	req.Data = &anypb.Any{Value: []byte("v3")}
+	_, err = cs.CompareAndSet(ctx, req)
+	require.Equal(t, codes.Aborted, status.Code(err))
+	got, err := cs.Get(ctx, &pb.GetRequest{Namespace: "ns1", Key: "key1"})
+	require.NoError(t, err)
+	require.EqualValues(t, "v2", got.Data.Value)
+	require.Equal(t, r2.Version, got.Version)
+
+	// an expired key counts as missing
+	v := cacheValue(val, ts)
+	v.Version = r2.Version
+	v.ExpiresAt = timestamppb.New(time.Now().Add(-time.Second))
+	cache.Set(ctx, "ns1key1", v)
+	req.ExpectedVersion = 0
+	req.MustExist = true
+	_, err = cs.CompareAndSet(ctx, req)
+	require.Equal(t, codes.NotFound, status.Code(err))
+	req.MustExist = false
+	_, err = cs.CompareAndSet(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, cs.WaitForPendingWrites(ctx))
+}
+
+func TestIncrement(t *testing.T) {
+	cache = strategy.NewLRUCache(1000)
+	cs = &CacheServer{Cache: cache, Etcd: mockETCD(), Addr: "cs1"}
+	req := &pb.IncrementRequest{Namespace: "ns1", Key: "key1", Delta: 5, Initial: 10, Ttl: durationpb.New(time.Minute)}
+
+	r, err := cs.Increment(ctx, req)
+	require.NoError(t, err)
+	require.EqualValues(t, 15, r.Value)
+	req.Delta = -20
+	r, err = cs.Increment(ctx, req)
+	require.NoError(t, err)
+	require.EqualValues(t, -5, r.Value)
+
+	got, err := cs.Get(ctx, &pb.GetRequest{Namespace: "ns1", Key: "key1"})
+	require.NoError(t, err)
+	n := &wrapperspb.Int64Value{}
+	require.NoError(t, got.Data.UnmarshalTo(n))
+	require.EqualValues(t, -5, n.Value)
+	v, _ := cache.Get(ctx, "ns1key1")
+	require.WithinDuration(t, time.Now().Add(time.Minute), v.ExpiresAt.AsTime(), time.Second)
+
+	req.Delta = math.MinInt64
+	_, err = cs.Increment(ctx, req)
+	require.Equal(t, codes.OutOfRange, status.Code(err))
+
+	_, err = cs.Increment(ctx, &pb.IncrementRequest{Namespace: "ns1", Key: "key2", Delta: 1, MustExist: true})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	cache.Set(ctx, "ns1str", cacheValue(val, ts))
+	_, err = cs.Increment(ctx, &pb.IncrementRequest{Namespace: "ns1", Key: "str", Delta: 1})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.NoError(t, cs.WaitForPendingWrites(ctx))
+}
+
+func TestIncrementAfterSet(t *testing.T) {
+	cache = strategy.NewLRUCache(1000)
+	cs = &CacheServer{Cache: cache, Etcd: mockETCD(), Addr: "cs1"}
+	data, _ := anypb.New(wrapperspb.Int64(41))
+	_, err := cs.Set(ctx, &pb.SetRequest{Namespace: "ns1", Key: "key1", Data: data})
+	require.NoError(t, err)
+
+	r, err := cs.Increment(ctx, &pb.IncrementRequest{Namespace: "ns1", Key: "key1", Delta: 1})
+	require.NoError(t, err)
+	require.EqualValues(t, 42, r.Value)
+	require.NoError(t, cs.WaitForPendingWrites(ctx))
+}
+
+func TestIncrementReplicasMerge(t *testing.T) {
+	cs1 := &CacheServer{Cache: strategy.NewLRUCache(1000), Etcd: mockETCD(), Addr: "cs1"}
+	cs2 := &CacheServer{Cache: strategy.NewLRUCache(1000), Etcd: mockETCD(), Addr: "cs2"}
+	req := &pb.IncrementRequest{Namespace: "ns1", Key: "key1", Delta: 1}
+
+	// both replicas increment before seeing each other's writes
+	for i := 0; i < 3; i++ {
+		cs1.Increment(ctx, req)
+	}
+	for i := 0; i < 2; i++ {
+		cs2.Increment(ctx, req)
+	}
+	v1, _ := cs1.Cache.Get(ctx, "ns1key1")
+	v2, _ := cs2.Cache.Get(ctx, "ns1key1")
+	cs1.Sync("ns1key1", v2)
+	cs2.Sync("ns1key1", v1)
+	cs1.Sync("ns1key1", v2) // syncing again changes nothing
+
+	for _, s := range []*CacheServer{cs1, cs2} {
+		r, err := s.Increment(ctx, &pb.IncrementRequest{Namespace: "ns1", Key: "key1"})
+		require.NoError(t, err)
+		require.EqualValues(t, 5, r.Value)
+		require.NoError(t, s.WaitForPendingWrites(ctx))
+	}
+
+	// a later Set replaces the counter
+	data, _ := anypb.New(wrapperspb.Int64(100))
+	cs1.Set(ctx, &pb.SetRequest{Namespace: "ns1", Key: "key1", Data: data})
+	v1, _ = cs1.Cache.Get(ctx, "ns1key1")
+	cs2.Sync("ns1key1", v1)
+	r, err := cs2.Increment(ctx, &pb.IncrementRequest{Namespace: "ns1", Key: "key1", Delta: 1})
+	require.NoError(t, err)
+	require.EqualValues(t, 101, r.Value)
+	require.NoError(t, cs1.WaitForPendingWrites(ctx))
+	require.NoError(t, cs2.WaitForPendingWrites(ctx))
+}
+
+func TestSyncSkewedClocks(t *testing.T) {
+	ahead := &CacheServer{Cache: strategy.NewLRUCache(1000), Etcd: mockETCD(), Addr: "ahead"}
+	ahead.clock.Wall = func() time.Time { return time.Now().Add(time.Hour) }
+	behind := &CacheServer{Cache: strategy.NewLRUCache(1000), Etcd: mockETCD(), Addr: "behind"}
+	set := func(s *CacheServer, v string) *pb.CacheValue {
+		_, err := s.Set(ctx, &pb.SetRequest{Namespace: "ns1", Key: "key1", Data: &anypb.Any{Value: []byte(v)}})
+		require.NoError(t, err)
+		require.NoError(t, s.WaitForPendingWrites(ctx))
+		cv, _ := s.Cache.Get(ctx, "ns1key1")
+		return cv
+	}
+	get := func(s *CacheServer) string {
+		r, err := s.Get(ctx, &pb.GetRequest{Namespace: "ns1", Key: "key1"})
+		require.NoError(t, err)
+		return string(r.Data.Value)
+	}
+
+	v1 := set(ahead, "v1")
+	behind.Sync("ns1key1", v1)
+	// written after v1 was seen, so it wins although its wall clock is an
+	// hour behind v1's
+	v2 := set(behind, "v2")
+	require.True(t, v2.SourceTs.AsTime().Before(v1.SourceTs.AsTime()))
+	ahead.Sync("ns1key1", v2)
+	require.Equal(t, "v2", get(ahead))
+	require.Equal(t, "v2", get(behind))
+
+	// an old write arriving late does not win either
+	behind.Sync("ns1key1", v1)
+	require.Equal(t, "v2", get(behind))
+}
+
+func TestSyncConcurrentWritesConverge(t *testing.T) {
+	wall := time.Now()
+	cs1 := &CacheServer{Cache: strategy.NewLRUCache(1000), Etcd: mockETCD(), Addr: "cs1"}
+	cs2 := &CacheServer{Cache: strategy.NewLRUCache(1000), Etcd: mockETCD(), Addr: "cs2"}
+	for _, s := range []*CacheServer{cs1, cs2} {
+		s.clock.Node = s.Addr
+		s.clock.Wall = func() time.Time { return wall }
+	}
+	// same physical time and counter, the node breaks the tie
+	cs1.Set(ctx, &pb.SetRequest{Namespace: "ns1", Key: "key1", Data: &anypb.Any{Value: []byte("v1")}})
+	cs2.Set(ctx, &pb.SetRequest{Namespace: "ns1", Key: "key1", Data: &anypb.Any{Value: []byte("v2")}})
+	v1, _ := cs1.Cache.Get(ctx, "ns1key1")
+	v2, _ := cs2.Cache.Get(ctx, "ns1key1")
+	require.Equal(t, v1.Hlc.Physical, v2.Hlc.Physical)
+	cs1.Sync("ns1key1", v2)
+	cs2.Sync("ns1key1", v1)
+	for _, s := range []*CacheServer{cs1, cs2} {
+		r, err := s.Get(ctx, &pb.GetRequest{Namespace: "ns1", Key: "key1"})
+		require.NoError(t, err)
+		require.Equal(t, "v2", string(r.Data.Value))
+		require.NoError(t, s.WaitForPendingWrites(ctx))
+	}
+}
+
+func TestSyncAfterDelete(t *testing.T) {
+	cs1 := &CacheServer{Cache: strategy.NewLRUCache(1000), Etcd: mockETCD(), Addr: "cs1"}
+	cs2 := &CacheServer{Cache: strategy.NewLRUCache(1000), Etcd: mockETCD(), Addr: "cs2"}
+	_, err := cs1.Set(ctx, &pb.SetRequest{Namespace: "ns1", Key: "key1", Data: &anypb.Any{Value: []byte("v1")}})
+	require.NoError(t, err)
+	v1, _ := cs1.Cache.Get(ctx, "ns1key1")
+	_, err = cs2.Delete(ctx, &pb.DeleteRequest{Namespace: "ns1", Key: "key1"})
+	require.NoError(t, err)
+
+	// the write was deleted after it was made, it does not come back
+	require.False(t, cs2.Sync("ns1key1", v1))
+	_, e := cs2.Cache.Get(ctx, "ns1key1")
+	require.False(t, e)
+
+	// a delete older than the cached value leaves it
+	require.False(t, cs1.Sync("ns1key1", v1))
+	require.False(t, cs1.SyncDelete("ns1key1", &pb.HLC{Physical: v1.Hlc.Physical - 1}))
+	_, e = cs1.Cache.Get(ctx, "ns1key1")
+	require.True(t, e)
+	require.True(t, cs1.SyncDelete("ns1key1", cs2.deleted.get("ns1key1", time.Now())))
+	_, e = cs1.Cache.Get(ctx, "ns1key1")
+	require.False(t, e)
+}
+
+func TestTombstonesExpireAfterTheDelete(t *testing.T) {
+	tombs := &tombstones{}
+	deleted := time.Now().Add(-time.Minute)
+	ts := &pb.HLC{Physical: deleted.UnixNano(), Node: "cs2"}
+	// when it was recorded does not matter, every replica forgets it at
+	// the same time
+	tombs.add("ns1key1", ts)
+	require.Equal(t, ts, tombs.get("ns1key1", deleted.Add(TombstoneTTL-time.Second)))
+	require.Contains(t, tombs.snapshot(deleted.Add(TombstoneTTL-time.Second)), "ns1key1")
+	require.Nil(t, tombs.get("ns1key1", deleted.Add(TombstoneTTL)))
+	require.Empty(t, tombs.snapshot(deleted.Add(TombstoneTTL)))
+}
+
+func TestMerkleDigestReusesRoundTree(t *testing.T) {
+	cs := &CacheServer{Cache: strategy.NewLRUCache(1000), Etcd: mockETCD(), Addr: "cs1"}
+	root := func(round uint64) uint64 {
+		r, err := cs.MerkleDigest(ctx, &pb.MerkleDigestRequest{Nodes: []uint32{0}, Round: round})
+		require.NoError(t, err)
+		return r.Hashes[0]
+	}
+	empty := root(1)
+	_, err := cs.Set(ctx, &pb.SetRequest{Namespace: "ns1", Key: "key1", Data: &anypb.Any{Value: []byte("v1")}})
+	require.NoError(t, err)
+
+	// the round's later requests see its first tree, a new round sees the
+	// write
+	require.Equal(t, empty, root(1))
+	require.NotEqual(t, empty, root(2))
+	require.NotEqual(t, empty, root(0))
+}
+
+func TestLoadStopsWhenFull(t *testing.T) {
+	cs := &CacheServer{Cache: strategy.NewLRUCache(1000), maxBytes: 300}
+	var n int
+	for i := 0; i < 10; i++ {
+		v := &pb.CacheValue{Data: &anypb.Any{Value: make([]byte, 50)}, Hlc: &pb.HLC{Physical: 1}}
+		if !cs.Load(fmt.Sprint("key", i), v, proto.Size(v)) {
+			break
+		}
+		n++
+	}
+	require.Positive(t, n)
+	require.Less(t, n, 10)
+	// entries the cache already has at the same write are not counted
+	v, _ := cs.Cache.Get(ctx, "key0")
+	require.True(t, cs.Load("key0", v, 0))
+	require.LessOrEqual(t, cs.Cache.CurrSize(ctx), int64(300))
+	s, err := cs.Stats(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.EqualValues(t, n, s.LoadedKeys)
+	require.True(t, s.LoadTruncated)
+}
+
+func TestSetWriteMode(t *testing.T) {
+	cache = strategy.NewLRUCache(100)
+	cs = &CacheServer{Cache: cache, Etcd: mockETCD()}
+	req := &pb.SetRequest{Namespace: "ns1", Key: "key1", Data: &anypb.Any{Value: []byte(val)}}
+
+	req.Mode = pb.WriteMode_IF_PRESENT
+	_, err := cs.Set(ctx, req)
+	require.Equal(t, codes.NotFound, status.Code(err))
+	req.Mode = pb.WriteMode_IF_ABSENT
+	_, err = cs.Set(ctx, req)
+	require.NoError(t, err)
+	_, err = cs.Set(ctx, req)
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+	req.Mode = pb.WriteMode_IF_PRESENT
+	_, err = cs.Set(ctx, req)
+	require.NoError(t, err)
+
+	// an expired key counts as missing
+	v := cacheValue(val, ts)
+	v.ExpiresAt = timestamppb.New(time.Now().Add(-time.Second))
+	cache.Set(ctx, "ns1key1", v)
+	_, err = cs.Set(ctx, req)
+	require.Equal(t, codes.NotFound, status.Code(err))
+	req.Mode = pb.WriteMode_IF_ABSENT
+	_, err = cs.Set(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, cs.WaitForPendingWrites(ctx))
+}
+
+func TestSetIfAbsentConcurrent(t *testing.T) {
+	cache = strategy.NewLRUCache(1000)
+	cs = &CacheServer{Cache: cache, Etcd: mockETCD()}
+	var wg sync.WaitGroup
+	var wins int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cs.Set(ctx, &pb.SetRequest{
+				Namespace: "ns1",
+				Key:       "key1",
+				Data:      &anypb.Any{Value: []byte(val)},
+				Mode:      pb.WriteMode_IF_ABSENT,
+			})
+			if err == nil {
+				atomic.AddInt32(&wins, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	require.EqualValues(t, 1, wins)
+	require.NoError(t, cs.WaitForPendingWrites(ctx))
+}
+
+func TestMGet(t *testing.T) {
+	cache = strategy.NewLRUCache(100)
+	cache.Set(ctx, "ns1key1", cacheValue(val, ts))
+	cs = &CacheServer{Cache: cache}
+
+	resp, err := cs.MGet(ctx, &pb.MGetRequest{Keys: []*pb.GetRequest{
+		{Namespace: "ns1", Key: "key2"},
+		{Namespace: "ns1", Key: "key1"},
+	}})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 2)
+	require.EqualValues(t, codes.NotFound, resp.Results[0].Status.Code)
+	require.Nil(t, resp.Results[0].Data)
+	require.EqualValues(t, codes.OK, resp.Results[1].Status.Code)
+	require.EqualValues(t, []byte(val), resp.Results[1].Data.Value)
+}
+
+func TestMSet(t *testing.T) {
+	cache = strategy.NewLRUCache(100)
+	cs = &CacheServer{Cache: cache, Etcd: mockETCD()}
+
+	resp, err := cs.MSet(ctx, &pb.MSetRequest{Items: []*pb.SetRequest{
+		{Namespace: "ns1", Key: "key1", Data: &anypb.Any{Value: []byte(val)}},
+		{Namespace: "ns1", Key: "key2", Data: &anypb.Any{Value: []byte(val)}, Ttl: durationpb.New(-time.Second)},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, cs.WaitForPendingWrites(ctx))
+	require.Len(t, resp.Statuses, 2)
+	require.EqualValues(t, codes.OK, resp.Statuses[0].Code)
+	require.EqualValues(t, codes.InvalidArgument, resp.Statuses[1].Code)
+	_, e := cache.Get(ctx, "ns1key1")
+	require.True(t, e)
+	_, e = cache.Get(ctx, "ns1key2")
+	require.False(t, e)
+}
+
+func TestMDelete(t *testing.T) {
+	cache = strategy.NewLRUCache(100)
+	cache.Set(ctx, "ns1key1", cacheValue(val, ts))
+	cs = &CacheServer{Cache: cache, Etcd: mockETCD(), shardNum: 1}
+
+	resp, err := cs.MDelete(ctx, &pb.MDeleteRequest{Keys: []*pb.DeleteRequest{
+		{Namespace: "ns1", Key: "key1"},
+		{Namespace: "ns1", Key: "key2"},
+	}})
+	require.NoError(t, err)
+	require.Len(t, resp.Statuses, 2)
+	_, e := cache.Get(ctx, "ns1key1")
+	require.False(t, e)
+	require.Equal(t, []string{"ganache/cache/1/ns1key1", "ganache/cache/1/ns1key2"}, kv.deleted)
+}
+
+func TestImport(t *testing.T) {
+	cs = &CacheServer{Cache: strategy.NewLRUCache(1000), Etcd: mockETCD(), Addr: "cs1", shardNum: 2}
+	for _, k := range []string{"key1", "key2"} {
+		_, err := cs.Set(ctx, &pb.SetRequest{Namespace: "ns1", Key: k, Data: &anypb.Any{Value: []byte("new")}})
+		require.NoError(t, err)
+	}
+	_, err := cs.Delete(ctx, &pb.DeleteRequest{Namespace: "ns1", Key: "key2"})
+	require.NoError(t, err)
+
+	// written on the old shard before the migration started
+	moved := func(k string) *pb.CacheKeyMetadata {
+		v := cacheValue("old", ts)
+		v.Hlc = &pb.HLC{Physical: time.Now().Add(-time.Minute).UnixNano(), Node: "cs9"}
+		return &pb.CacheKeyMetadata{Source: "cs9", Key: k, Value: v}
+	}
+	_, err = cs.Import(ctx, &pb.ImportRequest{Entries: []*pb.CacheKeyMetadata{
+		moved("ns1key1"), moved("ns1key2"), moved("ns1key3"),
+	}})
+	require.NoError(t, err)
+	require.NoError(t, cs.WaitForPendingWrites(ctx))
+
+	v, _ := cs.Cache.Get(ctx, "ns1key1")
+	require.Equal(t, []byte("new"), v.Data.Value)
+	_, e := cs.Cache.Get(ctx, "ns1key2")
+	require.False(t, e)
+	v, _ = cs.Cache.Get(ctx, "ns1key3")
+	require.Equal(t, []byte("old"), v.Data.Value)
+	// only the imported key is replicated
+	require.ElementsMatch(t, []string{
+		"put ganache/cache/2/ns1key1",
+		"put ganache/cache/2/ns1key2",
+		"delete ganache/cache/2/ns1key2",
+		"put ganache/cache/2/ns1key3",
+	}, kv.recorded())
+}
+
+func TestWatchKeys(t *testing.T) {
+	cs = &CacheServer{Cache: strategy.NewLRUCache(1000), Etcd: mockETCD(), Addr: "cs1"}
+	stream := &mockWatchKeysStream{ctx: ctx, sent: make(chan *pb.KeyChanges, 100)}
+	done := make(chan error)
+	go func() {
+		done <- cs.WatchKeys(&pb.WatchKeysRequest{}, stream)
+	}()
+	require.Empty(t, (<-stream.sent).Keys) // watching
+
+	_, err := cs.Set(ctx, &pb.SetRequest{Namespace: "ns1", Key: "key1", Data: &anypb.Any{Value: []byte("v1")}})
+	require.NoError(t, err)
+	_, err = cs.Delete(ctx, &pb.DeleteRequest{Namespace: "ns1", Key: "key2"})
+	require.NoError(t, err)
+	v := cacheValue(val, ts)
+	v.Hlc = &pb.HLC{Physical: time.Now().UnixNano(), Node: "cs2"}
+	require.True(t, cs.Sync("ns1key3", v))
+	require.False(t, cs.Sync("ns1key3", v)) // unchanged
+	cs.SyncDelete("ns1key4", &pb.HLC{Physical: time.Now().UnixNano(), Node: "cs2"})
+	cs.KeysExpired([]string{"ns1key5"})
+
+	var got []string
+	for len(got) < 5 {
+		select {
+		case c := <-stream.sent:
+			require.False(t, c.Flush)
+			got = append(got, c.Keys...)
+		case <-time.After(time.Second):
+			t.Fatalf("got changes of %v only", got)
+		}
+	}
+	require.ElementsMatch(t, []string{"ns1key1", "ns1key2", "ns1key3", "ns1key4", "ns1key5"}, got)
+
+	require.NoError(t, cs.StopWatches(ctx))
+	require.Equal(t, codes.Unavailable, status.Code(<-done))
+	err = cs.WatchKeys(&pb.WatchKeysRequest{}, stream)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestWatchKeysFlushesWhenBehind(t *testing.T) {
+	cs = &CacheServer{Cache: strategy.NewLRUCache(1000)}
+	kw, err := cs.watchers.add()
+	require.NoError(t, err)
+	for i := 0; i <= maxChangedKeys; i++ {
+		cs.watchers.publish(fmt.Sprintf("key%d", i))
+	}
+	keys, flush := cs.watchers.take(kw)
+	require.True(t, flush)
+	require.Empty(t, keys)
+
+	cs.watchers.publish("key")
+	keys, flush = cs.watchers.take(kw)
+	require.False(t, flush)
+	require.Equal(t, []string{"key"}, keys)
+}
+
 func cacheValue(v string, ts *timestamppb.Timestamp) *pb.CacheValue {
 	return &pb.CacheValue{
 		Data: &anypb.Any{
@@ -82,23 +821,52 @@ func cacheValue(v string, ts *timestamppb.Timestamp) *pb.CacheValue {
 
 func mockETCD() *clientv3.Client {
 	kv = &mockkv{}
-	return &clientv3.Client{KV: kv}
+	lease = &mocklease{}
+	return &clientv3.Client{KV: kv, Lease: lease}
 }
 
 // mockkv implements clientv3.KV
 type mockkv struct {
 	mock.Mock
+	deleted    []string
+	tombstones []*pb.CacheKeyMetadata
+	ops        []string      // "put <key>" and "delete <key>" in the order they ran
+	putGate    chan struct{} // if set, Put blocks until it is closed
+	l          sync.Mutex
 }
 
 func (kv *mockkv) Put(_ context.Context, k, v string, _ ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	if kv.putGate != nil {
+		<-kv.putGate
+	}
+	kv.l.Lock()
+	defer kv.l.Unlock()
+	// deletes are written as tombstones
+	if m := (&pb.CacheKeyMetadata{}); proto.Unmarshal([]byte(v), m) == nil && m.DeletedAt != nil {
+		kv.ops = append(kv.ops, "delete "+k)
+		kv.deleted = append(kv.deleted, k)
+		kv.tombstones = append(kv.tombstones, m)
+		return nil, nil
+	}
+	kv.ops = append(kv.ops, "put "+k)
 	return nil, nil
 }
 
+func (kv *mockkv) recorded() []string {
+	kv.l.Lock()
+	defer kv.l.Unlock()
+	return append([]string(nil), kv.ops...)
+}
+
 func (kv *mockkv) Get(_ context.Context, _ string, _ ...clientv3.OpOption) (*clientv3.GetResponse, error) {
 	return nil, nil
 }
 
-func (kv *mockkv) Delete(_ context.Context, _ string, _ ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+func (kv *mockkv) Delete(_ context.Context, k string, _ ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	kv.l.Lock()
+	defer kv.l.Unlock()
+	kv.ops = append(kv.ops, "delete "+k)
+	kv.deleted = append(kv.deleted, k)
 	return nil, nil
 }
 
@@ -113,3 +881,79 @@ func (kv *mockkv) Do(_ context.Context, _ clientv3.Op) (clientv3.OpResponse, err
 func (kv *mockkv) Txn(_ context.Context) clientv3.Txn {
 	return nil
 }
+
+// mocklease implements clientv3.Lease
+type mocklease struct {
+	mock.Mock
+	ttl int64
+	l   sync.Mutex
+}
+
+func (l *mocklease) granted() int64 {
+	l.l.Lock()
+	defer l.l.Unlock()
+	return l.ttl
+}
+
+func (l *mocklease) Grant(_ context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	l.l.Lock()
+	defer l.l.Unlock()
+	l.ttl = ttl
+	return &clientv3.LeaseGrantResponse{ID: clientv3.LeaseID(1), TTL: ttl}, nil
+}
+
+func (l *mocklease) Revoke(_ context.Context, _ clientv3.LeaseID) (*clientv3.LeaseRevokeResponse, error) {
+	return nil, nil
+}
+
+func (l *mocklease) TimeToLive(_ context.Context, _ clientv3.LeaseID, _ ...clientv3.LeaseOption) (*clientv3.LeaseTimeToLiveResponse, error) {
+	return nil, nil
+}
+
+func (l *mocklease) Leases(_ context.Context) (*clientv3.LeaseLeasesResponse, error) {
+	return nil, nil
+}
+
+func (l *mocklease) KeepAlive(_ context.Context, _ clientv3.LeaseID) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	return nil, nil
+}
+
+func (l *mocklease) KeepAliveOnce(_ context.Context, _ clientv3.LeaseID) (*clientv3.LeaseKeepAliveResponse, error) {
+	return nil, nil
+}
+
+func (l *mocklease) Close() error {
+	return nil
+}
+
+// mockTransferStream implements pb.Cache_TransferServer
+type mockTransferStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*pb.CacheKeyMetadata
+}
+
+func (m *mockTransferStream) Send(km *pb.CacheKeyMetadata) error {
+	m.sent = append(m.sent, km)
+	return nil
+}
+
+func (m *mockTransferStream) Context() context.Context {
+	return m.ctx
+}
+
+// mockWatchKeysStream implements pb.Cache_WatchKeysServer
+type mockWatchKeysStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *pb.KeyChanges
+}
+
+func (m *mockWatchKeysStream) Send(c *pb.KeyChanges) error {
+	m.sent <- c
+	return nil
+}
+
+func (m *mockWatchKeysStream) Context() context.Context {
+	return m.ctx
+}
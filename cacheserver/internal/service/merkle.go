@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/althk/ganache/cacheserver/internal/hlc"
+	"github.com/althk/ganache/cacheserver/internal/strategy"
+	pb "github.com/althk/ganache/cacheserver/proto"
+	etcdutils "github.com/althk/ganache/utils/etcd"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// The Merkle tree over a replica's keys has merkleFanout children per node
+// and MerkleLeaves leaves, each key hashes to one leaf. Nodes are numbered
+// breadth first from the root, 0.
+const (
+	merkleFanout = 16
+	MerkleLeaves = merkleFanout * merkleFanout * merkleFanout
+	firstLeaf    = (MerkleLeaves - 1) / (merkleFanout - 1) // node number of leaf 0
+	merkleNodes  = firstLeaf + MerkleLeaves
+	// TombstoneTTL is how long after a delete replicas remember it, so that
+	// anti-entropy removes a deleted key from replicas that missed the
+	// delete instead of copying it back. Anti-entropy must run more often.
+	TombstoneTTL = etcdutils.TombstoneTTL
+	// merkleTreeTTL is how long the tree built for an anti-entropy round
+	// serves the round's requests, and maxMerkleRounds how many rounds
+	// have their tree kept at a time.
+	merkleTreeTTL   = time.Minute
+	maxMerkleRounds = 64
+)
+
+// tombstones holds the recently deleted keys, by deletion time. A tombstone
+// expires TombstoneTTL after the wall clock time of its HLC, rather than
+// after it was recorded, so that every replica forgets it at the same time.
+type tombstones struct {
+	l sync.Mutex
+	m map[string]*pb.HLC
+}
+
+// add records that k was deleted at ts, unless it is known to have been
+// deleted later.
+func (t *tombstones) add(k string, ts *pb.HLC) {
+	t.l.Lock()
+	defer t.l.Unlock()
+	if t.m == nil {
+		t.m = make(map[string]*pb.HLC)
+	}
+	if curr, ok := t.m[k]; ok && hlc.Compare(curr, ts) >= 0 {
+		return
+	}
+	t.m[k] = ts
+}
+
+// get returns when k was deleted, nil if it was not within TombstoneTTL of
+// now.
+func (t *tombstones) get(k string, now time.Time) *pb.HLC {
+	t.l.Lock()
+	defer t.l.Unlock()
+	if ts := t.m[k]; ts != nil && !expired(ts, now) {
+		return ts
+	}
+	return nil
+}
+
+// snapshot drops the expired tombstones and returns the others.
+func (t *tombstones) snapshot(now time.Time) map[string]*pb.HLC {
+	t.l.Lock()
+	defer t.l.Unlock()
+	m := make(map[string]*pb.HLC, len(t.m))
+	for k, ts := range t.m {
+		if expired(ts, now) {
+			delete(t.m, k)
+			continue
+		}
+		m[k] = ts
+	}
+	return m
+}
+
+func expired(ts *pb.HLC, now time.Time) bool {
+	return !time.Unix(0, ts.Physical).Add(TombstoneTTL).After(now)
+}
+
+// merkleTrees holds the trees built for the anti-entropy rounds in
+// progress, so that a round's MerkleDigest requests, one per level of the
+// tree, do not range over the cache each.
+type merkleTrees struct {
+	l sync.Mutex
+	m map[uint64]roundTree
+}
+
+type roundTree struct {
+	hashes []uint64
+	built  time.Time
+}
+
+// get returns the tree of round, built with build unless the round already
+// has one. Round 0 is always built.
+func (t *merkleTrees) get(round uint64, now time.Time, build func() []uint64) []uint64 {
+	if round == 0 {
+		return build()
+	}
+	t.l.Lock()
+	for r, rt := range t.m {
+		if now.Sub(rt.built) >= merkleTreeTTL {
+			delete(t.m, r)
+		}
+	}
+	rt, ok := t.m[round]
+	t.l.Unlock()
+	if ok {
+		return rt.hashes
+	}
+	hashes := build()
+	t.l.Lock()
+	defer t.l.Unlock()
+	if t.m == nil {
+		t.m = make(map[uint64]roundTree)
+	}
+	if len(t.m) < maxMerkleRounds {
+		t.m[round] = roundTree{hashes: hashes, built: now}
+	}
+	return hashes
+}
+
+// MerkleDigest returns the hashes of the requested nodes of the replica's
+// Merkle tree, as of the round's first request.
+func (s *CacheServer) MerkleDigest(ctx context.Context, in *pb.MerkleDigestRequest) (*pb.MerkleDigestResponse, error) {
+	for _, n := range in.Nodes {
+		if n >= merkleNodes {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid Merkle tree node %d", n)
+		}
+	}
+	t := s.trees.get(in.Round, time.Now(), func() []uint64 { return s.merkleTree(ctx) })
+	hashes := make([]uint64, len(in.Nodes))
+	for i, n := range in.Nodes {
+		hashes[i] = t[n]
+	}
+	return &pb.MerkleDigestResponse{Hashes: hashes}, nil
+}
+
+// MerkleEntries streams the entries and tombstones that hash to the
+// requested leaves.
+func (s *CacheServer) MerkleEntries(in *pb.MerkleEntriesRequest, stream pb.Cache_MerkleEntriesServer) error {
+	leaves := make(map[uint32]bool, len(in.Leaves))
+	for _, l := range in.Leaves {
+		if l >= MerkleLeaves {
+			return status.Errorf(codes.InvalidArgument, "Invalid Merkle tree leaf %d", l)
+		}
+		leaves[l] = true
+	}
+	var err error
+	s.rangeEntries(stream.Context(), func(e *pb.ReplicationEntry) bool {
+		if !leaves[leafOf(e.Key)] {
+			return true
+		}
+		err = stream.Send(e)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if err = stream.Context().Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return nil
+}
+
+// AntiEntropy repairs the replica from a peer of its shard: it walks down
+// the peer's Merkle tree along the nodes whose hashes differ from its own
+// and syncs the entries of the differing leaves, so that each replica keeps
+// the latest write of every key. Keys on which the replica is ahead are
+// repaired when the peer runs AntiEntropy against it. It returns the
+// number of keys that changed.
+func (s *CacheServer) AntiEntropy(ctx context.Context, c pb.CacheClient) (int, error) {
+	local := s.merkleTree(ctx)
+	round := rand.Uint64() | 1 // not 0, which the peer does not keep
+	var leaves []uint32
+	for nodes := []uint32{0}; len(nodes) > 0; {
+		resp, err := c.MerkleDigest(ctx, &pb.MerkleDigestRequest{Nodes: nodes, Round: round})
+		if err != nil {
+			return 0, err
+		}
+		if len(resp.Hashes) != len(nodes) {
+			return 0, fmt.Errorf("got %d hashes for %d Merkle tree nodes", len(resp.Hashes), len(nodes))
+		}
+		var next []uint32
+		for i, n := range nodes {
+			switch {
+			case resp.Hashes[i] == local[n]:
+			case n >= firstLeaf:
+				leaves = append(leaves, n-firstLeaf)
+			default:
+				for c := n*merkleFanout + 1; c <= n*merkleFanout+merkleFanout; c++ {
+					next = append(next, c)
+				}
+			}
+		}
+		nodes = next
+	}
+	atomic.AddUint64(&s.counters.aeRounds, 1)
+	if len(leaves) == 0 {
+		return 0, nil
+	}
+	atomic.AddUint64(&s.counters.aeLeaves, uint64(len(leaves)))
+	stream, err := c.MerkleEntries(ctx, &pb.MerkleEntriesRequest{Leaves: leaves})
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		var changed bool
+		if e.Deleted {
+			changed = s.SyncDelete(e.Key, e.DeletedAt)
+		} else if e.Value != nil {
+			changed = s.Sync(e.Key, e.Value)
+		}
+		if changed {
+			atomic.AddUint64(&s.counters.aeRepaired, 1)
+			n++
+		}
+	}
+}
+
+// merkleTree returns the hashes of every node of the replica's Merkle tree.
+// A leaf's hash is the xor of the hashes of its entries, which does not
+// depend on the order the cache ranges over them.
+func (s *CacheServer) merkleTree(ctx context.Context) []uint64 {
+	t := make([]uint64, merkleNodes)
+	s.rangeEntries(ctx, func(e *pb.ReplicationEntry) bool {
+		t[firstLeaf+leafOf(e.Key)] ^= entryHash(e)
+		return true
+	})
+	var b [8]byte
+	for n := firstLeaf - 1; n >= 0; n-- {
+		h := fnv.New64a()
+		for c := n*merkleFanout + 1; c <= n*merkleFanout+merkleFanout; c++ {
+			binary.BigEndian.PutUint64(b[:], t[c])
+			h.Write(b[:])
+		}
+		t[n] = h.Sum64()
+	}
+	return t
+}
+
+// rangeEntries calls f for the latest write of every key until f returns
+// false: the cached value, or the tombstone of a key deleted after it.
+func (s *CacheServer) rangeEntries(ctx context.Context, f func(e *pb.ReplicationEntry) bool) {
+	now := time.Now()
+	tombs := s.deleted.snapshot(now)
+	more := true
+	s.Cache.Range(ctx, func(k string, v *pb.CacheValue) bool {
+		if strategy.Expired(v, now) {
+			return true
+		}
+		if ts, ok := tombs[k]; ok {
+			if hlc.Compare(ts, hlcOf(v)) >= 0 {
+				return true
+			}
+			delete(tombs, k)
+		}
+		more = f(&pb.ReplicationEntry{Key: k, Value: v})
+		return more
+	})
+	for k, ts := range tombs {
+		if !more || ctx.Err() != nil {
+			return
+		}
+		more = f(&pb.ReplicationEntry{Key: k, Deleted: true, DeletedAt: ts})
+	}
+}
+
+func leafOf(k string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(k))
+	return h.Sum32() % MerkleLeaves
+}
+
+// entryHash hashes what replicas must agree on for an entry: the key, when
+// it was written or deleted and, for counters, the merged increments.
+func entryHash(e *pb.ReplicationEntry) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(e.Key))
+	ts := e.DeletedAt
+	if !e.Deleted {
+		ts = hlcOf(e.Value)
+		if e.Value.Counter != nil {
+			b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(e.Value.Counter)
+			h.Write(b)
+		}
+	}
+	var b [13]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(ts.GetPhysical()))
+	binary.BigEndian.PutUint32(b[8:12], ts.GetLogical())
+	if e.Deleted {
+		b[12] = 1
+	}
+	h.Write(b[:])
+	h.Write([]byte(ts.GetNode()))
+	return h.Sum64()
+}
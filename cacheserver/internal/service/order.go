@@ -0,0 +1,41 @@
+package service
+
+import "sync"
+
+// keyOrder orders the etcd writes of each key: a write starts once the
+// write of the key before it is done, so a Put started before a Delete
+// cannot land after it.
+type keyOrder struct {
+	l    sync.Mutex
+	last map[string]chan struct{} // closed when the key's last write is done
+}
+
+var noWait = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// next queues a write of k. It returns a channel that is closed once the
+// previous write of k is done and a func to call when this one is.
+func (o *keyOrder) next(k string) (<-chan struct{}, func()) {
+	o.l.Lock()
+	defer o.l.Unlock()
+	if o.last == nil {
+		o.last = make(map[string]chan struct{})
+	}
+	prev, ok := o.last[k]
+	if !ok {
+		prev = noWait
+	}
+	done := make(chan struct{})
+	o.last[k] = done
+	return prev, func() {
+		o.l.Lock()
+		defer o.l.Unlock()
+		close(done)
+		if o.last[k] == done {
+			delete(o.last, k)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"io"
+	"time"
+
+	pb "github.com/althk/ganache/cacheserver/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Replicator sends the writes of a cache server to the other replicas of
+// its shard, which apply them with Sync and SyncDelete.
+type Replicator interface {
+	// Replicate sends v, the new value of key k, in the background. A ttl
+	// above zero is how long v has left to live. It fails if some replica
+	// cannot take v, e.g. because it is too far behind.
+	Replicate(ctx context.Context, k string, v *pb.CacheValue, ttl time.Duration) error
+	// Delete removes k, deleted at ts, from the other replicas.
+	Delete(ctx context.Context, k string, ts *pb.HLC) error
+	// Wait waits until the values passed to Replicate have been sent.
+	Wait(ctx context.Context) error
+}
+
+// Primary picks the replica of the shard that checks every CompareAndSet,
+// so that two swaps of a key sent to different replicas cannot both
+// succeed.
+type Primary interface {
+	// Client returns a client for the primary, nil if it is this server.
+	Client() (pb.CacheClient, error)
+}
+
+// Replicate applies the batches of writes streamed by another replica, in
+// order.
+func (s *CacheServer) Replicate(stream pb.Cache_ReplicateServer) error {
+	for {
+		b, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&emptypb.Empty{})
+		}
+		if err != nil {
+			return err
+		}
+		for _, e := range b.Entries {
+			if e.Deleted {
+				s.SyncDelete(e.Key, e.DeletedAt)
+			} else if e.Value != nil {
+				s.Sync(e.Key, e.Value)
+			}
+		}
+	}
+}
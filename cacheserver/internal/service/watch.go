@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	pb "github.com/althk/ganache/cacheserver/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxChangedKeys is the most changed keys queued for one WatchKeys stream.
+// A stream that falls further behind is told to flush instead.
+const maxChangedKeys = 100000
+
+// maxKeysPerChange is the most keys sent in one KeyChanges event.
+const maxKeysPerChange = 1000
+
+// keyWatchers tracks the WatchKeys streams and the keys that changed since
+// each was last sent to.
+type keyWatchers struct {
+	l       sync.Mutex
+	streams map[*keyWatch]struct{}
+	stopped bool
+}
+
+// keyWatch is the state of one WatchKeys stream.
+type keyWatch struct {
+	pending map[string]struct{} // changed and not sent yet
+	flush   bool
+	notify  chan struct{} // has room for one wake up
+	done    chan struct{} // closed when the server stops the watches
+}
+
+func (w *keyWatchers) add() (*keyWatch, error) {
+	kw := &keyWatch{
+		pending: make(map[string]struct{}),
+		notify:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	w.l.Lock()
+	defer w.l.Unlock()
+	if w.stopped {
+		return nil, status.Error(codes.Unavailable, "Cache server is shutting down.")
+	}
+	if w.streams == nil {
+		w.streams = make(map[*keyWatch]struct{})
+	}
+	w.streams[kw] = struct{}{}
+	return kw, nil
+}
+
+func (w *keyWatchers) remove(kw *keyWatch) {
+	w.l.Lock()
+	defer w.l.Unlock()
+	delete(w.streams, kw)
+}
+
+// publish queues key k for every stream.
+func (w *keyWatchers) publish(k string) {
+	w.l.Lock()
+	defer w.l.Unlock()
+	for kw := range w.streams {
+		switch {
+		case kw.flush:
+		case len(kw.pending) >= maxChangedKeys:
+			kw.pending = make(map[string]struct{})
+			kw.flush = true
+		default:
+			kw.pending[k] = struct{}{}
+		}
+		kw.wake()
+	}
+}
+
+// stop ends every stream and refuses new ones.
+func (w *keyWatchers) stop() {
+	w.l.Lock()
+	defer w.l.Unlock()
+	if w.stopped {
+		return
+	}
+	w.stopped = true
+	for kw := range w.streams {
+		close(kw.done)
+	}
+}
+
+// take returns what is to be sent to kw and clears it.
+func (w *keyWatchers) take(kw *keyWatch) (keys []string, flush bool) {
+	w.l.Lock()
+	defer w.l.Unlock()
+	flush, kw.flush = kw.flush, false
+	if !flush {
+		keys = make([]string, 0, len(kw.pending))
+		for k := range kw.pending {
+			keys = append(keys, k)
+		}
+	}
+	kw.pending = make(map[string]struct{})
+	return keys, flush
+}
+
+func (kw *keyWatch) wake() {
+	select {
+	case kw.notify <- struct{}{}:
+	default:
+	}
+}
+
+// WatchKeys streams the keys that change on this replica. Every change of
+// the shard reaches each of its replicas, either written to it or
+// replicated from another one, so watching any replica is enough. The
+// stream ends with Unavailable when the server shuts down.
+func (s *CacheServer) WatchKeys(_ *pb.WatchKeysRequest, stream pb.Cache_WatchKeysServer) error {
+	kw, err := s.watchers.add()
+	if err != nil {
+		return err
+	}
+	defer s.watchers.remove(kw)
+	if err := stream.Send(&pb.KeyChanges{}); err != nil {
+		return err
+	}
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-kw.done:
+			return status.Error(codes.Unavailable, "Cache server is shutting down.")
+		case <-kw.notify:
+		}
+		keys, flush := s.watchers.take(kw)
+		if flush {
+			if err := stream.Send(&pb.KeyChanges{Flush: true}); err != nil {
+				return err
+			}
+		}
+		for len(keys) > 0 {
+			n := len(keys)
+			if n > maxKeysPerChange {
+				n = maxKeysPerChange
+			}
+			if err := stream.Send(&pb.KeyChanges{Keys: keys[:n]}); err != nil {
+				return err
+			}
+			keys = keys[n:]
+		}
+	}
+}
+
+// StopWatches ends the WatchKeys streams so that shutting down does not
+// wait for them, their CFEs watch another replica instead.
+func (s *CacheServer) StopWatches(context.Context) error {
+	s.watchers.stop()
+	return nil
+}
+
+// KeysExpired reports keys the expiry sweeper removed to the WatchKeys
+// streams.
+func (s *CacheServer) KeysExpired(keys []string) {
+	for _, k := range keys {
+		s.watchers.publish(k)
+	}
+}
@@ -0,0 +1,259 @@
+package strategy
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	pb "github.com/althk/ganache/cacheserver/proto"
+)
+
+// arc lists, t1 and t2 hold cached entries, b1 and b2 hold the keys (and
+// sizes) of entries recently evicted from t1 and t2 respectively.
+const (
+	arcT1 = iota // seen once recently
+	arcT2        // seen at least twice recently
+	arcB1        // ghosts of t1
+	arcB2        // ghosts of t2
+)
+
+type arcEntry struct {
+	entry
+	list int // one of arcT1, arcT2, arcB1, arcB2
+}
+
+// arc implements CachingStrategy and provides an Adaptive Replacement Cache
+// (Megiddo & Modha) with the sizes measured in bytes instead of entries.
+// Hits in the ghost lists adapt the target size of t1, so a scan of
+// one-time keys only displaces other one-time keys and leaves t2 intact.
+type arc struct {
+	items    map[string]*list.Element // values are *arcEntry, ghosts included
+	lists    [4]*list.List            // most recent at the front
+	bytes    [4]int64                 // bytes per list, for ghosts as if cached
+	p        int64                    // target size of t1 in bytes
+	maxBytes int64
+	eq       expiryQueue
+	l        sync.Mutex
+}
+
+func (c *arc) Get(_ context.Context, k string) (*pb.CacheValue, bool) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	el, e := c.items[k]
+	if !e {
+		return nil, false
+	}
+	en := el.Value.(*arcEntry)
+	if en.list != arcT1 && en.list != arcT2 {
+		return nil, false
+	}
+	c.move(el, arcT2)
+	return en.val, true
+}
+
+func (c *arc) Set(_ context.Context, k string, v *pb.CacheValue) (int64, error) {
+	s := entrySize(k, v)
+	if s > c.maxBytes {
+		return 0, ErrValueTooLarge
+	}
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.set(k, v, s)
+	return s, nil
+}
+
+func (c *arc) Update(_ context.Context, k string, f func(curr *pb.CacheValue) (*pb.CacheValue, error)) (int64, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	var curr *pb.CacheValue
+	if el, e := c.items[k]; e && el.Value.(*arcEntry).list <= arcT2 {
+		curr = el.Value.(*arcEntry).val
+	}
+	v, err := f(curr)
+	if v == nil || err != nil {
+		return 0, err
+	}
+	if v == Remove {
+		if el, e := c.items[k]; e {
+			c.remove(el)
+		}
+		return 0, nil
+	}
+	s := entrySize(k, v)
+	if s > c.maxBytes {
+		return 0, ErrValueTooLarge
+	}
+	c.set(k, v, s)
+	return s, nil
+}
+
+func (c *arc) set(k string, v *pb.CacheValue, s int64) {
+	el, e := c.items[k]
+	if !e {
+		el = c.push(&arcEntry{entry: entry{key: k}}, arcT1)
+	}
+	en := el.Value.(*arcEntry)
+	switch en.list {
+	case arcB1:
+		c.p = min64(c.maxBytes, c.p+c.delta(s, arcB2, arcB1))
+		c.move(el, arcT2)
+	case arcB2:
+		c.p = max64(0, c.p-c.delta(s, arcB1, arcB2))
+		c.move(el, arcT2)
+	case arcT1, arcT2:
+		if e {
+			c.move(el, arcT2)
+		}
+	}
+	c.bytes[en.list] += s - en.size
+	en.val, en.size = v, s
+	c.replace(en)
+	c.trimGhosts()
+	c.eq.Schedule(k, v)
+}
+
+func (c *arc) Delete(_ context.Context, k string) bool {
+	c.l.Lock()
+	defer c.l.Unlock()
+	el, e := c.items[k]
+	if !e {
+		return false
+	}
+	cached := el.Value.(*arcEntry).list <= arcT2
+	c.remove(el)
+	return cached
+}
+
+func (c *arc) RemoveExpired(_ context.Context, now time.Time) []string {
+	keys := c.eq.PopExpired(now)
+	c.l.Lock()
+	defer c.l.Unlock()
+	var removed []string
+	for _, k := range keys {
+		el, e := c.items[k]
+		if !e {
+			continue
+		}
+		if en := el.Value.(*arcEntry); en.list <= arcT2 && Expired(en.val, now) {
+			c.remove(el)
+			removed = append(removed, k)
+		}
+	}
+	return removed
+}
+
+func (c *arc) Count(_ context.Context) int64 {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return int64(c.lists[arcT1].Len() + c.lists[arcT2].Len())
+}
+
+func (c *arc) CurrSize(_ context.Context) int64 {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.bytes[arcT1] + c.bytes[arcT2]
+}
+
+// Range calls f for a snapshot of the cached entries, ghosts are skipped.
+func (c *arc) Range(ctx context.Context, f func(k string, v *pb.CacheValue) bool) {
+	c.l.Lock()
+	ents := make([]entry, 0, c.lists[arcT1].Len()+c.lists[arcT2].Len())
+	for _, l := range c.lists[arcT1 : arcT2+1] {
+		for el := l.Front(); el != nil; el = el.Next() {
+			ents = append(ents, el.Value.(*arcEntry).entry)
+		}
+	}
+	c.l.Unlock()
+	rangeEntries(ctx, ents, f)
+}
+
+// delta returns how much p adapts on a ghost hit in list `in`, the rarer the
+// ghost list compared to the `other`, the bigger the step. c.l must be held.
+func (c *arc) delta(s int64, other, in int) int64 {
+	if c.bytes[in] > 0 && c.bytes[other] > c.bytes[in] {
+		return s * (c.bytes[other] / c.bytes[in])
+	}
+	return s
+}
+
+// replace moves entries from t1 or t2 to their ghost lists until the cached
+// bytes fit, never picking keep. c.l must be held.
+func (c *arc) replace(keep *arcEntry) {
+	for c.bytes[arcT1]+c.bytes[arcT2] > c.maxBytes {
+		t1 := c.victim(arcT1, keep)
+		t2 := c.victim(arcT2, keep)
+		if t1 != nil && (c.bytes[arcT1] > c.p || t2 == nil) {
+			c.ghost(t1, arcB1)
+		} else {
+			c.ghost(t2, arcB2)
+		}
+	}
+}
+
+// victim returns the least recent element of list l other than keep.
+func (c *arc) victim(l int, keep *arcEntry) *list.Element {
+	el := c.lists[l].Back()
+	if el != nil && el.Value.(*arcEntry) == keep {
+		el = el.Prev()
+	}
+	return el
+}
+
+// trimGhosts bounds the ghost lists: t1+b1 to the cache size and all lists
+// to twice the cache size. c.l must be held.
+func (c *arc) trimGhosts() {
+	for c.bytes[arcT1]+c.bytes[arcB1] > c.maxBytes && c.lists[arcB1].Len() > 0 {
+		c.remove(c.lists[arcB1].Back())
+	}
+	total := func() int64 { return c.bytes[arcT1] + c.bytes[arcT2] + c.bytes[arcB1] + c.bytes[arcB2] }
+	for total() > 2*c.maxBytes && c.lists[arcB2].Len() > 0 {
+		c.remove(c.lists[arcB2].Back())
+	}
+}
+
+// ghost turns a cached element into a ghost in list l, c.l must be held.
+func (c *arc) ghost(el *list.Element, l int) {
+	en := el.Value.(*arcEntry)
+	en.val = nil
+	c.move(el, l)
+}
+
+// move moves el to the front of list l, c.l must be held.
+func (c *arc) move(el *list.Element, l int) {
+	en := el.Value.(*arcEntry)
+	c.lists[en.list].Remove(el)
+	c.bytes[en.list] -= en.size
+	c.push(en, l)
+}
+
+// push adds en to the front of list l, c.l must be held.
+func (c *arc) push(en *arcEntry, l int) *list.Element {
+	en.list = l
+	el := c.lists[l].PushFront(en)
+	c.items[en.key] = el
+	c.bytes[l] += en.size
+	return el
+}
+
+// remove drops el from the cache and the ghost lists, c.l must be held.
+func (c *arc) remove(el *list.Element) {
+	en := el.Value.(*arcEntry)
+	c.lists[en.list].Remove(el)
+	c.bytes[en.list] -= en.size
+	delete(c.items, en.key)
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
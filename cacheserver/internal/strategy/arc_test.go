@@ -0,0 +1,59 @@
+package strategy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestARCScanResistance(t *testing.T) {
+	c := NewARCCache(maxBytes)
+	for i := 1; i <= 5; i++ {
+		k := fmt.Sprintf("key_%02d", i)
+		c.Set(ctx, k, cacheValue(v, ts))
+		c.Get(ctx, k) // seen twice, moves to t2
+	}
+	for i := 20; i < 70; i++ {
+		c.Set(ctx, fmt.Sprintf("key_%02d", i), cacheValue(v, ts))
+	}
+	for i := 1; i <= 5; i++ {
+		_, e := c.Get(ctx, fmt.Sprintf("key_%02d", i))
+		require.True(t, e, "hot key_%02d was evicted by the scan", i)
+	}
+	require.EqualValues(t, 10, c.Count(ctx))
+	require.NoError(t, c.validate())
+}
+
+func TestARCGhostHitAdaptsTarget(t *testing.T) {
+	c := NewARCCache(maxBytes)
+	for i := 1; i <= 10; i++ {
+		c.Set(ctx, fmt.Sprintf("key_%02d", i), cacheValue(v, ts))
+	}
+	for i := 6; i <= 10; i++ {
+		c.Get(ctx, fmt.Sprintf("key_%02d", i))
+	}
+	c.Set(ctx, "key_11", cacheValue(v, ts))
+	// key_01 was evicted from t1 and is remembered as a ghost
+	_, e := c.Get(ctx, "key_01")
+	require.False(t, e)
+	require.EqualValues(t, 0, c.p)
+
+	c.Set(ctx, "key_01", cacheValue(v, ts))
+	require.Greater(t, c.p, int64(0))
+	require.Equal(t, arcT2, c.items["key_01"].Value.(*arcEntry).list)
+	require.NoError(t, c.validate())
+}
+
+func TestARCDeleteGhost(t *testing.T) {
+	c := NewARCCache(maxBytes)
+	for i := 1; i <= 10; i++ {
+		c.Set(ctx, fmt.Sprintf("key_%02d", i), cacheValue(v, ts))
+		c.Get(ctx, fmt.Sprintf("key_%02d", i))
+	}
+	c.Set(ctx, "key_11", cacheValue(v, ts))
+	require.Equal(t, arcB2, c.items["key_01"].Value.(*arcEntry).list)
+	require.False(t, c.Delete(ctx, "key_01")) // only a ghost
+	require.NotContains(t, c.items, "key_01")
+	require.NoError(t, c.validate())
+}
@@ -0,0 +1,417 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/althk/ganache/cacheserver/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// testCache is what every eviction policy must provide to pass the
+// conformance tests.
+type testCache interface {
+	Get(ctx context.Context, key string) (*pb.CacheValue, bool)
+	Set(ctx context.Context, key string, val *pb.CacheValue) (int64, error)
+	Update(ctx context.Context, key string, f func(curr *pb.CacheValue) (*pb.CacheValue, error)) (int64, error)
+	Delete(ctx context.Context, key string) bool
+	Count(ctx context.Context) int64
+	CurrSize(ctx context.Context) int64
+	Range(ctx context.Context, f func(k string, v *pb.CacheValue) bool)
+	RemoveExpired(ctx context.Context, now time.Time) []string
+	validate() error // checks internal bookkeeping
+}
+
+var policies = map[string]func(maxBytes int64) testCache{
+	LRU:     func(maxBytes int64) testCache { return NewLRUCache(maxBytes) },
+	LFU:     func(maxBytes int64) testCache { return NewLFUCache(maxBytes) },
+	TinyLFU: func(maxBytes int64) testCache { return NewTinyLFUCache(maxBytes) },
+	ARC:     func(maxBytes int64) testCache { return NewARCCache(maxBytes) },
+}
+
+func forEachPolicy(t *testing.T, f func(t *testing.T, newCache func(maxBytes int64) testCache)) {
+	require.Len(t, policies, len(Policies))
+	for _, p := range Policies {
+		t.Run(p, func(t *testing.T) {
+			f(t, policies[p])
+		})
+	}
+}
+
+func TestConformanceSetGet(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newCache func(int64) testCache) {
+		c := newCache(maxBytes)
+		s, err := c.Set(ctx, "key_01", cacheValue(v, ts))
+		require.NoError(t, err)
+		require.EqualValues(t, es, s)
+		got, e := c.Get(ctx, "key_01")
+		require.True(t, e)
+		require.EqualValues(t, cacheValue(v, ts), got)
+		_, e = c.Get(ctx, "nonexistent_key")
+		require.False(t, e)
+		require.EqualValues(t, 1, c.Count(ctx))
+		require.EqualValues(t, es, c.CurrSize(ctx))
+		require.NoError(t, c.validate())
+	})
+}
+
+func TestConformanceOverwrite(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newCache func(int64) testCache) {
+		c := newCache(maxBytes)
+		c.Set(ctx, "key_01", cacheValue(v, ts))
+		c.Set(ctx, "key_02", cacheValue(v, ts))
+		s, err := c.Set(ctx, "key_01", cacheValue("1byte", ts))
+		require.NoError(t, err)
+		require.EqualValues(t, ks+5, s)
+		require.EqualValues(t, 2, c.Count(ctx))
+		require.EqualValues(t, es+ks+5, c.CurrSize(ctx))
+		got, e := c.Get(ctx, "key_01")
+		require.True(t, e)
+		require.EqualValues(t, "1byte", got.Data.Value)
+		require.NoError(t, c.validate())
+	})
+}
+
+func TestConformanceDelete(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newCache func(int64) testCache) {
+		c := newCache(maxBytes)
+		c.Set(ctx, "key_01", cacheValue(v, ts))
+		c.Set(ctx, "key_02", cacheValue(v, ts))
+		require.True(t, c.Delete(ctx, "key_01"))
+		require.False(t, c.Delete(ctx, "key_01"))
+		_, e := c.Get(ctx, "key_01")
+		require.False(t, e)
+		require.EqualValues(t, 1, c.Count(ctx))
+		require.EqualValues(t, es, c.CurrSize(ctx))
+		require.NoError(t, c.validate())
+	})
+}
+
+func TestConformanceRange(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newCache func(int64) testCache) {
+		c := newCache(maxBytes)
+		for i := 0; i < 5; i++ {
+			c.Set(ctx, fmt.Sprintf("key_%02d", i), cacheValue(v, ts))
+		}
+		var got []string
+		c.Range(ctx, func(k string, val *pb.CacheValue) bool {
+			// the lock must not be held while f runs
+			c.Set(ctx, k, val)
+			got = append(got, k)
+			return true
+		})
+		require.ElementsMatch(t, []string{"key_00", "key_01", "key_02", "key_03", "key_04"}, got)
+
+		n := 0
+		c.Range(ctx, func(string, *pb.CacheValue) bool {
+			n++
+			return n < 2
+		})
+		require.Equal(t, 2, n)
+		require.NoError(t, c.validate())
+	})
+}
+
+func TestConformanceUpdate(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newCache func(int64) testCache) {
+		c := newCache(maxBytes)
+		bump := func(curr *pb.CacheValue) (*pb.CacheValue, error) {
+			nv := cacheValue(v, ts)
+			nv.Version = curr.GetVersion() + 1
+			return nv, nil
+		}
+		s, err := c.Update(ctx, "key_01", bump)
+		require.NoError(t, err)
+		require.EqualValues(t, es, s)
+		c.Update(ctx, "key_01", bump)
+		got, _ := c.Get(ctx, "key_01")
+		require.EqualValues(t, 2, got.Version)
+
+		errNope := errors.New("nope")
+		_, err = c.Update(ctx, "key_01", func(*pb.CacheValue) (*pb.CacheValue, error) {
+			return nil, errNope
+		})
+		require.ErrorIs(t, err, errNope)
+		_, err = c.Update(ctx, "key_01", func(*pb.CacheValue) (*pb.CacheValue, error) {
+			return cacheValue(string(make([]byte, maxBytes)), ts), nil
+		})
+		require.ErrorIs(t, err, ErrValueTooLarge)
+		s, err = c.Update(ctx, "key_01", func(*pb.CacheValue) (*pb.CacheValue, error) {
+			return nil, nil
+		})
+		require.NoError(t, err)
+		require.Zero(t, s)
+		got, _ = c.Get(ctx, "key_01")
+		require.EqualValues(t, 2, got.Version)
+		require.EqualValues(t, 1, c.Count(ctx))
+		require.NoError(t, c.validate())
+
+		_, err = c.Update(ctx, "key_01", func(curr *pb.CacheValue) (*pb.CacheValue, error) {
+			require.EqualValues(t, 2, curr.Version)
+			return Remove, nil
+		})
+		require.NoError(t, err)
+		_, ok := c.Get(ctx, "key_01")
+		require.False(t, ok)
+		require.Zero(t, c.Count(ctx))
+		require.Zero(t, c.CurrSize(ctx))
+		require.NoError(t, c.validate())
+	})
+}
+
+func TestConformanceSetTooLarge(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newCache func(int64) testCache) {
+		c := newCache(maxBytes)
+		c.Set(ctx, "key_01", cacheValue(v, ts))
+		_, err := c.Set(ctx, "key_02", cacheValue(string(make([]byte, maxBytes)), ts))
+		require.ErrorIs(t, err, ErrValueTooLarge)
+		require.EqualValues(t, 1, c.Count(ctx))
+		require.NoError(t, c.validate())
+	})
+}
+
+func TestConformanceRemoveExpired(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newCache func(int64) testCache) {
+		c := newCache(maxBytes)
+		now := time.Now()
+		expiring := cacheValue(v, ts)
+		expiring.ExpiresAt = timestamppb.New(now.Add(time.Minute))
+		c.Set(ctx, "key_01", expiring)
+		c.Set(ctx, "key_02", expiring)
+		c.Set(ctx, "key_02", cacheValue(v, ts)) // no longer expires
+
+		require.Empty(t, c.RemoveExpired(ctx, now))
+		require.Equal(t, []string{"key_01"}, c.RemoveExpired(ctx, now.Add(time.Minute)))
+		_, e := c.Get(ctx, "key_01")
+		require.False(t, e)
+		_, e = c.Get(ctx, "key_02")
+		require.True(t, e)
+		require.EqualValues(t, es, c.CurrSize(ctx))
+		require.NoError(t, c.validate())
+	})
+}
+
+func TestConformanceStaysUnderMaxBytes(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newCache func(int64) testCache) {
+		c := newCache(maxBytes)
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 1000; i++ {
+			k := fmt.Sprintf("key_%02d", r.Intn(50))
+			if r.Intn(3) == 0 {
+				c.Get(ctx, k)
+				continue
+			}
+			_, err := c.Set(ctx, k, cacheValue(string(make([]byte, r.Intn(4*se))), ts))
+			require.NoError(t, err)
+			require.LessOrEqual(t, c.CurrSize(ctx), maxBytes)
+			require.NoError(t, c.validate())
+		}
+		// a full cache must keep a sensible number of entries
+		require.Greater(t, c.Count(ctx), int64(1))
+	})
+}
+
+// TestConformanceConcurrentAccess is meant to be run with -race, it hammers
+// the cache from many goroutines and then checks that the bookkeeping agrees.
+func TestConformanceConcurrentAccess(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newCache func(int64) testCache) {
+		c := newCache(maxBytes)
+		var wg sync.WaitGroup
+		for g := 0; g < 16; g++ {
+			wg.Add(1)
+			go func(seed int64) {
+				defer wg.Done()
+				r := rand.New(rand.NewSource(seed))
+				for i := 0; i < 2000; i++ {
+					k := fmt.Sprintf("key_%02d", r.Intn(30))
+					switch r.Intn(4) {
+					case 0:
+						c.Delete(ctx, k)
+					case 1:
+						c.Get(ctx, k)
+					default:
+						c.Set(ctx, k, cacheValue(v[:r.Intn(se)+1], ts))
+					}
+					if s := c.CurrSize(ctx); s > maxBytes {
+						t.Errorf("cache size %d exceeds max %d", s, maxBytes)
+					}
+				}
+			}(int64(g))
+		}
+		wg.Wait()
+		require.NoError(t, c.validate())
+	})
+}
+
+func TestConformanceConcurrentUpdate(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newCache func(int64) testCache) {
+		c := newCache(maxBytes)
+		var wg sync.WaitGroup
+		for g := 0; g < 16; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					c.Update(ctx, "key_01", func(curr *pb.CacheValue) (*pb.CacheValue, error) {
+						nv := cacheValue(v, ts)
+						nv.Version = curr.GetVersion() + 1
+						return nv, nil
+					})
+				}
+			}()
+		}
+		wg.Wait()
+		got, _ := c.Get(ctx, "key_01")
+		require.EqualValues(t, 1600, got.Version)
+		require.NoError(t, c.validate())
+	})
+}
+
+func TestConformanceConcurrentExpiry(t *testing.T) {
+	forEachPolicy(t, func(t *testing.T, newCache func(int64) testCache) {
+		c := newCache(maxBytes)
+		sctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go RunExpirySweeper(sctx, c, time.Millisecond, nil)
+
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 500; i++ {
+					cv := cacheValue(v, ts)
+					cv.ExpiresAt = timestamppb.New(time.Now().Add(time.Duration(i%5) * time.Millisecond))
+					c.Set(ctx, fmt.Sprintf("key_%02d", (g*i)%20), cv)
+				}
+			}(g)
+		}
+		wg.Wait()
+		require.Eventually(t, func() bool {
+			return c.Count(ctx) == 0
+		}, time.Second, time.Millisecond)
+		require.NoError(t, c.validate())
+	})
+}
+
+func (c *lru) validate() error {
+	c.l.Lock()
+	defer c.l.Unlock()
+	if len(c.items) != c.ll.Len() {
+		return fmt.Errorf("%d keys in map, %d in list", len(c.items), c.ll.Len())
+	}
+	var total int64
+	for el := c.ll.Front(); el != nil; el = el.Next() {
+		en := el.Value.(*entry)
+		if c.items[en.key] != el {
+			return fmt.Errorf("map and list disagree on %v", en.key)
+		}
+		total += en.size
+	}
+	return checkBytes(total, c.currBytes, c.maxBytes)
+}
+
+func (c *lfu) validate() error {
+	c.l.Lock()
+	defer c.l.Unlock()
+	var total int64
+	n := 0
+	for f, l := range c.freqs {
+		if l.Len() == 0 {
+			return fmt.Errorf("empty list for freq %d", f)
+		}
+		if f < c.minFreq {
+			return fmt.Errorf("freq %d is below min freq %d", f, c.minFreq)
+		}
+		for el := l.Front(); el != nil; el = el.Next() {
+			en := el.Value.(*lfuEntry)
+			if c.items[en.key] != el || en.freq != f {
+				return fmt.Errorf("map and lists disagree on %v", en.key)
+			}
+			total += en.size
+			n++
+		}
+	}
+	if n != len(c.items) {
+		return fmt.Errorf("%d keys in map, %d in lists", len(c.items), n)
+	}
+	return checkBytes(total, c.currBytes, c.maxBytes)
+}
+
+func (c *arc) validate() error {
+	c.l.Lock()
+	defer c.l.Unlock()
+	n := 0
+	for i, l := range c.lists {
+		var total int64
+		for el := l.Front(); el != nil; el = el.Next() {
+			en := el.Value.(*arcEntry)
+			if c.items[en.key] != el || en.list != i {
+				return fmt.Errorf("map and lists disagree on %v", en.key)
+			}
+			if (en.val == nil) != (i == arcB1 || i == arcB2) {
+				return fmt.Errorf("ghost state of %v is wrong", en.key)
+			}
+			total += en.size
+			n++
+		}
+		if total != c.bytes[i] {
+			return fmt.Errorf("list %d holds %d bytes, counted %d", i, total, c.bytes[i])
+		}
+	}
+	if n != len(c.items) {
+		return fmt.Errorf("%d keys in map, %d in lists", len(c.items), n)
+	}
+	if c.p < 0 || c.p > c.maxBytes {
+		return fmt.Errorf("target t1 size %d out of bounds", c.p)
+	}
+	if g := c.bytes[arcT1] + c.bytes[arcT2] + c.bytes[arcB1] + c.bytes[arcB2]; g > 2*c.maxBytes {
+		return fmt.Errorf("%d bytes tracked including ghosts, max %d", g, 2*c.maxBytes)
+	}
+	return checkBytes(c.bytes[arcT1]+c.bytes[arcT2], c.bytes[arcT1]+c.bytes[arcT2], c.maxBytes)
+}
+
+func (c *tinyLFU) validate() error {
+	c.l.Lock()
+	defer c.l.Unlock()
+	n := 0
+	var all int64
+	for i, l := range c.segs {
+		var total int64
+		for el := l.Front(); el != nil; el = el.Next() {
+			en := el.Value.(*tlfuEntry)
+			if c.items[en.key] != el || en.seg != i {
+				return fmt.Errorf("map and segments disagree on %v", en.key)
+			}
+			total += en.size
+			n++
+		}
+		if total != c.bytes[i] {
+			return fmt.Errorf("segment %d holds %d bytes, counted %d", i, total, c.bytes[i])
+		}
+		all += total
+	}
+	if n != len(c.items) {
+		return fmt.Errorf("%d keys in map, %d in segments", len(c.items), n)
+	}
+	if c.segs[tlfuWindow].Len() > 1 && c.bytes[tlfuWindow] > c.maxBytes[tlfuWindow] {
+		return fmt.Errorf("window holds %d bytes, max %d", c.bytes[tlfuWindow], c.maxBytes[tlfuWindow])
+	}
+	return checkBytes(all, c.currBytes, c.maxBytes[tlfuWindow]+c.maxBytes[tlfuProbation])
+}
+
+func checkBytes(total, curr, max int64) error {
+	if total != curr {
+		return fmt.Errorf("entries hold %d bytes, counted %d", total, curr)
+	}
+	if curr > max {
+		return fmt.Errorf("cache holds %d bytes, max %d", curr, max)
+	}
+	return nil
+}
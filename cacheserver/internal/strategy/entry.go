@@ -0,0 +1,38 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/althk/ganache/cacheserver/proto"
+)
+
+var ErrValueTooLarge = errors.New("value is larger than the cache size")
+
+// Remove, returned by the function passed to Update, deletes the key.
+var Remove = &pb.CacheValue{}
+
+// entry is an item stored in the cache, size is the number of bytes
+// it accounts for in the cache.
+type entry struct {
+	key  string
+	val  *pb.CacheValue
+	size int64
+}
+
+// entrySize returns the bytes accounted for k and v, i.e. the key and the
+// serialized data it holds.
+func entrySize(k string, v *pb.CacheValue) int64 {
+	return int64(len(k) + len(v.GetData().GetValue()))
+}
+
+// rangeEntries calls f for each of ents until f returns false. Strategies
+// snapshot their entries under the lock and call this after releasing it,
+// so that f may block without holding up the cache.
+func rangeEntries(ctx context.Context, ents []entry, f func(k string, v *pb.CacheValue) bool) {
+	for _, en := range ents {
+		if ctx.Err() != nil || !f(en.key, en.val) {
+			return
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package strategy
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	pb "github.com/althk/ganache/cacheserver/proto"
+	"github.com/rs/zerolog/log"
+)
+
+// Expirer is implemented by caching strategies that can drop
+// expired entries on demand.
+type Expirer interface {
+	// RemoveExpired removes all entries that expired at or before now
+	// and returns their keys.
+	RemoveExpired(ctx context.Context, now time.Time) []string
+}
+
+// RunExpirySweeper calls RemoveExpired on c every interval until ctx is done,
+// and removed, if not nil, with the keys it removed.
+func RunExpirySweeper(ctx context.Context, c Expirer, interval time.Duration, removed func(keys []string)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			keys := c.RemoveExpired(ctx, now)
+			if len(keys) == 0 {
+				continue
+			}
+			log.Debug().Msgf("Expiry sweeper removed %d keys", len(keys))
+			if removed != nil {
+				removed(keys)
+			}
+		}
+	}
+}
+
+// Expired reports whether v has an expiry deadline at or before now.
+func Expired(v *pb.CacheValue, now time.Time) bool {
+	return v.GetExpiresAt() != nil && !v.ExpiresAt.AsTime().After(now)
+}
+
+// expiryItem is a key and the deadline it was scheduled with.
+type expiryItem struct {
+	key      string
+	deadline time.Time
+}
+
+// expiryQueue is a min-heap of keys ordered by their expiry deadline.
+// Entries are not removed when a key is overwritten or deleted, so callers
+// must check that a popped key is still expired before dropping it.
+type expiryQueue struct {
+	items []expiryItem
+	l     sync.Mutex
+}
+
+func (q *expiryQueue) Len() int           { return len(q.items) }
+func (q *expiryQueue) Less(i, j int) bool { return q.items[i].deadline.Before(q.items[j].deadline) }
+func (q *expiryQueue) Swap(i, j int)      { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *expiryQueue) Push(x any)         { q.items = append(q.items, x.(expiryItem)) }
+func (q *expiryQueue) Pop() any {
+	n := len(q.items)
+	it := q.items[n-1]
+	q.items = q.items[:n-1]
+	return it
+}
+
+// Schedule tracks k for expiry if v has a deadline.
+func (q *expiryQueue) Schedule(k string, v *pb.CacheValue) {
+	if v.GetExpiresAt() == nil {
+		return
+	}
+	q.l.Lock()
+	defer q.l.Unlock()
+	heap.Push(q, expiryItem{key: k, deadline: v.ExpiresAt.AsTime()})
+}
+
+// PopExpired removes and returns the keys whose deadline is at or before now.
+func (q *expiryQueue) PopExpired(now time.Time) []string {
+	q.l.Lock()
+	defer q.l.Unlock()
+	var keys []string
+	for len(q.items) > 0 && !q.items[0].deadline.After(now) {
+		keys = append(keys, heap.Pop(q).(expiryItem).key)
+	}
+	return keys
+}
@@ -0,0 +1,184 @@
+package strategy
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	pb "github.com/althk/ganache/cacheserver/proto"
+)
+
+// lfuEntry is an entry along with the number of times it was accessed.
+type lfuEntry struct {
+	entry
+	freq int
+}
+
+// lfu implements CachingStrategy and provides a LFU cache with O(1)
+// operations. Entries are bucketed by access frequency; within a bucket
+// the least recently used entry is evicted first.
+type lfu struct {
+	items     map[string]*list.Element // values are *lfuEntry
+	freqs     map[int]*list.List       // freq -> entries, most recent at the front
+	minFreq   int                      // never above the lowest freq in use
+	maxBytes  int64
+	currBytes int64
+	eq        expiryQueue
+	l         sync.Mutex
+}
+
+func (c *lfu) Get(_ context.Context, k string) (*pb.CacheValue, bool) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	el, e := c.items[k]
+	if !e {
+		return nil, false
+	}
+	en := c.removeElement(el)
+	c.insert(en.key, en.val, en.size, en.freq+1)
+	return en.val, true
+}
+
+func (c *lfu) Set(_ context.Context, k string, v *pb.CacheValue) (int64, error) {
+	s := entrySize(k, v)
+	if s > c.maxBytes {
+		return 0, ErrValueTooLarge
+	}
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.set(k, v, s)
+	return s, nil
+}
+
+func (c *lfu) Update(_ context.Context, k string, f func(curr *pb.CacheValue) (*pb.CacheValue, error)) (int64, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	var curr *pb.CacheValue
+	if el, e := c.items[k]; e {
+		curr = el.Value.(*lfuEntry).val
+	}
+	v, err := f(curr)
+	if v == nil || err != nil {
+		return 0, err
+	}
+	if v == Remove {
+		if el, e := c.items[k]; e {
+			c.removeElement(el)
+		}
+		return 0, nil
+	}
+	s := entrySize(k, v)
+	if s > c.maxBytes {
+		return 0, ErrValueTooLarge
+	}
+	c.set(k, v, s)
+	return s, nil
+}
+
+func (c *lfu) set(k string, v *pb.CacheValue, s int64) {
+	freq := 0
+	if el, e := c.items[k]; e {
+		// an overwrite counts as an access and keeps the history
+		freq = c.removeElement(el).freq
+	}
+	for c.currBytes+s > c.maxBytes {
+		c.evict()
+	}
+	c.insert(k, v, s, freq+1)
+	c.eq.Schedule(k, v)
+}
+
+func (c *lfu) Delete(_ context.Context, k string) bool {
+	c.l.Lock()
+	defer c.l.Unlock()
+	el, e := c.items[k]
+	if !e {
+		return false
+	}
+	c.removeElement(el)
+	return true
+}
+
+func (c *lfu) RemoveExpired(_ context.Context, now time.Time) []string {
+	keys := c.eq.PopExpired(now)
+	c.l.Lock()
+	defer c.l.Unlock()
+	var removed []string
+	for _, k := range keys {
+		if el, e := c.items[k]; e && Expired(el.Value.(*lfuEntry).val, now) {
+			c.removeElement(el)
+			removed = append(removed, k)
+		}
+	}
+	return removed
+}
+
+func (c *lfu) Count(_ context.Context) int64 {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return int64(len(c.items))
+}
+
+func (c *lfu) CurrSize(_ context.Context) int64 {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.currBytes
+}
+
+// Range calls f for a snapshot of the cached entries, without affecting
+// their frequency.
+func (c *lfu) Range(ctx context.Context, f func(k string, v *pb.CacheValue) bool) {
+	c.l.Lock()
+	ents := make([]entry, 0, len(c.items))
+	for _, el := range c.items {
+		ents = append(ents, el.Value.(*lfuEntry).entry)
+	}
+	c.l.Unlock()
+	rangeEntries(ctx, ents, f)
+}
+
+// insert adds a new entry with the given freq, c.l must be held.
+func (c *lfu) insert(k string, v *pb.CacheValue, s int64, freq int) {
+	l, e := c.freqs[freq]
+	if !e {
+		l = list.New()
+		c.freqs[freq] = l
+	}
+	if len(c.items) == 0 || freq < c.minFreq {
+		c.minFreq = freq
+	}
+	c.items[k] = l.PushFront(&lfuEntry{entry: entry{key: k, val: v, size: s}, freq: freq})
+	c.currBytes += s
+}
+
+// evict drops the least recently used of the least frequently used
+// entries, c.l must be held.
+func (c *lfu) evict() {
+	l, e := c.freqs[c.minFreq]
+	if !e {
+		// the bucket was emptied by a removal, find the real minimum
+		c.minFreq = -1
+		for f := range c.freqs {
+			if c.minFreq == -1 || f < c.minFreq {
+				c.minFreq = f
+			}
+		}
+		l = c.freqs[c.minFreq]
+	}
+	c.removeElement(l.Back())
+}
+
+// removeElement drops el from the cache and returns its entry,
+// c.l must be held.
+func (c *lfu) removeElement(el *list.Element) *lfuEntry {
+	en := el.Value.(*lfuEntry)
+	l := c.freqs[en.freq]
+	l.Remove(el)
+	if l.Len() == 0 {
+		delete(c.freqs, en.freq)
+	}
+	delete(c.items, en.key)
+	c.currBytes -= en.size
+	return en
+}
@@ -0,0 +1,58 @@
+package strategy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLFUEvictsLeastFrequentlyUsed(t *testing.T) {
+	c := NewLFUCache(maxBytes)
+	for i := 1; i <= 10; i++ {
+		c.Set(ctx, fmt.Sprintf("key_%02d", i), cacheValue(v, ts))
+	}
+	for i := 2; i <= 10; i++ {
+		c.Get(ctx, fmt.Sprintf("key_%02d", i))
+	}
+
+	c.Set(ctx, "key_11", cacheValue(v, ts))
+	_, e := c.Get(ctx, "key_01")
+	require.False(t, e) // the only key never read
+	require.EqualValues(t, 10, c.Count(ctx))
+
+	// key_11 is now the least frequently used
+	c.Set(ctx, "key_12", cacheValue(v, ts))
+	_, e = c.Get(ctx, "key_11")
+	require.False(t, e)
+	require.NoError(t, c.validate())
+}
+
+func TestLFUTiesEvictLeastRecentlyUsed(t *testing.T) {
+	c := NewLFUCache(maxBytes)
+	for i := 1; i <= 10; i++ {
+		c.Set(ctx, fmt.Sprintf("key_%02d", i), cacheValue(v, ts))
+	}
+	c.Set(ctx, "key_11", cacheValue(v, ts))
+	_, e := c.Get(ctx, "key_01")
+	require.False(t, e)
+	_, e = c.Get(ctx, "key_02")
+	require.True(t, e)
+}
+
+func TestLFUOverwriteKeepsFrequency(t *testing.T) {
+	c := NewLFUCache(maxBytes)
+	for i := 1; i <= 10; i++ {
+		c.Set(ctx, fmt.Sprintf("key_%02d", i), cacheValue(v, ts))
+	}
+	c.Get(ctx, "key_01")
+	c.Set(ctx, "key_01", cacheValue(v, ts)) // freq 3
+	for i := 2; i <= 10; i++ {
+		c.Get(ctx, fmt.Sprintf("key_%02d", i)) // freq 2
+	}
+	c.Set(ctx, "key_11", cacheValue(v, ts))
+	c.Set(ctx, "key_12", cacheValue(v, ts))
+	_, e := c.Get(ctx, "key_01")
+	require.True(t, e)
+	require.NoError(t, c.validate())
+}
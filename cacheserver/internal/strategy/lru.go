@@ -1,140 +1,146 @@
 package strategy
 
 import (
+	"container/list"
 	"context"
 	"sync"
-	"sync/atomic"
+	"time"
 
-	"github.com/althk/dmap"
 	pb "github.com/althk/ganache/cacheserver/proto"
 )
 
 // lru implements CachingStrategy and provides a LRU cache.
+// All operations, including eviction, happen synchronously under a single
+// lock so that the map, the recency list and the byte count always agree.
 type lru struct {
-	cm        dmap.DMap[string, *pb.CacheValue]
-	ll        *dll  // doubly linked list
-	maxBytes  int64 // total cache size
-	currBytes int64 // current cache size
+	items     map[string]*list.Element // values are *entry
+	ll        *list.List               // most recently used at the front
+	maxBytes  int64                    // total cache size
+	currBytes int64                    // current cache size
+	eq        expiryQueue
+	l         sync.Mutex // Get updates recency, so there are no read-only ops
 }
 
 func (c *lru) Get(_ context.Context, k string) (*pb.CacheValue, bool) {
-	v, e := c.cm.Get(k)
-	if e {
-		go func() {
-			c.ll.UpsertFront(k, true)
-		}()
+	c.l.Lock()
+	defer c.l.Unlock()
+	el, e := c.items[k]
+	if !e {
+		return nil, false
 	}
-	return v, e
+	c.ll.MoveToFront(el)
+	return el.Value.(*entry).val, true
 }
 
 func (c *lru) Set(_ context.Context, k string, v *pb.CacheValue) (int64, error) {
-	s := int64(len(v.Data.Value))
-	e := c.cm.Has(k)
-	c.cm.Set(k, v)
-	go func() {
-		atomic.AddInt64(&c.currBytes, s)
-		c.ll.UpsertFront(k, e)
-		if atomic.LoadInt64(&c.currBytes) > atomic.LoadInt64(&c.maxBytes) {
-			rk := c.ll.RemoveBack()
-			rv, _ := c.cm.Get(rk)
-			rs := int64(len(rv.Data.Value))
-			c.cm.Remove(rk)
-			atomic.AddInt64(&c.currBytes, -rs)
-		}
-	}()
+	s := entrySize(k, v)
+	if s > c.maxBytes {
+		return 0, ErrValueTooLarge
+	}
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.set(k, v, s)
 	return s, nil
 }
 
-func (c *lru) Count(_ context.Context) int64 {
-	return int64(c.cm.Count())
-}
-
-func (c *lru) CurrSize(_ context.Context) int64 {
-	return atomic.LoadInt64(&c.currBytes)
+// Update caches the value f returns for the current value of k (nil if not
+// cached), f runs under the cache's lock. Nothing changes if f fails or
+// returns a nil value.
+func (c *lru) Update(_ context.Context, k string, f func(curr *pb.CacheValue) (*pb.CacheValue, error)) (int64, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	var curr *pb.CacheValue
+	if el, e := c.items[k]; e {
+		curr = el.Value.(*entry).val
+	}
+	v, err := f(curr)
+	if v == nil || err != nil {
+		return 0, err
+	}
+	if v == Remove {
+		if el, e := c.items[k]; e {
+			c.removeElement(el)
+		}
+		return 0, nil
+	}
+	s := entrySize(k, v)
+	if s > c.maxBytes {
+		return 0, ErrValueTooLarge
+	}
+	c.set(k, v, s)
+	return s, nil
 }
 
-// lln represents a node in a doubly linked list.
-// NOTE: this is a 'hardcoded' implementation for our LRU
-// cache and as such, the data it holds is the key of the
-// cached item as a string.
-type dlln struct {
-	data string
-	next *dlln // next node pointer
-	prev *dlln // prev node pointer
+// set caches v of size s under k, c.l must be held.
+func (c *lru) set(k string, v *pb.CacheValue, s int64) {
+	if el, e := c.items[k]; e {
+		en := el.Value.(*entry)
+		c.currBytes += s - en.size
+		en.val, en.size = v, s
+		c.ll.MoveToFront(el)
+	} else {
+		c.items[k] = c.ll.PushFront(&entry{key: k, val: v, size: s})
+		c.currBytes += s
+	}
+	c.eq.Schedule(k, v)
+	for c.currBytes > c.maxBytes {
+		c.removeElement(c.ll.Back())
+	}
 }
 
-// dll represents a doubly linked list.
-// We are not using container/list type here because
-// the remove operation is not optimal for our LRU use case.
-// We need a remove that always simply drops the last element,
-// i.e., there is no need to find the element and then delete.
-// NOTE: this is a 'hardcoded' impl for the LRU cache, as such
-// the impl has only partial functionality of a normal doubly
-// linked list.
-type dll struct {
-	head *dlln
-	tail *dlln
-	l    sync.Mutex // don't need a rwmutex as we don't read from this ll
+func (c *lru) Delete(_ context.Context, k string) bool {
+	c.l.Lock()
+	defer c.l.Unlock()
+	el, e := c.items[k]
+	if !e {
+		return false
+	}
+	c.removeElement(el)
+	return true
 }
 
-func (l *dll) InsertFront(k string) {
-	l.l.Lock()
-	defer l.l.Unlock()
-	nh := &dlln{
-		data: k,
-		next: l.head,
-		prev: nil,
-	}
-	if l.head != nil {
-		l.head.prev = nh
-	}
-	l.head = nh
-	if l.tail == nil {
-		l.tail = l.head
+func (c *lru) RemoveExpired(_ context.Context, now time.Time) []string {
+	keys := c.eq.PopExpired(now)
+	c.l.Lock()
+	defer c.l.Unlock()
+	var removed []string
+	for _, k := range keys {
+		// the key may have been overwritten with a later deadline
+		if el, e := c.items[k]; e && Expired(el.Value.(*entry).val, now) {
+			c.removeElement(el)
+			removed = append(removed, k)
+		}
 	}
+	return removed
 }
 
-func (l *dll) RemoveBack() string {
-	l.l.Lock()
-	defer l.l.Unlock()
-	v := l.tail.data
-	l.tail = l.tail.prev
-	l.tail.next = nil
-	return v
+func (c *lru) Count(_ context.Context) int64 {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return int64(c.ll.Len())
 }
 
-func (l *dll) MoveToFront(k string) {
-	l.l.Lock()
-	defer l.l.Unlock()
-	if l.head.data == k {
-		return // node is already head
-	}
-	n := l.head
-	for n != nil {
-		if n.data == k {
-			n.prev.next = n.next
-			t := l.head
-			l.head = &dlln{
-				data: n.data,
-				next: t,
-				prev: nil,
-			}
-			t.prev = l.head
+func (c *lru) CurrSize(_ context.Context) int64 {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.currBytes
+}
 
-			// adjust tail
-			if n.next == nil {
-				l.tail = n.prev
-			}
-			break
-		}
-		n = n.next
+// Range calls f for a snapshot of the cached entries, without affecting
+// their recency.
+func (c *lru) Range(ctx context.Context, f func(k string, v *pb.CacheValue) bool) {
+	c.l.Lock()
+	ents := make([]entry, 0, len(c.items))
+	for el := c.ll.Front(); el != nil; el = el.Next() {
+		ents = append(ents, *el.Value.(*entry))
 	}
+	c.l.Unlock()
+	rangeEntries(ctx, ents, f)
 }
 
-func (l *dll) UpsertFront(k string, exists bool) {
-	if exists {
-		l.MoveToFront(k)
-		return
-	}
-	l.InsertFront(k)
+// removeElement drops el from the cache, c.l must be held.
+func (c *lru) removeElement(el *list.Element) {
+	en := c.ll.Remove(el).(*entry)
+	delete(c.items, en.key)
+	c.currBytes -= en.size
 }
@@ -16,10 +16,11 @@ var (
 	ctx      = context.TODO()
 	v        = "10byte val"
 	se       = len([]byte(v))
+	ks       = len("key_01")
+	es       = ks + se // size of one cache entry
 	ts       = timestamppb.Now()
 	c        *lru
-	maxBytes = int64(100)
-	ll       *dll
+	maxBytes = int64(es * 10)
 )
 
 func TestLRUNew(t *testing.T) {
@@ -39,73 +40,77 @@ func TestLRUSetRemovesOldestItem(t *testing.T) {
 	populateCache(t)
 
 	// check LRU impact
-	require.True(t, c.cm.Has("key_1"))
+	require.True(t, has(c, "key_01"))
 	s, err := c.Set(ctx, "key_11", cacheValue(v, ts))
-	time.Sleep(time.Millisecond * 1) // required for allowing other goroutines to run
 
 	require.NoError(t, err)
 	require.EqualValues(t, 10, c.Count(ctx))
-	require.EqualValues(t, se, s)
-	require.EqualValues(t, se*10, c.CurrSize(ctx))
-	require.False(t, c.cm.Has("key_1")) // oldest key should be dropped
-	require.True(t, c.cm.Has("key_11"))
+	require.EqualValues(t, es, s)
+	require.EqualValues(t, es*10, c.CurrSize(ctx))
+	require.False(t, has(c, "key_01")) // oldest key should be dropped
+	require.True(t, has(c, "key_11"))
+	require.NoError(t, c.validate())
 }
 
-func TestLRUGetNonexistentItem(t *testing.T) {
+func TestLRUGetPromotesItem(t *testing.T) {
 	populateCache(t)
-	got, e := c.Get(ctx, "nonexistent_key")
-	require.Nil(t, got)
-	require.False(t, e)
-}
-func TestLRUGetExistingItem(t *testing.T) {
-	populateCache(t)
-	got, e := c.Get(ctx, "key_10")
+
+	_, e := c.Get(ctx, "key_01")
 	require.True(t, e)
-	require.EqualValues(t, cacheValue(v, ts), got)
+	_, err := c.Set(ctx, "key_11", cacheValue(v, ts))
+	require.NoError(t, err)
+	require.True(t, has(c, "key_01"))  // recently read, kept
+	require.False(t, has(c, "key_02")) // now the oldest key
+	require.NoError(t, c.validate())
 }
 
-func TestDLLUpsertFrontNewElement(t *testing.T) {
-	ll = &dll{}
-	ll.UpsertFront("key1", false)
-	require.EqualValues(t, "key1", ll.head.data)
-	require.EqualValues(t, "key1", ll.tail.data)
+func TestLRUEvictsUntilUnderMaxBytes(t *testing.T) {
+	populateCache(t)
 
-	ll.UpsertFront("key2", false)
-	ll.UpsertFront("key3", false)
-	require.EqualValues(t, "key3", ll.head.data)
-	require.EqualValues(t, "key1", ll.tail.data)
+	// needs the space of three entries
+	big := cacheValue(string(make([]byte, es*3-ks)), ts)
+	_, err := c.Set(ctx, "key_11", big)
+	require.NoError(t, err)
+	require.EqualValues(t, 8, c.Count(ctx))
+	require.EqualValues(t, es*10, c.CurrSize(ctx))
+	for _, k := range []string{"key_01", "key_02", "key_03"} {
+		require.False(t, has(c, k))
+	}
+	require.True(t, has(c, "key_04"))
+	require.NoError(t, c.validate())
 }
 
-func TestDLLUpsertFrontExistingElement(t *testing.T) {
-	ll = &dll{}
-	ll.UpsertFront("key1", false)
-	ll.UpsertFront("key2", false)
-	ll.UpsertFront("key3", false)
-	ll.UpsertFront("key1", true)
-	require.EqualValues(t, "key1", ll.head.data)
-	require.EqualValues(t, "key2", ll.tail.data)
+func TestRunExpirySweeper(t *testing.T) {
+	c = NewLRUCache(maxBytes)
+	expiring := cacheValue(v, ts)
+	expiring.ExpiresAt = timestamppb.New(time.Now().Add(10 * time.Millisecond))
+	c.Set(ctx, "key_01", expiring)
+
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	removed := make(chan []string, 1)
+	go RunExpirySweeper(sctx, c, 5*time.Millisecond, func(keys []string) { removed <- keys })
+	require.Eventually(t, func() bool {
+		return c.Count(ctx) == 0
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, []string{"key_01"}, <-removed)
 }
 
-func TestDLLRemoveBack(t *testing.T) {
-	ll = &dll{}
-	ll.UpsertFront("key1", false)
-	ll.UpsertFront("key2", false)
-	ll.UpsertFront("key3", false)
-	k := ll.RemoveBack()
-	require.EqualValues(t, "key1", k)
-	require.EqualValues(t, "key2", ll.tail.data)
-	require.EqualValues(t, "key3", ll.head.data)
+func has(c *lru, k string) bool {
+	c.l.Lock()
+	defer c.l.Unlock()
+	_, e := c.items[k]
+	return e
 }
 
 func populateCache(t *testing.T) {
 	c = NewLRUCache(maxBytes)
 	for i := 1; i <= 10; i++ {
-		s, err := c.Set(ctx, fmt.Sprintf("key_%d", i), cacheValue(v, ts))
-		time.Sleep(time.Millisecond * 1) // required for allowing other goroutines to run
+		s, err := c.Set(ctx, fmt.Sprintf("key_%02d", i), cacheValue(v, ts))
 		require.NoError(t, err)
 		require.EqualValues(t, i, c.Count(ctx))
-		require.EqualValues(t, se, s)
-		require.EqualValues(t, se*i, c.CurrSize(ctx))
+		require.EqualValues(t, es, s)
+		require.EqualValues(t, es*i, c.CurrSize(ctx))
 	}
 }
 
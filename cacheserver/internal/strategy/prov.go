@@ -1,15 +1,68 @@
 package strategy
 
 import (
-	"github.com/althk/dmap"
-	pb "github.com/althk/ganache/cacheserver/proto"
+	"container/list"
+	"fmt"
 )
 
+// Eviction policies.
+const (
+	LRU     = "lru"
+	LFU     = "lfu"
+	TinyLFU = "tinylfu"
+	ARC     = "arc"
+)
+
+// Policies lists the supported eviction policies.
+var Policies = []string{LRU, LFU, TinyLFU, ARC}
+
+var ErrUnknownPolicy = fmt.Errorf("unknown eviction policy, must be one of %v", Policies)
+
 func NewLRUCache(maxBytes int64) *lru {
 	return &lru{
-		cm:       dmap.New[string, *pb.CacheValue](26),
-		ll:       &dll{},
+		items:    make(map[string]*list.Element),
+		ll:       list.New(),
+		maxBytes: maxBytes,
+	}
+
+}
+
+func NewLFUCache(maxBytes int64) *lfu {
+	return &lfu{
+		items:    make(map[string]*list.Element),
+		freqs:    make(map[int]*list.List),
 		maxBytes: maxBytes,
 	}
+}
 
+func NewARCCache(maxBytes int64) *arc {
+	c := &arc{
+		items:    make(map[string]*list.Element),
+		maxBytes: maxBytes,
+	}
+	for i := range c.lists {
+		c.lists[i] = list.New()
+	}
+	return c
+}
+
+func NewTinyLFUCache(maxBytes int64) *tinyLFU {
+	window := maxBytes * tlfuWindowPercent / 100
+	main := maxBytes - window
+	width := int(maxBytes / tlfuAvgEntryBytes)
+	if width < tlfuMinSketchWidth {
+		width = tlfuMinSketchWidth
+	}
+	if width > tlfuMaxSketchWidth {
+		width = tlfuMaxSketchWidth
+	}
+	c := &tinyLFU{
+		items:    make(map[string]*list.Element),
+		maxBytes: [3]int64{window, main, main * tlfuProtectedPercent / 100},
+		sketch:   newCMSketch(width),
+	}
+	for i := range c.segs {
+		c.segs[i] = list.New()
+	}
+	return c
 }
@@ -0,0 +1,85 @@
+package strategy
+
+import "hash/fnv"
+
+const (
+	sketchDepth   = 4
+	sketchMaxFreq = 15 // counters saturate, like the 4-bit counters of TinyLFU
+)
+
+// cmSketch is a count-min sketch that estimates how often a key was seen.
+// Once the number of increments reaches the sample size all counters are
+// halved, so that the estimates favour recent popularity.
+type cmSketch struct {
+	rows      [sketchDepth][]uint8
+	seeds     [sketchDepth]uint64
+	mask      uint64
+	additions int
+	sample    int
+}
+
+// newCMSketch returns a sketch with width rounded up to a power of two.
+func newCMSketch(width int) *cmSketch {
+	w := 1
+	for w < width {
+		w <<= 1
+	}
+	s := &cmSketch{
+		mask:   uint64(w - 1),
+		sample: 10 * w,
+		seeds:  [sketchDepth]uint64{0x9e3779b97f4a7c15, 0xbf58476d1ce4e5b9, 0x94d049bb133111eb, 0xc2b2ae3d27d4eb4f},
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, w)
+	}
+	return s
+}
+
+func (s *cmSketch) Increment(k string) {
+	h := hashKey(k)
+	for i := range s.rows {
+		idx := s.index(h, i)
+		if s.rows[i][idx] < sketchMaxFreq {
+			s.rows[i][idx]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.sample {
+		s.reset()
+	}
+}
+
+// Estimate returns the estimated frequency of k.
+func (s *cmSketch) Estimate(k string) uint8 {
+	h := hashKey(k)
+	f := uint8(sketchMaxFreq)
+	for i := range s.rows {
+		if v := s.rows[i][s.index(h, i)]; v < f {
+			f = v
+		}
+	}
+	return f
+}
+
+func (s *cmSketch) index(h uint64, row int) uint64 {
+	h ^= s.seeds[row]
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	return h & s.mask
+}
+
+// reset halves all counters.
+func (s *cmSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+func hashKey(k string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(k))
+	return h.Sum64()
+}
@@ -0,0 +1,262 @@
+package strategy
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	pb "github.com/althk/ganache/cacheserver/proto"
+)
+
+// W-TinyLFU segments.
+const (
+	tlfuWindow    = iota // admission window, plain LRU
+	tlfuProbation        // main SLRU, seen once while in main
+	tlfuProtected        // main SLRU, seen again while in main
+)
+
+const (
+	tlfuWindowPercent    = 1  // of the cache size
+	tlfuProtectedPercent = 80 // of the main segment size
+	tlfuAvgEntryBytes    = 256
+	tlfuMinSketchWidth   = 1 << 10
+	tlfuMaxSketchWidth   = 1 << 22
+)
+
+type tlfuEntry struct {
+	entry
+	seg int // one of tlfuWindow, tlfuProbation, tlfuProtected
+}
+
+// tinyLFU implements CachingStrategy and provides a W-TinyLFU cache
+// (Einziger, Friedman & Manes). New entries go to a small LRU window; an
+// entry leaving the window only enters the main segmented LRU if the
+// frequency sketch says it is more popular than the entry it would evict,
+// so one-off keys from scans cannot flush out hot keys.
+type tinyLFU struct {
+	items     map[string]*list.Element // values are *tlfuEntry
+	segs      [3]*list.List            // most recent at the front
+	bytes     [3]int64
+	maxBytes  [3]int64 // per segment, probation has the main segment size
+	sketch    *cmSketch
+	currBytes int64
+	eq        expiryQueue
+	l         sync.Mutex
+}
+
+func (c *tinyLFU) Get(_ context.Context, k string) (*pb.CacheValue, bool) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.sketch.Increment(k) // misses count too, that is how keys earn admission
+	el, e := c.items[k]
+	if !e {
+		return nil, false
+	}
+	c.touch(el)
+	return el.Value.(*tlfuEntry).val, true
+}
+
+func (c *tinyLFU) Set(_ context.Context, k string, v *pb.CacheValue) (int64, error) {
+	s := entrySize(k, v)
+	if s > c.maxBytes[tlfuProbation] {
+		return 0, ErrValueTooLarge
+	}
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.set(k, v, s)
+	return s, nil
+}
+
+func (c *tinyLFU) Update(_ context.Context, k string, f func(curr *pb.CacheValue) (*pb.CacheValue, error)) (int64, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	var curr *pb.CacheValue
+	if el, e := c.items[k]; e {
+		curr = el.Value.(*tlfuEntry).val
+	}
+	v, err := f(curr)
+	if v == nil || err != nil {
+		return 0, err
+	}
+	if v == Remove {
+		if el, e := c.items[k]; e {
+			c.remove(el)
+		}
+		return 0, nil
+	}
+	s := entrySize(k, v)
+	if s > c.maxBytes[tlfuProbation] {
+		return 0, ErrValueTooLarge
+	}
+	c.set(k, v, s)
+	return s, nil
+}
+
+func (c *tinyLFU) set(k string, v *pb.CacheValue, s int64) {
+	c.sketch.Increment(k)
+	if el, e := c.items[k]; e {
+		en := el.Value.(*tlfuEntry)
+		c.bytes[en.seg] += s - en.size
+		c.currBytes += s - en.size
+		en.val, en.size = v, s
+		c.touch(el)
+	} else {
+		c.push(&tlfuEntry{entry: entry{key: k, val: v, size: s}}, tlfuWindow)
+		c.currBytes += s
+	}
+	c.eq.Schedule(k, v)
+	c.drainWindow()
+	for c.mainBytes() > c.mainMax(c.bytes[tlfuWindow]) {
+		c.remove(c.mainVictim())
+	}
+}
+
+func (c *tinyLFU) Delete(_ context.Context, k string) bool {
+	c.l.Lock()
+	defer c.l.Unlock()
+	el, e := c.items[k]
+	if !e {
+		return false
+	}
+	c.remove(el)
+	return true
+}
+
+func (c *tinyLFU) RemoveExpired(_ context.Context, now time.Time) []string {
+	keys := c.eq.PopExpired(now)
+	c.l.Lock()
+	defer c.l.Unlock()
+	var removed []string
+	for _, k := range keys {
+		if el, e := c.items[k]; e && Expired(el.Value.(*tlfuEntry).val, now) {
+			c.remove(el)
+			removed = append(removed, k)
+		}
+	}
+	return removed
+}
+
+func (c *tinyLFU) Count(_ context.Context) int64 {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return int64(len(c.items))
+}
+
+func (c *tinyLFU) CurrSize(_ context.Context) int64 {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.currBytes
+}
+
+// Range calls f for a snapshot of the cached entries, without affecting
+// their recency or frequency.
+func (c *tinyLFU) Range(ctx context.Context, f func(k string, v *pb.CacheValue) bool) {
+	c.l.Lock()
+	ents := make([]entry, 0, len(c.items))
+	for _, el := range c.items {
+		ents = append(ents, el.Value.(*tlfuEntry).entry)
+	}
+	c.l.Unlock()
+	rangeEntries(ctx, ents, f)
+}
+
+// touch records an access to a cached element, c.l must be held.
+func (c *tinyLFU) touch(el *list.Element) {
+	en := el.Value.(*tlfuEntry)
+	switch en.seg {
+	case tlfuWindow, tlfuProtected:
+		c.segs[en.seg].MoveToFront(el)
+	case tlfuProbation:
+		c.move(el, tlfuProtected)
+		for c.bytes[tlfuProtected] > c.maxBytes[tlfuProtected] {
+			c.move(c.segs[tlfuProtected].Back(), tlfuProbation)
+		}
+	}
+}
+
+// drainWindow moves entries out of the window until it fits, admitting
+// each one to the main segment only if it wins against the main segment's
+// victims. The newest entry always stays, so a write is not dropped before
+// it can be read even if it is larger than the window. c.l must be held.
+func (c *tinyLFU) drainWindow() {
+	for c.segs[tlfuWindow].Len() > 1 && c.bytes[tlfuWindow] > c.maxBytes[tlfuWindow] {
+		cand := c.segs[tlfuWindow].Back()
+		if c.admit(cand.Value.(*tlfuEntry)) {
+			c.move(cand, tlfuProbation)
+		} else {
+			c.remove(cand)
+		}
+	}
+}
+
+// admit reports whether en, leaving the window, should enter the main
+// segment, and if so evicts the victims that make room for it. en is only
+// admitted if it is more popular than every victim, a rejected entry
+// evicts nothing. c.l must be held.
+func (c *tinyLFU) admit(en *tlfuEntry) bool {
+	freq := c.sketch.Estimate(en.key)
+	need := c.mainBytes() + en.size - c.mainMax(c.bytes[tlfuWindow]-en.size)
+	var victims []*list.Element
+	for _, seg := range []int{tlfuProbation, tlfuProtected} {
+		for el := c.segs[seg].Back(); el != nil && need > 0; el = el.Prev() {
+			if freq <= c.sketch.Estimate(el.Value.(*tlfuEntry).key) {
+				return false
+			}
+			victims = append(victims, el)
+			need -= el.Value.(*tlfuEntry).size
+		}
+	}
+	for _, el := range victims {
+		c.remove(el)
+	}
+	return true
+}
+
+// mainMax returns how many bytes the main segment may hold while the
+// window holds windowBytes: the window may go over its size with its
+// newest entry, the main segment gives up the difference. c.l must be
+// held.
+func (c *tinyLFU) mainMax(windowBytes int64) int64 {
+	if over := windowBytes - c.maxBytes[tlfuWindow]; over > 0 {
+		return c.maxBytes[tlfuProbation] - over
+	}
+	return c.maxBytes[tlfuProbation]
+}
+
+// mainVictim returns the next entry to evict from the main segment,
+// c.l must be held and the main segment must not be empty.
+func (c *tinyLFU) mainVictim() *list.Element {
+	if el := c.segs[tlfuProbation].Back(); el != nil {
+		return el
+	}
+	return c.segs[tlfuProtected].Back()
+}
+
+func (c *tinyLFU) mainBytes() int64 {
+	return c.bytes[tlfuProbation] + c.bytes[tlfuProtected]
+}
+
+// move moves el to the front of segment seg, c.l must be held.
+func (c *tinyLFU) move(el *list.Element, seg int) {
+	en := el.Value.(*tlfuEntry)
+	c.segs[en.seg].Remove(el)
+	c.bytes[en.seg] -= en.size
+	c.push(en, seg)
+}
+
+// push adds en to the front of segment seg, c.l must be held.
+func (c *tinyLFU) push(en *tlfuEntry, seg int) {
+	en.seg = seg
+	c.items[en.key] = c.segs[seg].PushFront(en)
+	c.bytes[seg] += en.size
+}
+
+// remove drops el from the cache, c.l must be held.
+func (c *tinyLFU) remove(el *list.Element) {
+	en := el.Value.(*tlfuEntry)
+	c.segs[en.seg].Remove(el)
+	c.bytes[en.seg] -= en.size
+	c.currBytes -= en.size
+	delete(c.items, en.key)
+}
@@ -0,0 +1,92 @@
+package strategy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTinyLFUScanResistance(t *testing.T) {
+	c := NewTinyLFUCache(maxBytes)
+	for i := 1; i <= 5; i++ {
+		k := fmt.Sprintf("key_%02d", i)
+		c.Set(ctx, k, cacheValue(v, ts))
+		for j := 0; j < 3; j++ {
+			c.Get(ctx, k)
+		}
+	}
+	for i := 20; i < 70; i++ {
+		c.Set(ctx, fmt.Sprintf("key_%02d", i), cacheValue(v, ts))
+	}
+	for i := 1; i <= 5; i++ {
+		_, e := c.Get(ctx, fmt.Sprintf("key_%02d", i))
+		require.True(t, e, "hot key_%02d was evicted by the scan", i)
+	}
+	require.NoError(t, c.validate())
+}
+
+func TestTinyLFUAdmitsPopularKeys(t *testing.T) {
+	c := NewTinyLFUCache(maxBytes)
+	for i := 1; i <= 11; i++ {
+		c.Set(ctx, fmt.Sprintf("key_%02d", i), cacheValue(v, ts))
+	}
+	_, e := c.Get(ctx, "key_11")
+	require.True(t, e) // the newest entry stays in the window
+
+	c.Set(ctx, "key_12", cacheValue(v, ts))
+	_, e = c.Get(ctx, "key_11")
+	require.True(t, e) // read once more than the victim, admitted
+	_, e = c.Get(ctx, "key_01")
+	require.False(t, e)
+
+	c.Set(ctx, "key_13", cacheValue(v, ts))
+	_, e = c.Get(ctx, "key_02")
+	require.True(t, e) // the rejected candidate evicted nothing
+	_, e = c.Get(ctx, "key_12")
+	require.False(t, e) // as popular as the victim, not admitted
+
+	for j := 0; j < 3; j++ {
+		c.Get(ctx, "key_12") // misses raise the estimate too
+	}
+	c.Set(ctx, "key_12", cacheValue(v, ts))
+	c.Set(ctx, "key_14", cacheValue(v, ts))
+	_, e = c.Get(ctx, "key_12")
+	require.True(t, e)
+	require.NoError(t, c.validate())
+}
+
+func TestTinyLFUKeepsLatestWrite(t *testing.T) {
+	c := NewTinyLFUCache(maxBytes)
+	for i := 1; i <= 5; i++ {
+		k := fmt.Sprintf("key_%02d", i)
+		c.Set(ctx, k, cacheValue(v, ts))
+		for j := 0; j < 3; j++ {
+			c.Get(ctx, k)
+		}
+	}
+	for i := 20; i < 70; i++ {
+		k := fmt.Sprintf("key_%02d", i)
+		c.Set(ctx, k, cacheValue(v, ts))
+		_, e := c.Get(ctx, k)
+		require.True(t, e, "%v was dropped by its own write", k)
+	}
+	require.NoError(t, c.validate())
+}
+
+func TestCMSketch(t *testing.T) {
+	s := newCMSketch(16)
+	require.EqualValues(t, 0, s.Estimate("key_01"))
+	for i := 0; i < 5; i++ {
+		s.Increment("key_01")
+	}
+	require.EqualValues(t, 5, s.Estimate("key_01"))
+	for i := 0; i < 20; i++ {
+		s.Increment("key_02")
+	}
+	require.EqualValues(t, sketchMaxFreq, s.Estimate("key_02"))
+
+	s.reset()
+	require.EqualValues(t, 2, s.Estimate("key_01"))
+	require.EqualValues(t, sketchMaxFreq/2, s.Estimate("key_02"))
+}
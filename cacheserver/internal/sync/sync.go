@@ -2,47 +2,133 @@ package sync
 
 import (
 	"context"
+	"time"
 
 	"github.com/althk/ganache/cacheserver/internal/service"
 	pb "github.com/althk/ganache/cacheserver/proto"
 	"github.com/rs/zerolog/log"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"google.golang.org/protobuf/proto"
 )
 
-func syncCache(cs *service.CacheServer) error {
+const (
+	watchRetryWait = time.Second
+	// syncPageSize is how many keys syncCache reads from etcd at a time.
+	syncPageSize         = 500
+	syncProgressInterval = 5 * time.Second
+)
+
+// syncCache applies the shard's entries in etcd, a page at a time, and
+// returns the revision they were read at. The initial sync loads entries
+// only while they fit in the cache, a later one applies all of them.
+func syncCache(ctx context.Context, cs *service.CacheServer, initial bool) (int64, error) {
 	log.Info().Msg("Syncing existing stash of cache")
-	resp, err := cs.Etcd.Get(context.Background(), cs.EtcdShardPrefix(), clientv3.WithPrefix())
-	if err != nil {
-		return err
-	}
-	log.Info().Msgf("Found %d keys to sync", resp.Count)
-	for _, kv := range resp.Kvs {
-		d := &pb.CacheKeyMetadata{}
-		err := proto.Unmarshal(kv.Value, d)
+	prefix := shardPrefix(cs)
+	end := clientv3.GetPrefixRangeEnd(prefix)
+	var rev int64 // of the first page, the others are read at the same one
+	var n int
+	progress := time.Now()
+	for key := prefix; ; {
+		opts := []clientv3.OpOption{clientv3.WithRange(end), clientv3.WithLimit(syncPageSize)}
+		if rev > 0 {
+			opts = append(opts, clientv3.WithRev(rev))
+		}
+		resp, err := cs.Etcd.Get(ctx, key, opts...)
+		if err == rpctypes.ErrCompacted {
+			log.Warn().Int64("revision", rev).Msg("Sync revision compacted, starting over")
+			key, rev = prefix, 0
+			continue
+		}
 		if err != nil {
-			log.Warn().Msgf("error syncing key %v", kv.Key)
+			return 0, err
+		}
+		if rev == 0 {
+			rev = resp.Header.Revision
+		}
+		for _, kv := range resp.Kvs {
+			d := &pb.CacheKeyMetadata{}
+			err := proto.Unmarshal(kv.Value, d)
+			if err != nil {
+				log.Warn().Msgf("error syncing key %v", kv.Key)
+				continue
+			}
+			if d.DeletedAt != nil {
+				cs.SyncDelete(d.Key, d.DeletedAt)
+			} else if !initial {
+				cs.Sync(d.Key, d.Value)
+			} else if !cs.Load(d.Key, d.Value, len(kv.Value)) {
+				log.Warn().Int("keys", n).Int64("revision", rev).Msg("Cache is full, stopped syncing")
+				return rev, nil
+			}
+			n++
+		}
+		if !resp.More {
+			break
+		}
+		key = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+		if time.Since(progress) >= syncProgressInterval {
+			log.Info().Int("keys", n).Msg("Sync in progress")
+			progress = time.Now()
+		}
+	}
+	log.Info().Int("keys", n).Int64("revision", rev).Msg("Sync successfully completed")
+	return rev, nil
+}
+
+// watchCache applies the changes to the shard made after revision rev
+// until ctx is done. A broken watch is started again after the last
+// revision it delivered; if etcd has compacted that revision away, the
+// shard is synced again first. Deletes whose tombstones expired while the
+// revisions were missed are not seen, anti-entropy repairs them.
+func watchCache(ctx context.Context, cs *service.CacheServer, rev int64) {
+	for ctx.Err() == nil {
+		var err error
+		rev, err = watchFrom(ctx, cs, rev)
+		if err == rpctypes.ErrCompacted {
+			log.Warn().Int64("revision", rev).Msg("Cache watch revision compacted, syncing the shard again")
+			if rev, err = syncCache(ctx, cs, false); err == nil {
+				continue
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warn().Err(err).Int64("revision", rev).Msg("Cache watch failed, restarting")
+		select {
+		case <-ctx.Done():
+		case <-time.After(watchRetryWait):
 		}
-		cs.Cache.Set(context.Background(), d.Key, d.Value)
 	}
-	log.Info().Msg("Sync successfully completed")
-	return nil
 }
 
-func watchCache(cs *service.CacheServer) {
-	log.Info().Msgf("Setting up watch on %v", cs.EtcdShardPrefix())
-	rch := cs.Etcd.Watch(context.Background(), cs.EtcdShardPrefix(), clientv3.WithPrefix())
+// watchFrom applies the events after revision rev, in order, until the
+// watch breaks, and returns the last revision it applied.
+func watchFrom(ctx context.Context, cs *service.CacheServer, rev int64) (int64, error) {
+	prefix := shardPrefix(cs)
+	log.Info().Int64("revision", rev+1).Msgf("Setting up watch on %v", prefix)
+	wctx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+	rch := cs.Etcd.Watch(wctx, prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1))
 	for wresp := range rch {
+		if err := wresp.Err(); err != nil {
+			return rev, err
+		}
 		for _, e := range wresp.Events {
-			go func(e *clientv3.Event) {
-				processCacheEvent(cs, e)
-			}(e)
+			processCacheEvent(cs, e)
+			rev = e.Kv.ModRevision
 		}
-
 	}
+	return rev, ctx.Err()
 }
 
+// processCacheEvent applies a replica's write or tombstone. Deletes of etcd
+// keys are leases running out, of values that expire in the cache as well or
+// of tombstones, and are ignored.
 func processCacheEvent(cs *service.CacheServer, e *clientv3.Event) {
+	if e.Type == clientv3.EventTypeDelete {
+		return
+	}
 	d := &pb.CacheKeyMetadata{}
 	err := proto.Unmarshal(e.Kv.Value, d)
 	if err != nil {
@@ -52,10 +138,27 @@ func processCacheEvent(cs *service.CacheServer, e *clientv3.Event) {
 	if d.Source == cs.Addr {
 		return // ignore self updates
 	}
+	if d.DeletedAt != nil {
+		cs.SyncDelete(d.Key, d.DeletedAt)
+		return
+	}
 	cs.Sync(d.Key, d.Value)
 }
 
+// shardPrefix returns the etcd prefix of the shard's keys. It ends in "/"
+// so that shard 1 does not also match the keys of shards 10 to 19.
+func shardPrefix(cs *service.CacheServer) string {
+	return cs.EtcdShardPrefix() + "/"
+}
+
+// InitWatchAndSync syncs the shard from etcd and then watches it from the
+// revision the sync read, so that no write is missed or applied out of
+// order in between.
 func InitWatchAndSync(cs *service.CacheServer) error {
-	go watchCache(cs)
-	return syncCache(cs)
+	rev, err := syncCache(context.Background(), cs, true)
+	if err != nil {
+		return err
+	}
+	go watchCache(context.Background(), cs, rev)
+	return nil
 }
@@ -0,0 +1,137 @@
+package sync
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/althk/ganache/cacheserver/internal/config"
+	"github.com/althk/ganache/cacheserver/internal/service"
+	"github.com/althk/ganache/cacheserver/internal/strategy"
+	pb "github.com/althk/ganache/cacheserver/proto"
+	etcdutils "github.com/althk/ganache/utils/etcd"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSyncSkipsShardsWithTheSamePrefix(t *testing.T) {
+	kv := &fakeKV{data: map[string][]byte{}}
+	for shard, keys := range map[int][]string{1: {"a", "b"}, 10: {"c"}, 12: {"d"}} {
+		for _, k := range keys {
+			kv.data[etcdutils.CacheKeyPath(shard, k)] = entry(k)
+		}
+	}
+	w := &fakeWatcher{events: []*clientv3.Event{
+		{Type: clientv3.EventTypePut, Kv: &mvccpb.KeyValue{Key: []byte(etcdutils.CacheKeyPath(10, "e")), Value: entry("e"), ModRevision: 2}},
+		{Type: clientv3.EventTypePut, Kv: &mvccpb.KeyValue{Key: []byte(etcdutils.CacheKeyPath(1, "f")), Value: entry("f"), ModRevision: 3}},
+	}}
+	cs, _ := service.NewCacheServer(&config.CSConfig{Shard: 1, Addr: "cs1"}, strategy.NewLRUCache(1000), &clientv3.Client{KV: kv, Watcher: w})
+
+	rev, err := syncCache(context.Background(), cs, true)
+	require.NoError(t, err)
+	rev, err = watchFrom(context.Background(), cs, rev)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), rev)
+	for _, k := range []string{"a", "b", "f"} {
+		_, ok := cs.Cache.Get(context.Background(), k)
+		require.True(t, ok, k)
+	}
+	for _, k := range []string{"c", "d", "e"} {
+		_, ok := cs.Cache.Get(context.Background(), k)
+		require.False(t, ok, k)
+	}
+}
+
+func TestSyncAppliesTombstones(t *testing.T) {
+	path := func(k string) []byte { return []byte(etcdutils.CacheKeyPath(1, k)) }
+	now := time.Now().UnixNano()
+	kv := &fakeKV{data: map[string][]byte{
+		string(path("a")): value("a", now+10),
+		string(path("b")): tombstone("b", now+20),
+	}}
+	w := &fakeWatcher{events: []*clientv3.Event{
+		{Type: clientv3.EventTypePut, Kv: &mvccpb.KeyValue{Key: path("b"), Value: value("b", now+15), ModRevision: 2}},
+		{Type: clientv3.EventTypePut, Kv: &mvccpb.KeyValue{Key: path("c"), Value: tombstone("c", now+50), ModRevision: 3}},
+		{Type: clientv3.EventTypePut, Kv: &mvccpb.KeyValue{Key: path("d"), Value: tombstone("d", now+50), ModRevision: 4}},
+		{Type: clientv3.EventTypeDelete, Kv: &mvccpb.KeyValue{Key: path("a"), ModRevision: 5}},
+	}}
+	cs, _ := service.NewCacheServer(&config.CSConfig{Shard: 1, Addr: "cs1"}, strategy.NewLRUCache(1000), &clientv3.Client{KV: kv, Watcher: w})
+	cs.Sync("c", &pb.CacheValue{Hlc: &pb.HLC{Physical: now + 100, Node: "cs1"}})
+	cs.Sync("d", &pb.CacheValue{Hlc: &pb.HLC{Physical: now + 5, Node: "cs1"}})
+
+	rev, err := syncCache(context.Background(), cs, true)
+	require.NoError(t, err)
+	_, err = watchFrom(context.Background(), cs, rev)
+	require.NoError(t, err)
+	// a value older than the tombstone and a delete older than the local
+	// write are not applied, a lease running out is ignored
+	for k, want := range map[string]bool{"a": true, "b": false, "c": true, "d": false} {
+		_, ok := cs.Cache.Get(context.Background(), k)
+		require.Equal(t, want, ok, k)
+	}
+}
+
+func value(k string, physical int64) []byte {
+	b, _ := proto.Marshal(&pb.CacheKeyMetadata{Source: "cs2", Key: k, Value: &pb.CacheValue{Hlc: &pb.HLC{Physical: physical, Node: "cs2"}}})
+	return b
+}
+
+func tombstone(k string, physical int64) []byte {
+	b, _ := proto.Marshal(&pb.CacheKeyMetadata{Source: "cs2", Key: k, DeletedAt: &pb.HLC{Physical: physical, Node: "cs2"}})
+	return b
+}
+
+func entry(k string) []byte {
+	b, _ := proto.Marshal(&pb.CacheKeyMetadata{Source: "cs2", Key: k, Value: &pb.CacheValue{}})
+	return b
+}
+
+// fakeKV serves Get from a map, in a single page, the other calls are not
+// implemented.
+type fakeKV struct {
+	clientv3.KV
+	data map[string][]byte
+}
+
+func (kv *fakeKV) Get(_ context.Context, k string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	op := clientv3.OpGet(k, opts...)
+	end := string(op.RangeBytes())
+	var keys []string
+	for key := range kv.data {
+		if key == k || (end != "" && key >= k && key < end) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	r := &clientv3.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: 1}}
+	for _, key := range keys {
+		r.Kvs = append(r.Kvs, &mvccpb.KeyValue{Key: []byte(key), Value: kv.data[key], ModRevision: 1})
+	}
+	return r, nil
+}
+
+// fakeWatcher delivers the events in the watched range and then closes the
+// watch.
+type fakeWatcher struct {
+	clientv3.Watcher
+	events []*clientv3.Event
+}
+
+func (w *fakeWatcher) Watch(_ context.Context, k string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	op := clientv3.OpGet(k, opts...)
+	end := string(op.RangeBytes())
+	resp := clientv3.WatchResponse{}
+	for _, e := range w.events {
+		if key := string(e.Kv.Key); key >= k && key < end {
+			resp.Events = append(resp.Events, e)
+		}
+	}
+	ch := make(chan clientv3.WatchResponse, 1)
+	ch <- resp
+	close(ch)
+	return ch
+}
@@ -25,6 +25,43 @@ const _ = grpc.SupportPackageIsVersion7
 type CacheClient interface {
 	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
 	Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// CompareAndSet sets the key only if its current version is the expected
+	// one, it fails with Aborted otherwise.
+	CompareAndSet(ctx context.Context, in *CompareAndSetRequest, opts ...grpc.CallOption) (*CompareAndSetResponse, error)
+	// Increment atomically adds delta to an Int64Value entry, creating it if
+	// it does not exist. Other entries fail with FailedPrecondition and a
+	// result that does not fit in an int64 with OutOfRange.
+	Increment(ctx context.Context, in *IncrementRequest, opts ...grpc.CallOption) (*IncrementResponse, error)
+	Stats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StatsResponse, error)
+	// Transfer streams the cached entries that the given shard owns under the
+	// given shard map, and the global entries, which every shard has. It is
+	// used by CSM to move keys when resharding.
+	Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (Cache_TransferClient, error)
+	// MGet, MSet and MDelete apply the same operation to a batch of keys, the
+	// response has a result per key in request order.
+	MGet(ctx context.Context, in *MGetRequest, opts ...grpc.CallOption) (*MGetResponse, error)
+	MSet(ctx context.Context, in *MSetRequest, opts ...grpc.CallOption) (*MSetResponse, error)
+	MDelete(ctx context.Context, in *MDeleteRequest, opts ...grpc.CallOption) (*MDeleteResponse, error)
+	// Replicate applies the writes another replica of the shard streams to
+	// it, used instead of etcd with -replication_mode=peer.
+	Replicate(ctx context.Context, opts ...grpc.CallOption) (Cache_ReplicateClient, error)
+	// MerkleDigest returns hashes of nodes of the replica's Merkle tree over
+	// its keys, anti-entropy compares them to find the key ranges replicas
+	// disagree on.
+	MerkleDigest(ctx context.Context, in *MerkleDigestRequest, opts ...grpc.CallOption) (*MerkleDigestResponse, error)
+	// MerkleEntries streams the entries and recent deletes in the given
+	// leaves of the replica's Merkle tree.
+	MerkleEntries(ctx context.Context, in *MerkleEntriesRequest, opts ...grpc.CallOption) (Cache_MerkleEntriesClient, error)
+	// Import applies entries moved to the shard from other shards while
+	// resharding, like writes of another replica, and replicates those that
+	// changed the cache to the shard's other replicas.
+	Import(ctx context.Context, in *ImportRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// WatchKeys streams the keys that change on the replica, whether written
+	// to it, replicated to it or expired, for CFE to invalidate its near
+	// cache and tracking clients. The first event is empty and sent once the
+	// stream is watching.
+	WatchKeys(ctx context.Context, in *WatchKeysRequest, opts ...grpc.CallOption) (Cache_WatchKeysClient, error)
 }
 
 type cacheClient struct {
@@ -53,12 +90,260 @@ func (c *cacheClient) Set(ctx context.Context, in *SetRequest, opts ...grpc.Call
 	return out, nil
 }
 
+func (c *cacheClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/ganache.cs.Cache/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheClient) CompareAndSet(ctx context.Context, in *CompareAndSetRequest, opts ...grpc.CallOption) (*CompareAndSetResponse, error) {
+	out := new(CompareAndSetResponse)
+	err := c.cc.Invoke(ctx, "/ganache.cs.Cache/CompareAndSet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheClient) Increment(ctx context.Context, in *IncrementRequest, opts ...grpc.CallOption) (*IncrementResponse, error) {
+	out := new(IncrementResponse)
+	err := c.cc.Invoke(ctx, "/ganache.cs.Cache/Increment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheClient) Stats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StatsResponse, error) {
+	out := new(StatsResponse)
+	err := c.cc.Invoke(ctx, "/ganache.cs.Cache/Stats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheClient) Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (Cache_TransferClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Cache_ServiceDesc.Streams[0], "/ganache.cs.Cache/Transfer", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cacheTransferClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Cache_TransferClient interface {
+	Recv() (*CacheKeyMetadata, error)
+	grpc.ClientStream
+}
+
+type cacheTransferClient struct {
+	grpc.ClientStream
+}
+
+func (x *cacheTransferClient) Recv() (*CacheKeyMetadata, error) {
+	m := new(CacheKeyMetadata)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cacheClient) MGet(ctx context.Context, in *MGetRequest, opts ...grpc.CallOption) (*MGetResponse, error) {
+	out := new(MGetResponse)
+	err := c.cc.Invoke(ctx, "/ganache.cs.Cache/MGet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheClient) MSet(ctx context.Context, in *MSetRequest, opts ...grpc.CallOption) (*MSetResponse, error) {
+	out := new(MSetResponse)
+	err := c.cc.Invoke(ctx, "/ganache.cs.Cache/MSet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheClient) MDelete(ctx context.Context, in *MDeleteRequest, opts ...grpc.CallOption) (*MDeleteResponse, error) {
+	out := new(MDeleteResponse)
+	err := c.cc.Invoke(ctx, "/ganache.cs.Cache/MDelete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheClient) Replicate(ctx context.Context, opts ...grpc.CallOption) (Cache_ReplicateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Cache_ServiceDesc.Streams[1], "/ganache.cs.Cache/Replicate", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cacheReplicateClient{stream}
+	return x, nil
+}
+
+type Cache_ReplicateClient interface {
+	Send(*ReplicationBatch) error
+	CloseAndRecv() (*emptypb.Empty, error)
+	grpc.ClientStream
+}
+
+type cacheReplicateClient struct {
+	grpc.ClientStream
+}
+
+func (x *cacheReplicateClient) Send(m *ReplicationBatch) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *cacheReplicateClient) CloseAndRecv() (*emptypb.Empty, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(emptypb.Empty)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cacheClient) MerkleDigest(ctx context.Context, in *MerkleDigestRequest, opts ...grpc.CallOption) (*MerkleDigestResponse, error) {
+	out := new(MerkleDigestResponse)
+	err := c.cc.Invoke(ctx, "/ganache.cs.Cache/MerkleDigest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheClient) MerkleEntries(ctx context.Context, in *MerkleEntriesRequest, opts ...grpc.CallOption) (Cache_MerkleEntriesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Cache_ServiceDesc.Streams[2], "/ganache.cs.Cache/MerkleEntries", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cacheMerkleEntriesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Cache_MerkleEntriesClient interface {
+	Recv() (*ReplicationEntry, error)
+	grpc.ClientStream
+}
+
+type cacheMerkleEntriesClient struct {
+	grpc.ClientStream
+}
+
+func (x *cacheMerkleEntriesClient) Recv() (*ReplicationEntry, error) {
+	m := new(ReplicationEntry)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cacheClient) Import(ctx context.Context, in *ImportRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/ganache.cs.Cache/Import", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheClient) WatchKeys(ctx context.Context, in *WatchKeysRequest, opts ...grpc.CallOption) (Cache_WatchKeysClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Cache_ServiceDesc.Streams[3], "/ganache.cs.Cache/WatchKeys", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cacheWatchKeysClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Cache_WatchKeysClient interface {
+	Recv() (*KeyChanges, error)
+	grpc.ClientStream
+}
+
+type cacheWatchKeysClient struct {
+	grpc.ClientStream
+}
+
+func (x *cacheWatchKeysClient) Recv() (*KeyChanges, error) {
+	m := new(KeyChanges)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // CacheServer is the server API for Cache service.
 // All implementations must embed UnimplementedCacheServer
 // for forward compatibility
 type CacheServer interface {
 	Get(context.Context, *GetRequest) (*GetResponse, error)
 	Set(context.Context, *SetRequest) (*emptypb.Empty, error)
+	Delete(context.Context, *DeleteRequest) (*emptypb.Empty, error)
+	// CompareAndSet sets the key only if its current version is the expected
+	// one, it fails with Aborted otherwise.
+	CompareAndSet(context.Context, *CompareAndSetRequest) (*CompareAndSetResponse, error)
+	// Increment atomically adds delta to an Int64Value entry, creating it if
+	// it does not exist. Other entries fail with FailedPrecondition and a
+	// result that does not fit in an int64 with OutOfRange.
+	Increment(context.Context, *IncrementRequest) (*IncrementResponse, error)
+	Stats(context.Context, *emptypb.Empty) (*StatsResponse, error)
+	// Transfer streams the cached entries that the given shard owns under the
+	// given shard map, and the global entries, which every shard has. It is
+	// used by CSM to move keys when resharding.
+	Transfer(*TransferRequest, Cache_TransferServer) error
+	// MGet, MSet and MDelete apply the same operation to a batch of keys, the
+	// response has a result per key in request order.
+	MGet(context.Context, *MGetRequest) (*MGetResponse, error)
+	MSet(context.Context, *MSetRequest) (*MSetResponse, error)
+	MDelete(context.Context, *MDeleteRequest) (*MDeleteResponse, error)
+	// Replicate applies the writes another replica of the shard streams to
+	// it, used instead of etcd with -replication_mode=peer.
+	Replicate(Cache_ReplicateServer) error
+	// MerkleDigest returns hashes of nodes of the replica's Merkle tree over
+	// its keys, anti-entropy compares them to find the key ranges replicas
+	// disagree on.
+	MerkleDigest(context.Context, *MerkleDigestRequest) (*MerkleDigestResponse, error)
+	// MerkleEntries streams the entries and recent deletes in the given
+	// leaves of the replica's Merkle tree.
+	MerkleEntries(*MerkleEntriesRequest, Cache_MerkleEntriesServer) error
+	// Import applies entries moved to the shard from other shards while
+	// resharding, like writes of another replica, and replicates those that
+	// changed the cache to the shard's other replicas.
+	Import(context.Context, *ImportRequest) (*emptypb.Empty, error)
+	// WatchKeys streams the keys that change on the replica, whether written
+	// to it, replicated to it or expired, for CFE to invalidate its near
+	// cache and tracking clients. The first event is empty and sent once the
+	// stream is watching.
+	WatchKeys(*WatchKeysRequest, Cache_WatchKeysServer) error
 	mustEmbedUnimplementedCacheServer()
 }
 
@@ -72,6 +357,45 @@ func (UnimplementedCacheServer) Get(context.Context, *GetRequest) (*GetResponse,
 func (UnimplementedCacheServer) Set(context.Context, *SetRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Set not implemented")
 }
+func (UnimplementedCacheServer) Delete(context.Context, *DeleteRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedCacheServer) CompareAndSet(context.Context, *CompareAndSetRequest) (*CompareAndSetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompareAndSet not implemented")
+}
+func (UnimplementedCacheServer) Increment(context.Context, *IncrementRequest) (*IncrementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Increment not implemented")
+}
+func (UnimplementedCacheServer) Stats(context.Context, *emptypb.Empty) (*StatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stats not implemented")
+}
+func (UnimplementedCacheServer) Transfer(*TransferRequest, Cache_TransferServer) error {
+	return status.Errorf(codes.Unimplemented, "method Transfer not implemented")
+}
+func (UnimplementedCacheServer) MGet(context.Context, *MGetRequest) (*MGetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MGet not implemented")
+}
+func (UnimplementedCacheServer) MSet(context.Context, *MSetRequest) (*MSetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MSet not implemented")
+}
+func (UnimplementedCacheServer) MDelete(context.Context, *MDeleteRequest) (*MDeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MDelete not implemented")
+}
+func (UnimplementedCacheServer) Replicate(Cache_ReplicateServer) error {
+	return status.Errorf(codes.Unimplemented, "method Replicate not implemented")
+}
+func (UnimplementedCacheServer) MerkleDigest(context.Context, *MerkleDigestRequest) (*MerkleDigestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MerkleDigest not implemented")
+}
+func (UnimplementedCacheServer) MerkleEntries(*MerkleEntriesRequest, Cache_MerkleEntriesServer) error {
+	return status.Errorf(codes.Unimplemented, "method MerkleEntries not implemented")
+}
+func (UnimplementedCacheServer) Import(context.Context, *ImportRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Import not implemented")
+}
+func (UnimplementedCacheServer) WatchKeys(*WatchKeysRequest, Cache_WatchKeysServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchKeys not implemented")
+}
 func (UnimplementedCacheServer) mustEmbedUnimplementedCacheServer() {}
 
 // UnsafeCacheServer may be embedded to opt out of forward compatibility for this service.
@@ -121,6 +445,257 @@ func _Cache_Set_Handler(srv interface{}, ctx context.Context, dec func(interface
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Cache_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cs.Cache/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cache_CompareAndSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompareAndSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServer).CompareAndSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cs.Cache/CompareAndSet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServer).CompareAndSet(ctx, req.(*CompareAndSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cache_Increment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IncrementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServer).Increment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cs.Cache/Increment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServer).Increment(ctx, req.(*IncrementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cache_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cs.Cache/Stats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServer).Stats(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cache_Transfer_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TransferRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CacheServer).Transfer(m, &cacheTransferServer{stream})
+}
+
+type Cache_TransferServer interface {
+	Send(*CacheKeyMetadata) error
+	grpc.ServerStream
+}
+
+type cacheTransferServer struct {
+	grpc.ServerStream
+}
+
+func (x *cacheTransferServer) Send(m *CacheKeyMetadata) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Cache_MGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServer).MGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cs.Cache/MGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServer).MGet(ctx, req.(*MGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cache_MSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServer).MSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cs.Cache/MSet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServer).MSet(ctx, req.(*MSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cache_MDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServer).MDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cs.Cache/MDelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServer).MDelete(ctx, req.(*MDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cache_Replicate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CacheServer).Replicate(&cacheReplicateServer{stream})
+}
+
+type Cache_ReplicateServer interface {
+	SendAndClose(*emptypb.Empty) error
+	Recv() (*ReplicationBatch, error)
+	grpc.ServerStream
+}
+
+type cacheReplicateServer struct {
+	grpc.ServerStream
+}
+
+func (x *cacheReplicateServer) SendAndClose(m *emptypb.Empty) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *cacheReplicateServer) Recv() (*ReplicationBatch, error) {
+	m := new(ReplicationBatch)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Cache_MerkleDigest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MerkleDigestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServer).MerkleDigest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cs.Cache/MerkleDigest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServer).MerkleDigest(ctx, req.(*MerkleDigestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cache_MerkleEntries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(MerkleEntriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CacheServer).MerkleEntries(m, &cacheMerkleEntriesServer{stream})
+}
+
+type Cache_MerkleEntriesServer interface {
+	Send(*ReplicationEntry) error
+	grpc.ServerStream
+}
+
+type cacheMerkleEntriesServer struct {
+	grpc.ServerStream
+}
+
+func (x *cacheMerkleEntriesServer) Send(m *ReplicationEntry) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Cache_Import_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServer).Import(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cs.Cache/Import",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServer).Import(ctx, req.(*ImportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cache_WatchKeys_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchKeysRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CacheServer).WatchKeys(m, &cacheWatchKeysServer{stream})
+}
+
+type Cache_WatchKeysServer interface {
+	Send(*KeyChanges) error
+	grpc.ServerStream
+}
+
+type cacheWatchKeysServer struct {
+	grpc.ServerStream
+}
+
+func (x *cacheWatchKeysServer) Send(m *KeyChanges) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Cache_ServiceDesc is the grpc.ServiceDesc for Cache service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -136,7 +711,64 @@ var Cache_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Set",
 			Handler:    _Cache_Set_Handler,
 		},
+		{
+			MethodName: "Delete",
+			Handler:    _Cache_Delete_Handler,
+		},
+		{
+			MethodName: "CompareAndSet",
+			Handler:    _Cache_CompareAndSet_Handler,
+		},
+		{
+			MethodName: "Increment",
+			Handler:    _Cache_Increment_Handler,
+		},
+		{
+			MethodName: "Stats",
+			Handler:    _Cache_Stats_Handler,
+		},
+		{
+			MethodName: "MGet",
+			Handler:    _Cache_MGet_Handler,
+		},
+		{
+			MethodName: "MSet",
+			Handler:    _Cache_MSet_Handler,
+		},
+		{
+			MethodName: "MDelete",
+			Handler:    _Cache_MDelete_Handler,
+		},
+		{
+			MethodName: "MerkleDigest",
+			Handler:    _Cache_MerkleDigest_Handler,
+		},
+		{
+			MethodName: "Import",
+			Handler:    _Cache_Import_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Transfer",
+			Handler:       _Cache_Transfer_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Replicate",
+			Handler:       _Cache_Replicate_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "MerkleEntries",
+			Handler:       _Cache_MerkleEntries_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchKeys",
+			Handler:       _Cache_WatchKeys_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "cacherserver.proto",
 }
@@ -5,21 +5,31 @@ import (
 	"flag"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/althk/ganache/cfe/internal/server"
+	"github.com/althk/ganache/cfe/internal/service"
 	pb "github.com/althk/ganache/cfe/proto"
+	etcdutils "github.com/althk/ganache/utils/etcd"
+	"github.com/althk/ganache/utils/lifecycle"
 	"github.com/althk/goeasy/grpcutils"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 var (
 	port             = flag.Int("port", 0, "cache server port, defaults to 0 which means any available port")
 	etcdSpec         = flag.String("etcd_server", "", "address of etcd server in the form host:port")
 	csResolverPrefix = flag.String("cacheserver_resolver_prefix", "ganache/cacheserver", "key prefix for cache service resolver")
+	shardMapKey      = flag.String("shard_map_key", etcdutils.ShardMapKey, "etcd key of the shard map published by CSM")
+	nearCache        = flag.String("near_cache", "", "comma separated namespace:max_entries:max_staleness of the namespaces to cache in process, e.g. users:1000:500ms")
+	clientTracking   = flag.Bool("client_tracking", false, "let clients watch for changes of the keys they read, for their local caches")
 	debug            = flag.Bool("debug", false, "enable debug logging")
-	shards           = flag.Int("shards", 1, "number of shards to use for distribution")
+	shutdownTimeout  = flag.Duration("shutdown_timeout", 30*time.Second, "how long shutting down may take, RPCs still running after that are cancelled")
+	drainDelay       = flag.Duration("drain_delay", 2*time.Second, "how long to keep serving after turning unhealthy, so that clients stop sending requests first")
 	clientCAPath     = flag.String("client_ca_file", "", "Path to CA cert file that can verify client certs")
 	rootCAPath       = flag.String("root_ca_file", "", "Path to CA cert file that can verify server/peer certs")
 	tlsCrtPath       = flag.String("tls_cert_file", "", "Path to server's TLS cert file")
@@ -57,10 +67,15 @@ func main() {
 			SkipTLS:          *skipTLS,
 			RootCAFilePath:   *rootCAPath,
 		},
-		KeepAliveConfig: &grpcutils.KeepAliveConfig{}, // use defaults
+		KeepAliveConfig:  &grpcutils.KeepAliveConfig{}, // use defaults
+		SkipHealthServer: true,                         // registered below, drained on shutdown
 	}
 
-	cfeServer, err := server.New(grpcCfg, *etcdSpec, *csResolverPrefix, *shards)
+	nearCacheCfg, err := service.ParseNearCacheConfig(*nearCache)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid -near_cache flag.")
+	}
+	cfeServer, err := server.New(grpcCfg, *etcdSpec, *csResolverPrefix, *shardMapKey, nearCacheCfg, *clientTracking)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to create CFE server.")
 	}
@@ -72,6 +87,32 @@ func main() {
 	// register CFE server
 	pb.RegisterCFEServer(s, cfeServer)
 
-	log.Info().Msgf("Starting CFE on address %v", lis.Addr().String())
-	s.Serve(lis)
+	// not ready until the shard map has been loaded
+	hs := health.NewServer()
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	hs.SetServingStatus(pb.CFE_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(s, hs)
+	go func() {
+		<-cfeServer.Ready()
+		hs.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+		hs.SetServingStatus(pb.CFE_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+		log.Info().Msg("CFE is ready")
+	}()
+
+	go func() {
+		log.Info().Msgf("Starting CFE on address %v", lis.Addr().String())
+		if err := s.Serve(lis); err != nil {
+			log.Fatal().Err(err).Msg("CFE failed.")
+		}
+	}()
+
+	sig := lifecycle.WaitForSignal()
+	log.Info().Msgf("Received %v, shutting down", sig)
+	d := &lifecycle.Drainer{
+		Server:     s,
+		Health:     hs,
+		DrainDelay: *drainDelay,
+		Drain:      []lifecycle.Step{cfeServer.StopClientTracking},
+	}
+	d.Shutdown(*shutdownTimeout)
 }
@@ -4,6 +4,7 @@ go 1.18
 
 require (
 	github.com/althk/ganache/cacheserver v0.0.0-20220706175043-8ffe22299080
+	github.com/althk/ganache/csm v0.0.0-20220706175043-8ffe22299080
 	github.com/althk/ganache/utils v0.0.0-20220706175043-8ffe22299080
 	github.com/althk/goeasy/grpcutils v0.0.0-20220712184942-d7de7754eb7f
 	github.com/rs/zerolog v1.27.0
@@ -57,3 +58,5 @@ replace github.com/althk/ganache/cacheserver => ../cacheserver
 replace github.com/althk/ganache/utils => ../utils
 
 replace github.com/althk/ganache/client => ../client
+
+replace github.com/althk/ganache/csm => ../csm
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	cspb "github.com/althk/ganache/cacheserver/proto"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// invalidator is told about the keys that changed, i.e. the CFE.
+type invalidator interface {
+	Invalidate(k string)
+	InvalidateAll()
+}
+
+// invalidationWatcher reports the changes of one shard to a CFE's near
+// cache and tracking clients, as a cache server of the shard streams them
+// with WatchKeys. Every change reaches each replica of the shard, whether
+// they replicate through etcd or with peers, so one stream per shard is
+// enough. Expired keys are reported when the cache server removes them.
+type invalidationWatcher struct {
+	cfe   invalidator
+	shard int
+	conn  *grpc.ClientConn
+}
+
+// Run watches the shard until ctx is done or conn is closed. Changes made
+// while the stream is broken are not seen, so everything is invalidated
+// each time it is restarted.
+func (w *invalidationWatcher) Run(ctx context.Context) {
+	c := cspb.NewCacheClient(w.conn)
+	for !w.stopped(ctx) {
+		err := w.watch(ctx, c)
+		w.cfe.InvalidateAll()
+		if w.stopped(ctx) {
+			return
+		}
+		log.Warn().Err(err).Int("shard", w.shard).Msg("Near cache invalidation stream failed")
+		select {
+		case <-ctx.Done():
+		case <-time.After(watchRetryWait):
+		}
+	}
+}
+
+func (w *invalidationWatcher) stopped(ctx context.Context) bool {
+	return ctx.Err() != nil || w.conn.GetState() == connectivity.Shutdown
+}
+
+func (w *invalidationWatcher) watch(ctx context.Context, c cspb.CacheClient) error {
+	stream, err := c.WatchKeys(ctx, &cspb.WatchKeysRequest{})
+	if err != nil {
+		return err
+	}
+	// the first event says the stream is watching, what changed before
+	// that may have been missed
+	if _, err := stream.Recv(); err != nil {
+		return err
+	}
+	w.cfe.InvalidateAll()
+	for {
+		ch, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if ch.Flush {
+			w.cfe.InvalidateAll()
+		}
+		for _, k := range ch.Keys {
+			w.cfe.Invalidate(k)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	cspb "github.com/althk/ganache/cacheserver/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestInvalidationWatcher(t *testing.T) {
+	cs := &fakeCacheServer{streams: make(chan cspb.Cache_WatchKeysServer)}
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	cspb.RegisterCacheServer(srv, cs)
+	go srv.Serve(lis)
+	defer srv.Stop()
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	inv := &fakeInvalidator{}
+	w := &invalidationWatcher{cfe: inv, shard: 1, conn: conn}
+	done := make(chan struct{})
+	go func() {
+		w.Run(context.Background())
+		close(done)
+	}()
+
+	stream := <-cs.streams
+	require.NoError(t, stream.Send(&cspb.KeyChanges{}))
+	require.NoError(t, stream.Send(&cspb.KeyChanges{Keys: []string{"ns1key1", "ns1key2"}}))
+	require.NoError(t, stream.Send(&cspb.KeyChanges{Flush: true}))
+	require.Eventually(t, func() bool {
+		return inv.equal("all", "ns1key1", "ns1key2", "all")
+	}, time.Second, 10*time.Millisecond)
+
+	// a broken stream is watched again, after dropping everything
+	cs.fail <- status.Error(codes.Unavailable, "shutting down")
+	stream = <-cs.streams
+	require.NoError(t, stream.Send(&cspb.KeyChanges{}))
+	require.NoError(t, stream.Send(&cspb.KeyChanges{Keys: []string{"ns1key3"}}))
+	require.Eventually(t, func() bool {
+		return inv.equal("all", "ns1key1", "ns1key2", "all", "all", "all", "ns1key3")
+	}, 3*time.Second, 10*time.Millisecond)
+
+	conn.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watcher still running after its connection was closed")
+	}
+}
+
+// fakeCacheServer hands its WatchKeys streams to the test, which sends the
+// events, until an error is sent to fail.
+type fakeCacheServer struct {
+	cspb.UnimplementedCacheServer
+	streams chan cspb.Cache_WatchKeysServer
+	fail    chan error
+}
+
+func (f *fakeCacheServer) WatchKeys(_ *cspb.WatchKeysRequest, stream cspb.Cache_WatchKeysServer) error {
+	fail := make(chan error)
+	f.fail = fail
+	f.streams <- stream
+	select {
+	case err := <-fail:
+		return err
+	case <-stream.Context().Done():
+		return stream.Context().Err()
+	}
+}
+
+// fakeInvalidator records the invalidated keys, and "all" for each
+// InvalidateAll.
+type fakeInvalidator struct {
+	l    sync.Mutex
+	keys []string
+}
+
+func (f *fakeInvalidator) Invalidate(k string) {
+	f.l.Lock()
+	defer f.l.Unlock()
+	f.keys = append(f.keys, k)
+}
+
+func (f *fakeInvalidator) InvalidateAll() {
+	f.Invalidate("all")
+}
+
+func (f *fakeInvalidator) equal(keys ...string) bool {
+	f.l.Lock()
+	defer f.l.Unlock()
+	if len(f.keys) != len(keys) {
+		return false
+	}
+	for i, k := range keys {
+		if f.keys[i] != k {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -11,48 +12,72 @@ import (
 	"github.com/rs/zerolog/log"
 	resolverv3 "go.etcd.io/etcd/client/v3/naming/resolver"
 	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/health" // enables client side health checking
 	"google.golang.org/grpc/resolver"
 )
 
-func New(grpcCfg *grpcutils.GRPCServerConfig, etcdSpec, csResolverPrefix string, shardCount int) (*service.CFE, error) {
-	r, err := etcdResolver(etcdSpec)
+// csServiceConfig spreads requests over the replicas of a shard and skips
+// replicas whose health service does not report the cache as SERVING, e.g.
+// while they sync or drain.
+var csServiceConfig = fmt.Sprintf(`{
+	"loadBalancingPolicy": "round_robin",
+	"healthCheckConfig": {"serviceName": %q}
+}`, cspb.Cache_ServiceDesc.ServiceName)
+
+// New returns a CFE that follows the shard map stored under shardMapKey,
+// connecting to the cache servers of new shards as they are added. The
+// namespaces in nearCache are cached in process and, with clientTracking,
+// clients may watch the keys they read. Both are invalidated by the keys
+// each shard's cache servers report changed.
+func New(grpcCfg *grpcutils.GRPCServerConfig, etcdSpec, csResolverPrefix, shardMapKey string, nearCache map[string]service.NearCacheConfig, clientTracking bool) (*service.CFE, error) {
+	log.Info().Msgf("Connecting to etcd server: %v", etcdSpec)
+	etcdc, err := etcdutils.V3Client(etcdSpec)
 	if err != nil {
 		return nil, err
 	}
-	c := make(map[int]cspb.CacheClient)
-	for i := 0; i < shardCount; i++ {
-		c[i], err = getCacheCli(grpcCfg, r, csResolverPrefix, i)
-		if err != nil {
-			return nil, err
-		}
+	r, err := resolverv3.NewBuilder(etcdc)
+	if err != nil {
+		return nil, err
 	}
-	return service.NewCFE(shardCount, c)
-
+	cfe := service.NewCFE(nil)
+	cfe.SetNearCache(nearCache)
+	if clientTracking {
+		cfe.EnableClientTracking()
+	}
+	w := &shardMapWatcher{
+		cfe:   cfe,
+		etcd:  etcdc,
+		key:   shardMapKey,
+		conns: make(map[int]*grpc.ClientConn),
+		dial: func(shard int) (*grpc.ClientConn, error) {
+			conn, err := dialCacheServer(grpcCfg, r, csResolverPrefix, shard)
+			if err == nil && cfe.NeedsInvalidations() {
+				// stops once the connection is closed
+				iw := &invalidationWatcher{cfe: cfe, shard: shard, conn: conn}
+				go iw.Run(context.Background())
+			}
+			return conn, err
+		},
+	}
+	go w.Run(context.Background())
+	return cfe, nil
 }
 
-func getCacheCli(grpcCfg *grpcutils.GRPCServerConfig, r resolver.Builder, cacheResolverPrefix string, shardNum int) (cspb.CacheClient, error) {
+func dialCacheServer(grpcCfg *grpcutils.GRPCServerConfig, r resolver.Builder, cacheResolverPrefix string, shardNum int) (*grpc.ClientConn, error) {
 	ep := strings.Join([]string{"etcd://", cacheResolverPrefix, fmt.Sprint(shardNum)}, "/")
 	log.Info().Msgf("Build cacheserver client for %v", ep)
 	opts, err := grpcCfg.GetGRPCDialOpts()
-	opts = append(opts, grpc.WithResolvers(r))
+	opts = append(opts, grpc.WithResolvers(r), grpc.WithDefaultServiceConfig(csServiceConfig))
 	if err != nil {
 		return nil, err
 	}
-	conn, err := grpc.Dial(ep, opts...)
-	if err != nil {
-		return nil, err
-	}
-	c := cspb.NewCacheClient(conn)
-	return c, nil
+	return grpc.Dial(ep, opts...)
 }
 
-func etcdResolver(etcdSpec string) (resolver.Builder, error) {
-	log.Info().Msgf("Connecting to etcd server: %v", etcdSpec)
-	cli, _ := etcdutils.V3Client(etcdSpec)
-	r, err := resolverv3.NewBuilder(cli)
-	if err != nil {
-		return nil, err
+func cacheClients(conns map[int]*grpc.ClientConn) map[int]cspb.CacheClient {
+	c := make(map[int]cspb.CacheClient, len(conns))
+	for n, conn := range conns {
+		c[n] = cspb.NewCacheClient(conn)
 	}
-	return r, nil
-
+	return c
 }
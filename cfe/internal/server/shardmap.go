@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/althk/ganache/cfe/internal/service"
+	csmpb "github.com/althk/ganache/csm/proto"
+	"github.com/rs/zerolog/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// connections to removed shards stay open this long so that requests
+	// routed with the previous topology can finish.
+	connCloseDelay = 30 * time.Second
+	watchRetryWait = time.Second
+)
+
+// shardMapWatcher keeps the topology of a CFE in sync with the shard map in
+// etcd.
+type shardMapWatcher struct {
+	cfe   *service.CFE
+	etcd  *clientv3.Client
+	key   string
+	dial  func(shard int) (*grpc.ClientConn, error)
+	conns map[int]*grpc.ClientConn // only touched by Run
+}
+
+// Run loads the shard map and then watches it for changes until ctx is done.
+// Whenever the watch breaks the map is read again, so no update is missed.
+func (w *shardMapWatcher) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		rev, err := w.load(ctx)
+		if err != nil {
+			log.Error().Err(err).Str("key", w.key).Msg("Failed to load shard map")
+		} else {
+			w.watch(ctx, rev+1)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(watchRetryWait):
+		}
+	}
+}
+
+// load applies the current shard map, if any, and returns the etcd revision
+// it was read at.
+func (w *shardMapWatcher) load(ctx context.Context) (int64, error) {
+	r, err := w.etcd.Get(ctx, w.key)
+	if err != nil {
+		return 0, err
+	}
+	if len(r.Kvs) == 0 {
+		log.Warn().Str("key", w.key).Msg("No shard map published yet")
+	} else {
+		w.apply(r.Kvs[0].Value)
+	}
+	return r.Header.Revision, nil
+}
+
+func (w *shardMapWatcher) watch(ctx context.Context, rev int64) {
+	wch := w.etcd.Watch(clientv3.WithRequireLeader(ctx), w.key, clientv3.WithRev(rev))
+	for wr := range wch {
+		if err := wr.Err(); err != nil {
+			log.Error().Err(err).Str("key", w.key).Msg("Shard map watch failed")
+			return
+		}
+		for _, e := range wr.Events {
+			if e.Type == clientv3.EventTypePut {
+				w.apply(e.Kv.Value)
+			}
+		}
+	}
+}
+
+func (w *shardMapWatcher) apply(b []byte) {
+	m := &csmpb.ShardMap{}
+	if err := proto.Unmarshal(b, m); err != nil {
+		log.Error().Err(err).Msg("Failed to decode shard map")
+		return
+	}
+	if t := w.cfe.Topology(); t != nil && m.Version <= t.Version {
+		return
+	}
+	conns := make(map[int]*grpc.ClientConn, len(m.Shards))
+	for _, sh := range append(m.Shards, m.PreviousShards...) {
+		n := int(sh.Id)
+		if _, e := conns[n]; e {
+			continue
+		}
+		if conn, e := w.conns[n]; e {
+			conns[n] = conn
+			continue
+		}
+		conn, err := w.dial(n)
+		if err != nil {
+			log.Error().Err(err).Int("shard", n).Msg("Failed to connect to shard, keeping current shard map")
+			w.closeUnused(conns)
+			return
+		}
+		conns[n] = conn
+	}
+	t, err := service.NewTopology(m, cacheClients(conns))
+	if err != nil {
+		log.Error().Err(err).Int64("version", m.Version).Msg("Invalid shard map")
+		w.closeUnused(conns)
+		return
+	}
+	w.cfe.SetTopology(t)
+	log.Info().
+		Int64("version", m.Version).
+		Int("shards", len(m.Shards)).
+		Bool("migrating", m.Migrating).
+		Msg("Switched to new shard map")
+	old := w.conns
+	w.conns = conns
+	w.closeUnused(old)
+}
+
+// closeUnused closes the connections in conns that are not in w.conns, after
+// a delay.
+func (w *shardMapWatcher) closeUnused(conns map[int]*grpc.ClientConn) {
+	for n, conn := range conns {
+		if w.conns[n] == conn {
+			continue
+		}
+		conn := conn
+		time.AfterFunc(connCloseDelay, func() { conn.Close() })
+	}
+}
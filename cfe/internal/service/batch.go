@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	cspb "github.com/althk/ganache/cacheserver/proto"
+	pb "github.com/althk/ganache/cfe/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MaxBatchKeys is the most keys a single MGet, MSet or MDelete may have.
+const MaxBatchKeys = 1000
+
+// shardBatch is the part of a batch that goes to one shard, idx holds the
+// positions of its keys in the batch.
+type shardBatch struct {
+	c   cspb.CacheClient
+	idx []int
+}
+
+// MGet groups the keys by the shard that owns them and reads the shards in
+// parallel. While keys are being migrated, misses are retried on the keys'
+// previous owners like Get does.
+func (s *CFE) MGet(ctx context.Context, in *pb.MGetRequest) (*pb.MGetResponse, error) {
+	t, err := s.batchTopology(len(in.Keys))
+	if err != nil {
+		return nil, err
+	}
+	curr, prev := t.batchOwners(len(in.Keys), func(i int) string {
+		return fmt.Sprintf("%s%s", in.Keys[i].Namespace, in.Keys[i].Key)
+	})
+	results := make([]*pb.MGetResult, len(in.Keys))
+	get := func(b *shardBatch) {
+		req := &cspb.MGetRequest{Keys: make([]*cspb.GetRequest, len(b.idx))}
+		for j, i := range b.idx {
+			req.Keys[j] = &cspb.GetRequest{
+				Namespace: in.Keys[i].Namespace,
+				Key:       in.Keys[i].Key,
+			}
+		}
+		r, err := b.c.MGet(ctx, req)
+		if err == nil && len(r.Results) != len(b.idx) {
+			err = errBatchMismatch
+		}
+		for j, i := range b.idx {
+			if err != nil {
+				results[i] = &pb.MGetResult{Status: shardStatus(err)}
+				continue
+			}
+			results[i] = &pb.MGetResult{
+				Data:    r.Results[j].GetData(),
+				Status:  keyStatus(r.Results[j].GetStatus()),
+				Version: r.Results[j].GetVersion(),
+			}
+		}
+	}
+	fanOut(groupByShard(curr, nil), get)
+	if prev != nil {
+		fanOut(groupByShard(prev, func(i int) bool {
+			return codes.Code(results[i].Status.Code) == codes.NotFound
+		}), get)
+	}
+	return &pb.MGetResponse{Results: results}, nil
+}
+
+// MSet groups the items by the shard that owns their key and writes to the
+// shards in parallel. Unlike Set, write modes are only checked on the new
+// owners while keys are being migrated. Global items are written to every
+// shard one at a time.
+func (s *CFE) MSet(ctx context.Context, in *pb.MSetRequest) (*pb.MSetResponse, error) {
+	t, err := s.batchTopology(len(in.Items))
+	if err != nil {
+		return nil, err
+	}
+	curr, _ := t.batchOwners(len(in.Items), func(i int) string {
+		return fmt.Sprintf("%s%s", in.Items[i].Namespace, in.Items[i].Key)
+	})
+	statuses := make([]*pb.KeyStatus, len(in.Items))
+	defer func() {
+		for _, it := range in.Items {
+			s.Invalidate(it.Namespace + it.Key)
+		}
+	}()
+	for i, it := range in.Items {
+		if it.Global {
+			_, err := s.setGlobal(ctx, it)
+			statuses[i] = globalStatus(err)
+		}
+	}
+	fanOut(groupByShard(curr, func(i int) bool { return !in.Items[i].Global }), func(b *shardBatch) {
+		req := &cspb.MSetRequest{Items: make([]*cspb.SetRequest, len(b.idx))}
+		for j, i := range b.idx {
+			req.Items[j] = &cspb.SetRequest{
+				Namespace: in.Items[i].Namespace,
+				Key:       in.Items[i].Key,
+				Data:      in.Items[i].GetData(),
+				Ttl:       in.Items[i].GetTtl(),
+				Mode:      cspb.WriteMode(in.Items[i].Mode),
+			}
+		}
+		r, err := b.c.MSet(ctx, req)
+		mergeStatuses(statuses, b.idx, r.GetStatuses(), err)
+	})
+	return &pb.MSetResponse{Statuses: statuses}, nil
+}
+
+// MDelete groups the keys by the shard that owns them and deletes them from
+// the shards in parallel. Like Delete, while keys are being migrated they
+// are also deleted from their previous owners. Global keys are deleted from
+// every shard one at a time.
+func (s *CFE) MDelete(ctx context.Context, in *pb.MDeleteRequest) (*pb.MDeleteResponse, error) {
+	t, err := s.batchTopology(len(in.Keys))
+	if err != nil {
+		return nil, err
+	}
+	curr, prev := t.batchOwners(len(in.Keys), func(i int) string {
+		return fmt.Sprintf("%s%s", in.Keys[i].Namespace, in.Keys[i].Key)
+	})
+	statuses := make([]*pb.KeyStatus, len(in.Keys))
+	defer func() {
+		for _, k := range in.Keys {
+			s.Invalidate(k.Namespace + k.Key)
+		}
+	}()
+	del := func(b *shardBatch) {
+		req := &cspb.MDeleteRequest{Keys: make([]*cspb.DeleteRequest, len(b.idx))}
+		for j, i := range b.idx {
+			req.Keys[j] = &cspb.DeleteRequest{
+				Namespace: in.Keys[i].Namespace,
+				Key:       in.Keys[i].Key,
+			}
+		}
+		r, err := b.c.MDelete(ctx, req)
+		mergeStatuses(statuses, b.idx, r.GetStatuses(), err)
+	}
+	for i, k := range in.Keys {
+		if k.Global {
+			_, err := s.deleteGlobal(ctx, k)
+			statuses[i] = globalStatus(err)
+		}
+	}
+	fanOut(groupByShard(curr, func(i int) bool { return !in.Keys[i].Global }), del)
+	if prev != nil {
+		fanOut(groupByShard(prev, func(i int) bool {
+			return !in.Keys[i].Global && codes.Code(statuses[i].Code) == codes.OK
+		}), del)
+	}
+	return &pb.MDeleteResponse{Statuses: statuses}, nil
+}
+
+var errBatchMismatch = status.Error(codes.Internal, "cache server returned the wrong number of results")
+
+// batchTopology returns the topology a batch of n keys is routed with.
+func (s *CFE) batchTopology(n int) (*Topology, error) {
+	if n > MaxBatchKeys {
+		return nil, status.Errorf(codes.InvalidArgument, "batch has %d keys, at most %d are allowed", n, MaxBatchKeys)
+	}
+	t := s.Topology()
+	if t == nil {
+		return nil, errNoTopology
+	}
+	return t, nil
+}
+
+// batchOwners returns the owner of each of the n keys returned by key and,
+// while migrating, their previous owners (nil where unchanged).
+func (t *Topology) batchOwners(n int, key func(i int) string) ([]cspb.CacheClient, []cspb.CacheClient) {
+	curr := make([]cspb.CacheClient, n)
+	var prev []cspb.CacheClient
+	if t.PrevPicker != nil {
+		prev = make([]cspb.CacheClient, n)
+	}
+	for i := 0; i < n; i++ {
+		c, p := t.owners(key(i))
+		curr[i] = c
+		if prev != nil {
+			prev[i] = p
+		}
+	}
+	return curr, prev
+}
+
+// groupByShard groups batch positions by their client, skipping positions
+// without one and those for which include returns false.
+func groupByShard(clients []cspb.CacheClient, include func(i int) bool) []*shardBatch {
+	byClient := make(map[cspb.CacheClient]*shardBatch)
+	var batches []*shardBatch
+	for i, c := range clients {
+		if c == nil || (include != nil && !include(i)) {
+			continue
+		}
+		b, ok := byClient[c]
+		if !ok {
+			b = &shardBatch{c: c}
+			byClient[c] = b
+			batches = append(batches, b)
+		}
+		b.idx = append(b.idx, i)
+	}
+	return batches
+}
+
+// fanOut calls f for every batch in parallel and waits for all of them.
+// Batches never share a position so f may write its results without locking.
+func fanOut(batches []*shardBatch, f func(b *shardBatch)) {
+	var wg sync.WaitGroup
+	for _, b := range batches {
+		wg.Add(1)
+		go func(b *shardBatch) {
+			defer wg.Done()
+			f(b)
+		}(b)
+	}
+	wg.Wait()
+}
+
+// mergeStatuses copies a shard's per-key statuses to their positions in the
+// batch, or the shard's error to all of them if the call failed.
+func mergeStatuses(statuses []*pb.KeyStatus, idx []int, got []*cspb.KeyStatus, err error) {
+	if err == nil && len(got) != len(idx) {
+		err = errBatchMismatch
+	}
+	for j, i := range idx {
+		if err != nil {
+			statuses[i] = shardStatus(err)
+			continue
+		}
+		statuses[i] = keyStatus(got[j])
+	}
+}
+
+// shardStatus is the status of every key in a sub-batch whose shard could
+// not be called.
+func shardStatus(err error) *pb.KeyStatus {
+	es := status.Convert(err)
+	if es.Code() == codes.Unavailable {
+		return &pb.KeyStatus{Code: int32(codes.Unavailable), Message: "No cache server available."}
+	}
+	return &pb.KeyStatus{Code: int32(codes.Internal), Message: es.Message()}
+}
+
+func keyStatus(st *cspb.KeyStatus) *pb.KeyStatus {
+	return &pb.KeyStatus{
+		Code:    st.GetCode(),
+		Message: st.GetMessage(),
+	}
+}
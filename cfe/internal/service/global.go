@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cspb "github.com/althk/ganache/cacheserver/proto"
+	pb "github.com/althk/ganache/cfe/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// setGlobal writes a global key to every shard, including the previous
+// shards while keys are being migrated, so that whichever shard a Get goes
+// to has it. The expiry is computed here once, so every shard expires the
+// key at the same time. If some shards fail the write may have reached the
+// others, it is retried by setting the key again.
+func (s *CFE) setGlobal(ctx context.Context, in *pb.SetRequest) (*emptypb.Empty, error) {
+	if in.Mode != pb.WriteMode_ALWAYS {
+		return nil, status.Errorf(codes.InvalidArgument, "Global key %v can only be written with the ALWAYS mode", in.Key)
+	}
+	t := s.Topology()
+	if t == nil {
+		return nil, errNoTopology
+	}
+	req := &cspb.SetRequest{
+		Namespace: in.Namespace,
+		Key:       in.Key,
+		Data:      in.GetData(),
+		Global:    true,
+	}
+	if ttl := in.GetTtl(); ttl != nil {
+		if err := ttl.CheckValid(); err != nil || ttl.AsDuration() <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid ttl %v for key %v", ttl, in.Key)
+		}
+		req.ExpiresAt = timestamppb.New(time.Now().Add(ttl.AsDuration()))
+	}
+	defer s.Invalidate(in.Namespace + in.Key)
+	err := t.broadcast(func(c cspb.CacheClient) error {
+		_, err := c.Set(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, globalError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// deleteGlobal deletes a global key from every shard.
+func (s *CFE) deleteGlobal(ctx context.Context, in *pb.DeleteRequest) (*emptypb.Empty, error) {
+	t := s.Topology()
+	if t == nil {
+		return nil, errNoTopology
+	}
+	defer s.Invalidate(in.Namespace + in.Key)
+	req := &cspb.DeleteRequest{
+		Namespace: in.Namespace,
+		Key:       in.Key,
+		Global:    true,
+	}
+	err := t.broadcast(func(c cspb.CacheClient) error {
+		_, err := c.Delete(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, globalError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// broadcast calls f with the client of every shard in parallel and returns
+// one of the errors, if any.
+func (t *Topology) broadcast(f func(c cspb.CacheClient) error) error {
+	var wg sync.WaitGroup
+	var l sync.Mutex
+	var ferr error
+	for _, c := range t.Clients {
+		wg.Add(1)
+		go func(c cspb.CacheClient) {
+			defer wg.Done()
+			if err := f(c); err != nil {
+				l.Lock()
+				defer l.Unlock()
+				ferr = err
+			}
+		}(c)
+	}
+	wg.Wait()
+	return ferr
+}
+
+func globalError(err error) error {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.Unavailable:
+		return err
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// globalStatus is the status of a global key in a batch.
+func globalStatus(err error) *pb.KeyStatus {
+	st := status.Convert(err)
+	return &pb.KeyStatus{
+		Code:    int32(st.Code()),
+		Message: st.Message(),
+	}
+}
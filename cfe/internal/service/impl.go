@@ -3,31 +3,74 @@ package service
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	cspb "github.com/althk/ganache/cacheserver/proto"
 	pb "github.com/althk/ganache/cfe/proto"
+	"github.com/althk/ganache/utils/coalesce"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 var (
-	ErrShardOrClientsEmpty          = fmt.Errorf("shard count and cache clients cannot be zero/empty")
-	ErrShardAndClientCountsMismatch = fmt.Errorf("no. of shards != no. of cache clients")
+	ErrShardOrClientsEmpty = fmt.Errorf("shard map and cache clients cannot be empty")
+	ErrMissingShardClient  = fmt.Errorf("no cache client for a shard in the shard map")
 )
 
 type CFE struct {
 	pb.UnimplementedCFEServer
-	ShardCount   int
-	CacheClients map[int]cspb.CacheClient
+	topo  *Topology
+	l     sync.RWMutex
+	ready chan struct{}  // closed once there is a topology
+	gets  coalesce.Group // Gets in flight, by key
+	near  *nearCache     // nil if no namespace is cached
+	// keys read by clients watching invalidations, nil if not enabled
+	tracking *tracking
 }
 
+// Get reads from the shard that owns the key. While keys are being migrated
+// to a new shard, a miss there is retried on the key's previous owner.
+// Concurrent Gets of the same key share one call to the shard, a caller
+// that is cancelled or times out leaves the others waiting for it. Keys of
+// namespaces with a near cache are served from it while cached.
 func (s *CFE) Get(ctx context.Context, in *pb.GetRequest) (*pb.GetResponse, error) {
-	c := s.getCacheClient(in.Namespace, in.Key, s.ShardCount)
-	r, err := c.Get(ctx, &cspb.GetRequest{
+	// tracked before reading, so that changes made during the read are sent
+	tracked := s.tracking.track(in.TrackingId, in.Namespace+in.Key)
+	if r := s.near.get(in.Namespace, in.Key, time.Now()); r != nil {
+		r.Tracked = tracked
+		return r, nil
+	}
+	c, prev, err := s.getCacheClients(in.Namespace, in.Key)
+	if err != nil {
+		return nil, err
+	}
+	req := &cspb.GetRequest{
 		Namespace: in.Namespace,
 		Key:       in.Key,
+	}
+	v, err, _ := s.gets.Do(ctx, fmt.Sprintf("%s%s", in.Namespace, in.Key), func(ctx context.Context) (interface{}, error) {
+		f := s.near.begin(in.Namespace, in.Key)
+		r, err := c.Get(ctx, req)
+		if prev != nil && status.Code(err) == codes.NotFound {
+			r, err = prev.Get(ctx, req)
+		}
+		if err != nil {
+			s.near.finish(in.Namespace, f, nil, time.Now())
+			return nil, err
+		}
+		resp := &pb.GetResponse{
+			Data:    r.GetData(),
+			Version: r.GetVersion(),
+		}
+		s.near.finish(in.Namespace, f, resp, time.Now())
+		return resp, nil
 	})
+	if ctx.Err() != nil {
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
 	if err != nil {
 		es := status.Convert(err)
 		switch es.Code() {
@@ -39,56 +82,310 @@ func (s *CFE) Get(ctx context.Context, in *pb.GetRequest) (*pb.GetResponse, erro
 			return nil, status.Error(codes.Internal, err.Error())
 		}
 	}
-	resp := &pb.GetResponse{
-		Data: r.GetData(),
-	}
-
-	return resp, nil
+	r := v.(*pb.GetResponse)
+	// the response is shared by the coalesced callers
+	return &pb.GetResponse{
+		Data:    r.Data,
+		Version: r.Version,
+		Tracked: tracked,
+	}, nil
 }
 
+// Set writes to the shard that owns the key. While keys are being migrated,
+// a key that has not been moved yet only exists on its previous owner, so
+// that is where IF_ABSENT and IF_PRESENT writes check for it. Global writes
+// go to every shard.
 func (s *CFE) Set(ctx context.Context, in *pb.SetRequest) (*emptypb.Empty, error) {
-	c := s.getCacheClient(in.Namespace, in.Key, s.ShardCount)
+	if in.Global {
+		return s.setGlobal(ctx, in)
+	}
+	c, prev, err := s.getCacheClients(in.Namespace, in.Key)
+	if err != nil {
+		return nil, err
+	}
+	// also when the write fails, it may have reached the shard
+	defer s.Invalidate(in.Namespace + in.Key)
 	req := &cspb.SetRequest{
 		Namespace: in.Namespace,
 		Key:       in.Key,
 		Data:      in.GetData(),
+		Ttl:       in.GetTtl(),
+		Mode:      cspb.WriteMode(in.Mode),
+	}
+	if prev != nil && in.Mode != pb.WriteMode_ALWAYS {
+		if req.Mode, err = migratingMode(ctx, prev, in); err != nil {
+			return nil, err
+		}
 	}
 	r, err := c.Set(ctx, req)
 	if err != nil {
+		switch status.Code(err) {
+		case codes.AlreadyExists, codes.NotFound, codes.FailedPrecondition:
+			return nil, err
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return r, nil
+}
+
+// migratingMode returns the mode for writing in to the key's new owner, the
+// key exists if either the new or the previous owner has it.
+func migratingMode(ctx context.Context, prev cspb.CacheClient, in *pb.SetRequest) (cspb.WriteMode, error) {
+	_, err := prev.Get(ctx, &cspb.GetRequest{
+		Namespace: in.Namespace,
+		Key:       in.Key,
+	})
+	switch status.Code(err) {
+	case codes.OK:
+		if in.Mode == pb.WriteMode_IF_ABSENT {
+			return 0, status.Errorf(codes.AlreadyExists, "Key %v already exists", in.Key)
+		}
+		return cspb.WriteMode_ALWAYS, nil
+	case codes.NotFound:
+		return cspb.WriteMode(in.Mode), nil
+	case codes.Unavailable:
+		return 0, status.Error(codes.Unavailable, "No cache server available.")
+	default:
+		return 0, status.Error(codes.Internal, err.Error())
+	}
+}
+
+// CompareAndSet goes to the shard that owns the key. While keys are being
+// migrated, a key the new owner does not have yet is swapped on its
+// previous owner instead, like Increment, so that it is checked against the
+// version Get returned from there.
+func (s *CFE) CompareAndSet(ctx context.Context, in *pb.CompareAndSetRequest) (*pb.CompareAndSetResponse, error) {
+	c, prev, err := s.getCacheClients(in.Namespace, in.Key)
+	if err != nil {
+		return nil, err
+	}
+	// also when the write fails, it may have reached the shard
+	defer s.Invalidate(in.Namespace + in.Key)
+	req := &cspb.CompareAndSetRequest{
+		Namespace:       in.Namespace,
+		Key:             in.Key,
+		Data:            in.GetData(),
+		Ttl:             in.GetTtl(),
+		ExpectedVersion: in.ExpectedVersion,
+		MustExist:       prev != nil,
+	}
+	r, err := c.CompareAndSet(ctx, req)
+	if prev != nil && status.Code(err) == codes.NotFound {
+		req.MustExist = false
+		r, err = prev.CompareAndSet(ctx, req)
+	}
+	if err != nil {
+		switch status.Code(err) {
+		case codes.Aborted, codes.InvalidArgument, codes.Unavailable, codes.FailedPrecondition:
+			return nil, err
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return &pb.CompareAndSetResponse{Version: r.Version}, nil
+}
+
+// Increment goes to the shard that owns the key. While keys are being
+// migrated, a counter the new owner does not have yet is incremented on its
+// previous owner instead, it is moved to the new owner with its other keys.
+func (s *CFE) Increment(ctx context.Context, in *pb.IncrementRequest) (*pb.IncrementResponse, error) {
+	c, prev, err := s.getCacheClients(in.Namespace, in.Key)
+	if err != nil {
+		return nil, err
+	}
+	// also when the write fails, it may have reached the shard
+	defer s.Invalidate(in.Namespace + in.Key)
+	req := &cspb.IncrementRequest{
+		Namespace: in.Namespace,
+		Key:       in.Key,
+		Delta:     in.Delta,
+		Initial:   in.Initial,
+		Ttl:       in.GetTtl(),
+		MustExist: prev != nil,
+	}
+	r, err := c.Increment(ctx, req)
+	if prev != nil && status.Code(err) == codes.NotFound {
+		req.MustExist = false
+		r, err = prev.Increment(ctx, req)
+	}
+	if err != nil {
+		switch status.Code(err) {
+		case codes.FailedPrecondition, codes.OutOfRange, codes.InvalidArgument, codes.Unavailable:
+			return nil, err
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return &pb.IncrementResponse{Value: r.Value}, nil
+}
+
+// Delete removes the key from the shard that owns it and, while keys are
+// being migrated, from its previous owner so that reads cannot fall back to
+// the old value. Global deletes go to every shard.
+func (s *CFE) Delete(ctx context.Context, in *pb.DeleteRequest) (*emptypb.Empty, error) {
+	if in.Global {
+		return s.deleteGlobal(ctx, in)
+	}
+	c, prev, err := s.getCacheClients(in.Namespace, in.Key)
+	if err != nil {
+		return nil, err
+	}
+	// also when the write fails, it may have reached the shard
+	defer s.Invalidate(in.Namespace + in.Key)
+	req := &cspb.DeleteRequest{
+		Namespace: in.Namespace,
+		Key:       in.Key,
+	}
+	r, err := c.Delete(ctx, req)
+	if err == nil && prev != nil {
+		r, err = prev.Delete(ctx, req)
+	}
+	if err != nil {
+		es := status.Convert(err)
+		if es.Code() == codes.Unavailable || es.Code() == codes.FailedPrecondition {
+			return nil, err
+		}
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	return r, nil
 }
 
-func (s *CFE) getCacheClient(ns, key string, mod int) cspb.CacheClient {
-	k := fmt.Sprintf("%s%s", ns, key)
-	shardNum := int(fnv32(k)) % mod
-	return s.CacheClients[shardNum]
+// ClusterStats collects stats from every shard in parallel. A shard that
+// cannot be reached is reported with its error instead of failing the call.
+// Each shard's stats come from whichever of its replicas served the request.
+func (s *CFE) ClusterStats(ctx context.Context, _ *emptypb.Empty) (*pb.ClusterStatsResponse, error) {
+	t := s.Topology()
+	if t == nil {
+		return nil, errNoTopology
+	}
+	shards := make([]*pb.ShardStats, 0, len(t.Clients))
+	var wg sync.WaitGroup
+	var l sync.Mutex
+	for n, c := range t.Clients {
+		wg.Add(1)
+		go func(n int, c cspb.CacheClient) {
+			defer wg.Done()
+			ss := shardStats(ctx, n, c)
+			l.Lock()
+			defer l.Unlock()
+			shards = append(shards, ss)
+		}(n, c)
+	}
+	wg.Wait()
+	sort.Slice(shards, func(i, j int) bool { return shards[i].Shard < shards[j].Shard })
+
+	resp := &pb.ClusterStatsResponse{Shards: shards}
+	var hits, misses uint64
+	for _, ss := range shards {
+		resp.TotalReqCount += ss.TotalReqCount
+		resp.TotalCacheSizeBytes += ss.TotalCacheSizeBytes
+		resp.TotalKeysCount += ss.TotalKeysCount
+		hits += ss.CacheHitCount
+		misses += ss.CacheMissCount
+	}
+	if hits+misses > 0 {
+		resp.CacheHitRatio = hits * 100 / (hits + misses)
+	}
+	return resp, nil
 }
 
-func NewCFE(shardCount int, cacheClis map[int]cspb.CacheClient) (*CFE, error) {
-	if shardCount == 0 || len(cacheClis) == 0 {
-		return nil, ErrShardOrClientsEmpty
+func shardStats(ctx context.Context, n int, c cspb.CacheClient) *pb.ShardStats {
+	r, err := c.Stats(ctx, &emptypb.Empty{})
+	if err != nil {
+		return &pb.ShardStats{Shard: int32(n), Error: err.Error()}
 	}
-	if shardCount != len(cacheClis) {
-		return nil, ErrShardAndClientCountsMismatch
+	return &pb.ShardStats{
+		Shard:               int32(n),
+		GetReqCount:         r.GetReqCount,
+		SetReqCount:         r.SetReqCount,
+		DeleteReqCount:      r.DeleteReqCount,
+		TotalReqCount:       r.TotalReqCount,
+		CacheHitCount:       r.CacheHitCount,
+		CacheMissCount:      r.CacheMissCount,
+		CacheHitRatio:       r.CacheHitRatio,
+		TotalCacheSizeBytes: r.TotalCacheSizeBytes,
+		TotalKeysCount:      r.TotalKeysCount,
 	}
-	return &CFE{
-		ShardCount:   shardCount,
-		CacheClients: cacheClis,
-	}, nil
 }
 
-// fnv32 computes and returns a hash for the given key.
-// lifted as-is from
-// https://github.com/orcaman/concurrent-map/blob/v2.0.0/concurrent_map.go
-func fnv32(key string) uint32 {
-	hash := uint32(2166136261)
-	const prime32 = uint32(16777619)
-	keyLength := len(key)
-	for i := 0; i < keyLength; i++ {
-		hash *= prime32
-		hash ^= uint32(key[i])
+// Topology returns the shard topology requests are currently routed with,
+// nil until the first one is set.
+func (s *CFE) Topology() *Topology {
+	s.l.RLock()
+	defer s.l.RUnlock()
+	return s.topo
+}
+
+// SetTopology swaps in t for new requests, requests already in flight finish
+// with the topology they started with. It returns false and keeps the
+// current topology if t is not newer.
+func (s *CFE) SetTopology(t *Topology) bool {
+	s.l.Lock()
+	defer s.l.Unlock()
+	if s.topo != nil && t.Version <= s.topo.Version {
+		return false
+	}
+	if s.topo == nil {
+		close(s.ready)
+	}
+	s.topo = t
+	return true
+}
+
+// SetNearCache enables the near cache for the namespaces in cfgs, it must be
+// called before serving. Entries are dropped when this CFE writes their key,
+// other writes must be reported with Invalidate.
+func (s *CFE) SetNearCache(cfgs map[string]NearCacheConfig) {
+	if len(cfgs) > 0 {
+		s.near = newNearCache(cfgs)
+	}
+}
+
+// NeedsInvalidations reports whether the near cache or client tracking is
+// enabled, which need writes made through other CFEs reported.
+func (s *CFE) NeedsInvalidations() bool {
+	return s.near != nil || s.tracking != nil
+}
+
+// Invalidate reports a change of cache key k, namespace and key
+// concatenated, to the near cache and to the clients that have read it.
+func (s *CFE) Invalidate(k string) {
+	s.near.invalidate(k)
+	s.tracking.invalidate(k)
+}
+
+// InvalidateAll drops every entry of the near cache and tells every client
+// to do the same, for when invalidations may have been missed.
+func (s *CFE) InvalidateAll() {
+	s.near.purge()
+	s.tracking.flushAll()
+}
+
+// Ready returns a channel that is closed once the CFE has a topology and can
+// route requests.
+func (s *CFE) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// getCacheClients returns the client of the shard that owns the key and,
+// while migrating, the client of its previous owner (nil if unchanged).
+func (s *CFE) getCacheClients(ns, key string) (cspb.CacheClient, cspb.CacheClient, error) {
+	t := s.Topology()
+	if t == nil {
+		return nil, nil, errNoTopology
+	}
+	curr, prev := t.owners(fmt.Sprintf("%s%s", ns, key))
+	return curr, prev, nil
+}
+
+// NewCFE returns a CFE that routes requests with t, t may be nil if the
+// shard map is not known yet, requests fail with Unavailable until
+// SetTopology is called.
+func NewCFE(t *Topology) *CFE {
+	s := &CFE{ready: make(chan struct{})}
+	if t != nil {
+		s.SetTopology(t)
 	}
-	return hash
+	return s
 }
@@ -2,10 +2,16 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	cspb "github.com/althk/ganache/cacheserver/proto"
 	pb "github.com/althk/ganache/cfe/proto"
+	csmpb "github.com/althk/ganache/csm/proto"
+	"github.com/althk/ganache/utils/sharding"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
@@ -13,37 +19,86 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 var setRequestMsg *cspb.SetRequest
+var deleteRequestMsg *cspb.DeleteRequest
+var mockLock sync.Mutex // global writes call the mocks in parallel
 
-func TestNewCFEValid(t *testing.T) {
+func TestNewTopology(t *testing.T) {
 	cacheClis := make(map[int]cspb.CacheClient)
 	cacheClis[0] = &mockCacheClient{}
 
-	got, err := NewCFE(1, cacheClis)
+	got, err := NewTopology(testShardMap(1, 1), cacheClis)
 	require.NoError(t, err)
-	require.NotNil(t, got)
+	require.EqualValues(t, 1, got.Version)
+	require.Len(t, got.Clients, 1)
 }
 
-func TestNewCFEError(t *testing.T) {
+func TestNewTopologyError(t *testing.T) {
 	cacheClis := make(map[int]cspb.CacheClient)
 	cacheClis[0] = &mockCacheClient{}
 
-	got, err := NewCFE(0, cacheClis)
+	got, err := NewTopology(testShardMap(1, 0), cacheClis)
 	require.Nil(t, got)
 	require.ErrorIs(t, err, ErrShardOrClientsEmpty)
 
-	got, err = NewCFE(10, cacheClis)
+	got, err = NewTopology(testShardMap(1, 10), cacheClis)
 	require.Nil(t, got)
-	require.ErrorIs(t, err, ErrShardAndClientCountsMismatch)
+	require.ErrorIs(t, err, ErrMissingShardClient)
+
+	m := testShardMap(1, 1)
+	m.Picker = "nope"
+	got, err = NewTopology(m, cacheClis)
+	require.Nil(t, got)
+	require.ErrorIs(t, err, sharding.ErrUnknownPicker)
+}
+
+func TestCFENoTopology(t *testing.T) {
+	c := NewCFE(nil)
+
+	_, err := c.Get(context.TODO(), &pb.GetRequest{
+		Namespace: "ns1",
+		Key:       "validkey",
+	})
+	require.EqualValues(t, codes.Unavailable, status.Code(err))
+	_, err = c.ClusterStats(context.TODO(), &emptypb.Empty{})
+	require.EqualValues(t, codes.Unavailable, status.Code(err))
+}
+
+func TestCFESetTopology(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	cacheClis[0] = &mockCacheClient{}
+	cacheClis[1] = &mockCacheClient{}
+	c := NewCFE(nil)
+
+	select {
+	case <-c.Ready():
+		t.Fatal("ready without a topology")
+	default:
+	}
+	t1, _ := NewTopology(testShardMap(1, 1), cacheClis)
+	require.True(t, c.SetTopology(t1))
+	<-c.Ready()
+	t2, _ := NewTopology(testShardMap(2, 2), cacheClis)
+	require.True(t, c.SetTopology(t2))
+	require.Same(t, t2, c.Topology())
+
+	// a stale shard map must not replace a newer one
+	require.False(t, c.SetTopology(t1))
+	require.Same(t, t2, c.Topology())
+
+	resp, err := c.ClusterStats(context.TODO(), &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, resp.Shards, 2)
 }
 
 func TestCFEGetExistingItem(t *testing.T) {
 	cacheClis := make(map[int]cspb.CacheClient)
 	cacheClis[0] = &mockCacheClient{}
-	c, _ := NewCFE(1, cacheClis)
+	c := testCFE(1, cacheClis)
 
 	resp, err := c.Get(context.TODO(), &pb.GetRequest{
 		Namespace: "ns1",
@@ -56,7 +111,7 @@ func TestCFEGetExistingItem(t *testing.T) {
 func TestCFEGetNonExistentItem(t *testing.T) {
 	cacheClis := make(map[int]cspb.CacheClient)
 	cacheClis[0] = &mockCacheClient{}
-	c, _ := NewCFE(1, cacheClis)
+	c := testCFE(1, cacheClis)
 
 	resp, err := c.Get(context.TODO(), &pb.GetRequest{
 		Namespace: "ns1",
@@ -66,10 +121,184 @@ func TestCFEGetNonExistentItem(t *testing.T) {
 	require.EqualValues(t, codes.NotFound, status.Code(err))
 }
 
+func TestCFEGetCoalesced(t *testing.T) {
+	cli := &mockCacheClient{getGate: make(chan struct{})}
+	cacheClis := map[int]cspb.CacheClient{0: cli}
+	c := testCFE(1, cacheClis)
+	req := &pb.GetRequest{Namespace: "ns1", Key: "validkey"}
+
+	var wg, started sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		started.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Done()
+			resp, err := c.Get(context.TODO(), req)
+			require.NoError(t, err)
+			require.EqualValues(t, "someval", resp.Data.Value)
+		}()
+	}
+	started.Wait()
+	// a waiter that times out does not cancel the call for the others
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.Get(ctx, req)
+	require.EqualValues(t, codes.DeadlineExceeded, status.Code(err))
+
+	close(cli.getGate)
+	wg.Wait()
+	require.EqualValues(t, 1, atomic.LoadInt32(&cli.gets))
+}
+
+func TestCFENearCache(t *testing.T) {
+	cli := &mockCacheClient{data: map[string]string{"k1": "v1", "k2": "v2"}}
+	c := testCFE(1, map[int]cspb.CacheClient{0: cli})
+	c.SetNearCache(map[string]NearCacheConfig{"ns1": {MaxEntries: 10, MaxStaleness: time.Hour}})
+	get := func(ns, k string) string {
+		resp, err := c.Get(context.TODO(), &pb.GetRequest{Namespace: ns, Key: k})
+		require.NoError(t, err)
+		return string(resp.Data.Value)
+	}
+
+	require.Equal(t, "v1", get("ns1", "k1"))
+	require.Equal(t, "v1", get("ns1", "k1"))
+	require.EqualValues(t, 1, atomic.LoadInt32(&cli.gets))
+
+	// namespaces without a near cache always go to the shard
+	get("ns2", "k1")
+	get("ns2", "k1")
+	require.EqualValues(t, 3, atomic.LoadInt32(&cli.gets))
+
+	// writes through this CFE drop the key
+	_, err := c.Set(context.TODO(), &pb.SetRequest{Namespace: "ns1", Key: "k1", Data: &anypb.Any{Value: []byte("v1.1")}})
+	require.NoError(t, err)
+	require.Equal(t, "v1.1", get("ns1", "k1"))
+	require.EqualValues(t, 4, atomic.LoadInt32(&cli.gets))
+
+	// and so do invalidations of writes elsewhere
+	cli.data["k1"] = "v1.2"
+	require.Equal(t, "v1.1", get("ns1", "k1"))
+	c.Invalidate("ns1k1")
+	require.Equal(t, "v1.2", get("ns1", "k1"))
+	require.EqualValues(t, 5, atomic.LoadInt32(&cli.gets))
+
+	// misses are not cached
+	_, err = c.Get(context.TODO(), &pb.GetRequest{Namespace: "ns1", Key: "k3"})
+	require.EqualValues(t, codes.NotFound, status.Code(err))
+	_, err = c.Get(context.TODO(), &pb.GetRequest{Namespace: "ns1", Key: "k3"})
+	require.EqualValues(t, codes.NotFound, status.Code(err))
+	require.EqualValues(t, 7, atomic.LoadInt32(&cli.gets))
+}
+
+func TestCFENearCacheInvalidatedWhileReading(t *testing.T) {
+	cli := &mockCacheClient{data: map[string]string{"k1": "v1"}, getGate: make(chan struct{})}
+	c := testCFE(1, map[int]cspb.CacheClient{0: cli})
+	c.SetNearCache(map[string]NearCacheConfig{"ns1": {MaxEntries: 10, MaxStaleness: time.Hour}})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := c.Get(context.TODO(), &pb.GetRequest{Namespace: "ns1", Key: "k1"})
+		require.NoError(t, err)
+	}()
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&cli.gets) == 1 }, time.Second, time.Millisecond)
+	// the read in flight may have seen the old value, it is not cached
+	c.Invalidate("ns1k1")
+	close(cli.getGate)
+	<-done
+	_, err := c.Get(context.TODO(), &pb.GetRequest{Namespace: "ns1", Key: "k1"})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, atomic.LoadInt32(&cli.gets))
+}
+
+func TestNearCacheLimits(t *testing.T) {
+	nc := newNearCache(map[string]NearCacheConfig{"ns1": {MaxEntries: 2, MaxStaleness: time.Minute}})
+	now := time.Now()
+	put := func(k string) {
+		nc.finish("ns1", nc.begin("ns1", k), &pb.GetResponse{Version: 1}, now)
+	}
+	put("k1")
+	put("k2")
+	require.NotNil(t, nc.get("ns1", "k1", now))
+	put("k3")
+	// k2 is the least recently used
+	require.Nil(t, nc.get("ns1", "k2", now))
+	require.NotNil(t, nc.get("ns1", "k1", now))
+	require.NotNil(t, nc.get("ns1", "k3", now))
+
+	require.Nil(t, nc.get("ns1", "k1", now.Add(time.Minute)))
+	nc.purge()
+	require.Nil(t, nc.get("ns1", "k3", now))
+}
+
+func TestParseNearCacheConfig(t *testing.T) {
+	cfgs, err := ParseNearCacheConfig("users:1000:500ms,a:b:10:5s")
+	require.NoError(t, err)
+	require.Equal(t, map[string]NearCacheConfig{
+		"users": {MaxEntries: 1000, MaxStaleness: 500 * time.Millisecond},
+		"a:b":   {MaxEntries: 10, MaxStaleness: 5 * time.Second},
+	}, cfgs)
+
+	cfgs, err = ParseNearCacheConfig("")
+	require.NoError(t, err)
+	require.Empty(t, cfgs)
+
+	for _, bad := range []string{"users", "users:0:1s", "users:10:0s", "users:x:1s", "u:1:1s,u:2:1s"} {
+		_, err := ParseNearCacheConfig(bad)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestCFEWatchInvalidations(t *testing.T) {
+	cli := &mockCacheClient{data: map[string]string{"k1": "v1", "k2": "v2"}}
+	c := testCFE(1, map[int]cspb.CacheClient{0: cli})
+	c.EnableClientTracking()
+	stream := &mockInvalidationStream{ctx: context.TODO(), events: make(chan *pb.InvalidationEvent, 10)}
+	done := make(chan error, 1)
+	go func() { done <- c.WatchInvalidations(&pb.WatchInvalidationsRequest{}, stream) }()
+	id := (<-stream.events).TrackingId
+	require.NotEmpty(t, id)
+
+	get := func(k, id string) bool {
+		resp, err := c.Get(context.TODO(), &pb.GetRequest{Namespace: "ns1", Key: k, TrackingId: id})
+		require.NoError(t, err)
+		return resp.Tracked
+	}
+	require.True(t, get("k1", id))
+	require.True(t, get("k2", id))
+	require.False(t, get("k2", "unknown"))
+
+	// writes through this CFE and elsewhere are reported once
+	_, err := c.Set(context.TODO(), &pb.SetRequest{Namespace: "ns1", Key: "k1", Data: &anypb.Any{}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"ns1k1"}, (<-stream.events).Keys)
+	c.Invalidate("ns1k1")
+	c.Invalidate("ns1k3") // never read
+	c.Invalidate("ns1k2")
+	require.Equal(t, []string{"ns1k2"}, (<-stream.events).Keys)
+
+	c.InvalidateAll()
+	require.True(t, (<-stream.events).Flush)
+
+	require.NoError(t, c.StopClientTracking(context.TODO()))
+	require.EqualValues(t, codes.Unavailable, status.Code(<-done))
+	_, err = c.Get(context.TODO(), &pb.GetRequest{Namespace: "ns1", Key: "k1", TrackingId: id})
+	require.NoError(t, err)
+	require.Empty(t, stream.events)
+}
+
+func TestCFEWatchInvalidationsDisabled(t *testing.T) {
+	c := testCFE(1, map[int]cspb.CacheClient{0: &mockCacheClient{}})
+	stream := &mockInvalidationStream{ctx: context.TODO(), events: make(chan *pb.InvalidationEvent, 1)}
+	err := c.WatchInvalidations(&pb.WatchInvalidationsRequest{}, stream)
+	require.EqualValues(t, codes.FailedPrecondition, status.Code(err))
+}
+
 func TestCFESet(t *testing.T) {
 	cacheClis := make(map[int]cspb.CacheClient)
 	cacheClis[0] = &mockCacheClient{}
-	c, _ := NewCFE(1, cacheClis)
+	c := testCFE(1, cacheClis)
 
 	_, err := c.Set(context.TODO(), &pb.SetRequest{
 		Namespace: "ns1",
@@ -82,14 +311,440 @@ func TestCFESet(t *testing.T) {
 	require.EqualValues(t, []byte("someval"), setRequestMsg.Data.Value)
 }
 
+func TestCFESetWithTTL(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	cacheClis[0] = &mockCacheClient{}
+	c := testCFE(1, cacheClis)
+
+	_, err := c.Set(context.TODO(), &pb.SetRequest{
+		Namespace: "ns1",
+		Key:       "validkey",
+		Data: &anypb.Any{
+			Value: []byte("someval"),
+		},
+		Ttl: durationpb.New(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, setRequestMsg.Ttl.AsDuration())
+}
+
+func TestCFECompareAndSet(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	cacheClis[0] = &mockCacheClient{}
+	c := testCFE(1, cacheClis)
+
+	resp, err := c.CompareAndSet(context.TODO(), &pb.CompareAndSetRequest{
+		Namespace:       "ns1",
+		Key:             "validkey",
+		ExpectedVersion: 1,
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, resp.Version)
+
+	_, err = c.CompareAndSet(context.TODO(), &pb.CompareAndSetRequest{
+		Namespace:       "ns1",
+		Key:             "validkey",
+		ExpectedVersion: 5,
+	})
+	require.EqualValues(t, codes.Aborted, status.Code(err))
+}
+
+func TestCFEMigratingIncrement(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	old := &mockCacheClient{counts: map[string]int64{}}
+	cacheClis[0] = old
+	cacheClis[1] = &mockCacheClient{counts: map[string]int64{}}
+	topo, _ := NewTopology(testMigratingMap(), cacheClis)
+	c := NewCFE(topo)
+	k := migratingKey(topo)
+	old.counts[k] = 10
+	req := &pb.IncrementRequest{Namespace: "ns1", Key: k, Delta: 1}
+
+	// not moved yet, incremented on the previous owner
+	resp, err := c.Increment(context.TODO(), req)
+	require.NoError(t, err)
+	require.EqualValues(t, 11, resp.Value)
+
+	// once moved, the new owner has it
+	cacheClis[1].(*mockCacheClient).counts[k] = 20
+	resp, err = c.Increment(context.TODO(), req)
+	require.NoError(t, err)
+	require.EqualValues(t, 21, resp.Value)
+	require.EqualValues(t, 11, old.counts[k])
+}
+
+func TestCFEMigratingCompareAndSet(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	old := &mockCacheClient{versions: map[string]uint64{}}
+	curr := &mockCacheClient{versions: map[string]uint64{}}
+	cacheClis[0] = old
+	cacheClis[1] = curr
+	topo, _ := NewTopology(testMigratingMap(), cacheClis)
+	c := NewCFE(topo)
+	k := migratingKey(topo)
+	old.versions[k] = 5
+	req := &pb.CompareAndSetRequest{Namespace: "ns1", Key: k, ExpectedVersion: 5}
+
+	// not moved yet, swapped on the previous owner
+	resp, err := c.CompareAndSet(context.TODO(), req)
+	require.NoError(t, err)
+	require.EqualValues(t, 6, resp.Version)
+	_, err = c.CompareAndSet(context.TODO(), req)
+	require.EqualValues(t, codes.Aborted, status.Code(err))
+	require.Zero(t, curr.versions[k])
+
+	// once moved, the new owner has it
+	curr.versions[k] = 6
+	req.ExpectedVersion = 6
+	resp, err = c.CompareAndSet(context.TODO(), req)
+	require.NoError(t, err)
+	require.EqualValues(t, 7, resp.Version)
+	require.EqualValues(t, 6, old.versions[k])
+}
+
+func TestCFESetWriteMode(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	cacheClis[0] = &mockCacheClient{data: map[string]string{}}
+	c := testCFE(1, cacheClis)
+	req := &pb.SetRequest{Namespace: "ns1", Key: "key1", Mode: pb.WriteMode_IF_PRESENT}
+
+	_, err := c.Set(context.TODO(), req)
+	require.EqualValues(t, codes.NotFound, status.Code(err))
+	req.Mode = pb.WriteMode_IF_ABSENT
+	_, err = c.Set(context.TODO(), req)
+	require.NoError(t, err)
+	_, err = c.Set(context.TODO(), req)
+	require.EqualValues(t, codes.AlreadyExists, status.Code(err))
+}
+
+func TestCFEMigratingSetWriteMode(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	old := &mockCacheClient{data: map[string]string{}}
+	cacheClis[0] = old
+	cacheClis[1] = &mockCacheClient{data: map[string]string{}}
+	topo, _ := NewTopology(testMigratingMap(), cacheClis)
+	c := NewCFE(topo)
+	k := migratingKey(topo)
+	old.data[k] = "oldval"
+
+	// the key has not moved yet, but it exists
+	_, err := c.Set(context.TODO(), &pb.SetRequest{Namespace: "ns1", Key: k, Mode: pb.WriteMode_IF_ABSENT})
+	require.EqualValues(t, codes.AlreadyExists, status.Code(err))
+	_, err = c.Set(context.TODO(), &pb.SetRequest{
+		Namespace: "ns1",
+		Key:       k,
+		Data:      &anypb.Any{Value: []byte("newval")},
+		Mode:      pb.WriteMode_IF_PRESENT,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "newval", cacheClis[1].(*mockCacheClient).data[k])
+}
+
+func TestCFEDelete(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	cacheClis[0] = &mockCacheClient{}
+	c := testCFE(1, cacheClis)
+
+	_, err := c.Delete(context.TODO(), &pb.DeleteRequest{
+		Namespace: "ns1",
+		Key:       "validkey",
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, "ns1", deleteRequestMsg.Namespace)
+	require.EqualValues(t, "validkey", deleteRequestMsg.Key)
+}
+
+func TestCFEMigratingGet(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	old := &mockCacheClient{data: map[string]string{}}
+	cacheClis[0] = old
+	cacheClis[1] = &mockCacheClient{data: map[string]string{}}
+	topo, err := NewTopology(testMigratingMap(), cacheClis)
+	require.NoError(t, err)
+	c := NewCFE(topo)
+	k := migratingKey(topo)
+
+	// not moved yet, served by the previous owner
+	old.data[k] = "oldval"
+	resp, err := c.Get(context.TODO(), &pb.GetRequest{Namespace: "ns1", Key: k})
+	require.NoError(t, err)
+	require.EqualValues(t, "oldval", resp.Data.Value)
+
+	// the new owner wins once it has the key
+	cacheClis[1].(*mockCacheClient).data[k] = "newval"
+	resp, err = c.Get(context.TODO(), &pb.GetRequest{Namespace: "ns1", Key: k})
+	require.NoError(t, err)
+	require.EqualValues(t, "newval", resp.Data.Value)
+
+	_, err = c.Get(context.TODO(), &pb.GetRequest{Namespace: "ns1", Key: "nonexistentkey"})
+	require.EqualValues(t, codes.NotFound, status.Code(err))
+}
+
+func TestCFEMigratingDelete(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	cacheClis[0] = &mockCacheClient{}
+	cacheClis[1] = &mockCacheClient{}
+	topo, _ := NewTopology(testMigratingMap(), cacheClis)
+	c := NewCFE(topo)
+	k := migratingKey(topo)
+
+	_, err := c.Delete(context.TODO(), &pb.DeleteRequest{Namespace: "ns1", Key: k})
+	require.NoError(t, err)
+	require.Equal(t, []string{k}, cacheClis[0].(*mockCacheClient).deleted)
+	require.Equal(t, []string{k}, cacheClis[1].(*mockCacheClient).deleted)
+}
+
+func TestNewTopologyMigrating(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	cacheClis[1] = &mockCacheClient{}
+
+	// shard 0, the previous owner of all keys, has no client
+	_, err := NewTopology(testMigratingMap(), cacheClis)
+	require.ErrorIs(t, err, ErrMissingShardClient)
+
+	cacheClis[0] = &mockCacheClient{}
+	topo, err := NewTopology(testMigratingMap(), cacheClis)
+	require.NoError(t, err)
+	require.NotNil(t, topo.PrevPicker)
+}
+
+func TestCFEClusterStats(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	cacheClis[0] = &mockCacheClient{}
+	cacheClis[1] = &mockCacheClient{}
+	cacheClis[2] = &mockCacheClient{statsErr: status.Error(codes.Unavailable, "down")}
+	c := testCFE(3, cacheClis)
+
+	resp, err := c.ClusterStats(context.TODO(), &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, resp.Shards, 3)
+	for i, ss := range resp.Shards {
+		require.EqualValues(t, i, ss.Shard)
+	}
+	require.EqualValues(t, 10, resp.Shards[0].TotalKeysCount)
+	require.NotEmpty(t, resp.Shards[2].Error)
+	require.EqualValues(t, 20, resp.TotalKeysCount)
+	require.EqualValues(t, 2000, resp.TotalCacheSizeBytes)
+	require.EqualValues(t, 8, resp.TotalReqCount)
+	require.EqualValues(t, 50, resp.CacheHitRatio)
+}
+
+func TestCFEMGet(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	cacheClis[0] = &mockCacheClient{}
+	cacheClis[1] = &mockCacheClient{}
+	cacheClis[2] = &mockCacheClient{batchErr: status.Error(codes.Unavailable, "down")}
+	c := testCFE(3, cacheClis)
+	var keys []*pb.GetRequest
+	for i := 0; i < 20; i++ {
+		keys = append(keys, &pb.GetRequest{Namespace: "ns1", Key: "validkey"})
+	}
+	keys = append(keys, &pb.GetRequest{Namespace: "ns1", Key: "nonexistentkey"})
+	// land keys on every shard
+	for i := 0; i < 30; i++ {
+		keys = append(keys, &pb.GetRequest{Namespace: fmt.Sprintf("ns%d", i), Key: "validkey"})
+	}
+
+	resp, err := c.MGet(context.TODO(), &pb.MGetRequest{Keys: keys})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, len(keys))
+	for i, k := range keys {
+		r := resp.Results[i]
+		switch {
+		case c.Topology().Picker.Shard(k.Namespace+k.Key) == 2:
+			require.EqualValues(t, codes.Unavailable, r.Status.Code)
+		case k.Key == "nonexistentkey":
+			require.EqualValues(t, codes.NotFound, r.Status.Code)
+		default:
+			require.EqualValues(t, codes.OK, r.Status.Code)
+			require.EqualValues(t, "someval", r.Data.Value)
+		}
+	}
+}
+
+func TestCFEMGetTooManyKeys(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	cacheClis[0] = &mockCacheClient{}
+	c := testCFE(1, cacheClis)
+
+	_, err := c.MGet(context.TODO(), &pb.MGetRequest{Keys: make([]*pb.GetRequest, MaxBatchKeys+1)})
+	require.EqualValues(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCFEMigratingMGet(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	cacheClis[0] = &mockCacheClient{data: map[string]string{}}
+	cacheClis[1] = &mockCacheClient{data: map[string]string{}}
+	topo, _ := NewTopology(testMigratingMap(), cacheClis)
+	c := NewCFE(topo)
+	k := migratingKey(topo)
+	cacheClis[0].(*mockCacheClient).data[k] = "oldval"
+
+	resp, err := c.MGet(context.TODO(), &pb.MGetRequest{Keys: []*pb.GetRequest{
+		{Namespace: "ns1", Key: k},
+		{Namespace: "ns1", Key: "nonexistentkey"},
+	}})
+	require.NoError(t, err)
+	require.EqualValues(t, codes.OK, resp.Results[0].Status.Code)
+	require.EqualValues(t, "oldval", resp.Results[0].Data.Value)
+	require.EqualValues(t, codes.NotFound, resp.Results[1].Status.Code)
+}
+
+func TestCFEMSet(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	cacheClis[0] = &mockCacheClient{}
+	cacheClis[1] = &mockCacheClient{}
+	c := testCFE(2, cacheClis)
+	var items []*pb.SetRequest
+	for i := 0; i < 20; i++ {
+		items = append(items, &pb.SetRequest{Namespace: "ns1", Key: fmt.Sprintf("key%d", i)})
+	}
+
+	resp, err := c.MSet(context.TODO(), &pb.MSetRequest{Items: items})
+	require.NoError(t, err)
+	require.Len(t, resp.Statuses, len(items))
+	var got []string
+	for i := 0; i < 2; i++ {
+		set := cacheClis[i].(*mockCacheClient).set
+		for _, k := range set {
+			require.Equal(t, i, c.Topology().Picker.Shard("ns1"+k))
+		}
+		got = append(got, set...)
+	}
+	require.Len(t, got, len(items))
+}
+
+func TestCFEGlobal(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	for i := 0; i < 3; i++ {
+		cacheClis[i] = &mockCacheClient{data: map[string]string{}}
+	}
+	c := testCFE(3, cacheClis)
+
+	_, err := c.Set(context.TODO(), &pb.SetRequest{
+		Namespace: "ns1",
+		Key:       "config",
+		Data:      &anypb.Any{Value: []byte("someval")},
+		Ttl:       durationpb.New(time.Minute),
+		Global:    true,
+	})
+	require.NoError(t, err)
+	require.True(t, setRequestMsg.Global)
+	require.Nil(t, setRequestMsg.Ttl)
+	require.WithinDuration(t, time.Now().Add(time.Minute), setRequestMsg.ExpiresAt.AsTime(), time.Second)
+	for _, cli := range cacheClis {
+		require.Equal(t, "someval", cli.(*mockCacheClient).data["config"])
+	}
+
+	_, err = c.Set(context.TODO(), &pb.SetRequest{Namespace: "ns1", Key: "config", Global: true, Mode: pb.WriteMode_IF_ABSENT})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	resp, err := c.MDelete(context.TODO(), &pb.MDeleteRequest{Keys: []*pb.DeleteRequest{
+		{Namespace: "ns1", Key: "config", Global: true},
+	}})
+	require.NoError(t, err)
+	require.EqualValues(t, codes.OK, resp.Statuses[0].Code)
+	for _, cli := range cacheClis {
+		require.Equal(t, []string{"config"}, cli.(*mockCacheClient).deleted)
+	}
+	require.True(t, deleteRequestMsg.Global)
+}
+
+func TestCFEMigratingMDelete(t *testing.T) {
+	cacheClis := make(map[int]cspb.CacheClient)
+	cacheClis[0] = &mockCacheClient{}
+	cacheClis[1] = &mockCacheClient{}
+	topo, _ := NewTopology(testMigratingMap(), cacheClis)
+	c := NewCFE(topo)
+	k := migratingKey(topo)
+
+	resp, err := c.MDelete(context.TODO(), &pb.MDeleteRequest{Keys: []*pb.DeleteRequest{
+		{Namespace: "ns1", Key: k},
+	}})
+	require.NoError(t, err)
+	require.EqualValues(t, codes.OK, resp.Statuses[0].Code)
+	require.Equal(t, []string{k}, cacheClis[0].(*mockCacheClient).deleted)
+	require.Equal(t, []string{k}, cacheClis[1].(*mockCacheClient).deleted)
+}
+
+// migratingKey returns a key that moves from shard 0 to shard 1 when shard
+// 1 is added to a single shard map.
+func migratingKey(t *Topology) string {
+	for i := 0; ; i++ {
+		k := fmt.Sprintf("key%d", i)
+		if t.Picker.Shard("ns1"+k) == 1 {
+			return k
+		}
+	}
+}
+
+func testMigratingMap() *csmpb.ShardMap {
+	m := testShardMap(2, 2)
+	m.Migrating = true
+	m.PreviousShards = testShardMap(1, 1).Shards
+	return m
+}
+
+type mockInvalidationStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *pb.InvalidationEvent
+}
+
+func (m *mockInvalidationStream) Context() context.Context {
+	return m.ctx
+}
+
+func (m *mockInvalidationStream) Send(ev *pb.InvalidationEvent) error {
+	m.events <- ev
+	return nil
+}
+
+func testShardMap(version int64, shardCount int) *csmpb.ShardMap {
+	m := &csmpb.ShardMap{
+		Version: version,
+		Picker:  sharding.Ring,
+		Vnodes:  sharding.DefaultVNodes,
+	}
+	for i := 0; i < shardCount; i++ {
+		m.Shards = append(m.Shards, &csmpb.Shard{Id: int32(i), Weight: 1})
+	}
+	return m
+}
+
+func testCFE(shardCount int, cacheClis map[int]cspb.CacheClient) *CFE {
+	t, _ := NewTopology(testShardMap(1, shardCount), cacheClis)
+	return NewCFE(t)
+}
+
 // mockCacheClient implements cspb.CacheClient
 type mockCacheClient struct {
 	//getReqResponses map[string]
 	mock.Mock
+	statsErr error
+	data     map[string]string // if set, Get is served from it
+	deleted  []string
+	set      []string
+	counts   map[string]int64
+	versions map[string]uint64 // if set, CompareAndSet checks against it
+	gets     int32             // Get calls, updated atomically
+	getGate  chan struct{}     // if set, Get blocks until it is closed
+	batchErr error             // if set, batch calls fail with it
 }
 
 func (m *mockCacheClient) Get(_ context.Context, in *cspb.GetRequest, _ ...grpc.CallOption) (*cspb.GetResponse, error) {
+	atomic.AddInt32(&m.gets, 1)
+	if m.getGate != nil {
+		<-m.getGate
+	}
 	k := in.Key
+	if m.data != nil {
+		v, e := m.data[k]
+		if !e {
+			return nil, status.Error(codes.NotFound, "not found")
+		}
+		return &cspb.GetResponse{Data: &anypb.Any{Value: []byte(v)}}, nil
+	}
 	switch k {
 	case "validkey":
 		return &cspb.GetResponse{
@@ -104,10 +759,127 @@ func (m *mockCacheClient) Get(_ context.Context, in *cspb.GetRequest, _ ...grpc.
 	}
 }
 func (m *mockCacheClient) Set(_ context.Context, in *cspb.SetRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	mockLock.Lock()
+	defer mockLock.Unlock()
 	setRequestMsg = &cspb.SetRequest{}
 	proto.Merge(setRequestMsg, in)
+	if m.data != nil {
+		_, e := m.data[in.Key]
+		switch {
+		case in.Mode == cspb.WriteMode_IF_ABSENT && e:
+			return nil, status.Error(codes.AlreadyExists, "exists")
+		case in.Mode == cspb.WriteMode_IF_PRESENT && !e:
+			return nil, status.Error(codes.NotFound, "not found")
+		}
+		m.data[in.Key] = string(in.Data.GetValue())
+	}
 	return &emptypb.Empty{}, nil
 }
+func (m *mockCacheClient) Delete(_ context.Context, in *cspb.DeleteRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	mockLock.Lock()
+	defer mockLock.Unlock()
+	deleteRequestMsg = &cspb.DeleteRequest{}
+	proto.Merge(deleteRequestMsg, in)
+	m.deleted = append(m.deleted, in.Key)
+	return &emptypb.Empty{}, nil
+}
+func (m *mockCacheClient) MGet(ctx context.Context, in *cspb.MGetRequest, _ ...grpc.CallOption) (*cspb.MGetResponse, error) {
+	if m.batchErr != nil {
+		return nil, m.batchErr
+	}
+	resp := &cspb.MGetResponse{}
+	for _, k := range in.Keys {
+		r, err := m.Get(ctx, k)
+		st := status.Convert(err)
+		resp.Results = append(resp.Results, &cspb.MGetResult{
+			Data:   r.GetData(),
+			Status: &cspb.KeyStatus{Code: int32(st.Code()), Message: st.Message()},
+		})
+	}
+	return resp, nil
+}
+func (m *mockCacheClient) MSet(_ context.Context, in *cspb.MSetRequest, _ ...grpc.CallOption) (*cspb.MSetResponse, error) {
+	if m.batchErr != nil {
+		return nil, m.batchErr
+	}
+	resp := &cspb.MSetResponse{}
+	for _, it := range in.Items {
+		m.set = append(m.set, it.Key)
+		resp.Statuses = append(resp.Statuses, &cspb.KeyStatus{})
+	}
+	return resp, nil
+}
+func (m *mockCacheClient) MDelete(_ context.Context, in *cspb.MDeleteRequest, _ ...grpc.CallOption) (*cspb.MDeleteResponse, error) {
+	if m.batchErr != nil {
+		return nil, m.batchErr
+	}
+	resp := &cspb.MDeleteResponse{}
+	for _, k := range in.Keys {
+		m.deleted = append(m.deleted, k.Key)
+		resp.Statuses = append(resp.Statuses, &cspb.KeyStatus{})
+	}
+	return resp, nil
+}
+func (m *mockCacheClient) CompareAndSet(_ context.Context, in *cspb.CompareAndSetRequest, _ ...grpc.CallOption) (*cspb.CompareAndSetResponse, error) {
+	if m.versions != nil {
+		v := m.versions[in.Key]
+		if v == 0 && in.MustExist {
+			return nil, status.Error(codes.NotFound, "not found")
+		}
+		if v != in.ExpectedVersion {
+			return nil, status.Error(codes.Aborted, "version mismatch")
+		}
+		m.versions[in.Key] = v + 1
+		return &cspb.CompareAndSetResponse{Version: v + 1}, nil
+	}
+	if in.ExpectedVersion != 1 {
+		return nil, status.Error(codes.Aborted, "version mismatch")
+	}
+	return &cspb.CompareAndSetResponse{Version: 2}, nil
+}
+func (m *mockCacheClient) Increment(_ context.Context, in *cspb.IncrementRequest, _ ...grpc.CallOption) (*cspb.IncrementResponse, error) {
+	if m.counts == nil {
+		m.counts = make(map[string]int64)
+	}
+	v, e := m.counts[in.Key]
+	if !e && in.MustExist {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	if !e {
+		v = in.Initial
+	}
+	m.counts[in.Key] = v + in.Delta
+	return &cspb.IncrementResponse{Value: v + in.Delta}, nil
+}
+func (m *mockCacheClient) Transfer(_ context.Context, _ *cspb.TransferRequest, _ ...grpc.CallOption) (cspb.Cache_TransferClient, error) {
+	return nil, status.Error(codes.Unimplemented, "not implemented")
+}
+func (m *mockCacheClient) Replicate(_ context.Context, _ ...grpc.CallOption) (cspb.Cache_ReplicateClient, error) {
+	return nil, status.Error(codes.Unimplemented, "not implemented")
+}
+func (m *mockCacheClient) MerkleDigest(_ context.Context, _ *cspb.MerkleDigestRequest, _ ...grpc.CallOption) (*cspb.MerkleDigestResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "not implemented")
+}
+func (m *mockCacheClient) MerkleEntries(_ context.Context, _ *cspb.MerkleEntriesRequest, _ ...grpc.CallOption) (cspb.Cache_MerkleEntriesClient, error) {
+	return nil, status.Error(codes.Unimplemented, "not implemented")
+}
+func (m *mockCacheClient) Import(_ context.Context, _ *cspb.ImportRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "not implemented")
+}
+func (m *mockCacheClient) WatchKeys(_ context.Context, _ *cspb.WatchKeysRequest, _ ...grpc.CallOption) (cspb.Cache_WatchKeysClient, error) {
+	return nil, status.Error(codes.Unimplemented, "not implemented")
+}
 func (m *mockCacheClient) Stats(_ context.Context, _ *emptypb.Empty, _ ...grpc.CallOption) (*cspb.StatsResponse, error) {
-	return nil, nil
+	if m.statsErr != nil {
+		return nil, m.statsErr
+	}
+	return &cspb.StatsResponse{
+		GetReqCount:         4,
+		TotalReqCount:       4,
+		CacheHitCount:       2,
+		CacheMissCount:      2,
+		CacheHitRatio:       50,
+		TotalCacheSizeBytes: 1000,
+		TotalKeysCount:      10,
+	}, nil
 }
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"container/list"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/althk/ganache/cfe/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// NearCacheConfig configures the near cache of one namespace.
+type NearCacheConfig struct {
+	// MaxEntries is how many keys of the namespace are kept, the least
+	// recently used ones are dropped first.
+	MaxEntries int
+	// MaxStaleness is how long an entry is served for at most, even if its
+	// invalidation never arrives.
+	MaxStaleness time.Duration
+}
+
+// ParseNearCacheConfig parses a comma separated list of
+// namespace:max_entries:max_staleness, e.g. "users:1000:500ms,flags:100:5s".
+func ParseNearCacheConfig(s string) (map[string]NearCacheConfig, error) {
+	cfgs := make(map[string]NearCacheConfig)
+	if s == "" {
+		return cfgs, nil
+	}
+	for _, spec := range strings.Split(s, ",") {
+		parts := strings.Split(spec, ":")
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("invalid near cache config %q, want namespace:max_entries:max_staleness", spec)
+		}
+		// the namespace itself may contain colons
+		ns := strings.Join(parts[:len(parts)-2], ":")
+		n, err := strconv.Atoi(parts[len(parts)-2])
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid max entries in near cache config %q", spec)
+		}
+		d, err := time.ParseDuration(parts[len(parts)-1])
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid max staleness in near cache config %q", spec)
+		}
+		if _, dup := cfgs[ns]; dup {
+			return nil, fmt.Errorf("namespace %q configured twice for the near cache", ns)
+		}
+		cfgs[ns] = NearCacheConfig{MaxEntries: n, MaxStaleness: d}
+	}
+	return cfgs, nil
+}
+
+// nearCache keeps recent Get responses of the configured namespaces. Entries
+// are indexed by cache key, i.e. namespace and key concatenated, since that
+// is all an invalidation carries.
+type nearCache struct {
+	l       sync.Mutex
+	lists   map[string]*nsList // by namespace
+	entries map[string]*list.Element
+	fills   map[string]*fill // reads from the shards in flight, by cache key
+}
+
+// fill tracks the reads of a key that may add it to the cache. A read that
+// started before an invalidation may return the old value, so it is not
+// added if the key was invalidated in the meantime.
+type fill struct {
+	key     string
+	refs    int
+	invalid bool
+}
+
+// nsList is the LRU list of one namespace, front is most recently used.
+type nsList struct {
+	cfg NearCacheConfig
+	ll  *list.List
+}
+
+type nearEntry struct {
+	key     string
+	ns      *nsList
+	resp    *pb.GetResponse
+	expires time.Time
+}
+
+func newNearCache(cfgs map[string]NearCacheConfig) *nearCache {
+	c := &nearCache{
+		lists:   make(map[string]*nsList, len(cfgs)),
+		entries: make(map[string]*list.Element),
+		fills:   make(map[string]*fill),
+	}
+	for ns, cfg := range cfgs {
+		c.lists[ns] = &nsList{cfg: cfg, ll: list.New()}
+	}
+	return c
+}
+
+// get returns a copy of the cached response for key of namespace ns, or nil.
+func (c *nearCache) get(ns, key string, now time.Time) *pb.GetResponse {
+	if c == nil || c.lists[ns] == nil {
+		return nil
+	}
+	c.l.Lock()
+	defer c.l.Unlock()
+	el, ok := c.entries[ns+key]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*nearEntry)
+	if !now.Before(e.expires) {
+		c.remove(el)
+		return nil
+	}
+	e.ns.ll.MoveToFront(el)
+	return proto.Clone(e.resp).(*pb.GetResponse)
+}
+
+// begin is called before reading key of namespace ns from its shard, it
+// returns nil if the namespace is not cached.
+func (c *nearCache) begin(ns, key string) *fill {
+	if c == nil || c.lists[ns] == nil {
+		return nil
+	}
+	c.l.Lock()
+	defer c.l.Unlock()
+	k := ns + key
+	f, ok := c.fills[k]
+	if !ok || f.invalid {
+		f = &fill{key: k}
+		c.fills[k] = f
+	}
+	f.refs++
+	return f
+}
+
+// finish is called with the result of the read started with begin, resp is
+// cached unless it is nil or the key was invalidated since.
+func (c *nearCache) finish(ns string, f *fill, resp *pb.GetResponse, now time.Time) {
+	if f == nil {
+		return
+	}
+	c.l.Lock()
+	defer c.l.Unlock()
+	f.refs--
+	if f.refs == 0 && c.fills[f.key] == f {
+		delete(c.fills, f.key)
+	}
+	if resp == nil || f.invalid {
+		return
+	}
+	nl := c.lists[ns]
+	k := f.key
+	if el, ok := c.entries[k]; ok {
+		c.remove(el)
+	}
+	c.entries[k] = nl.ll.PushFront(&nearEntry{
+		key:     k,
+		ns:      nl,
+		resp:    proto.Clone(resp).(*pb.GetResponse),
+		expires: now.Add(nl.cfg.MaxStaleness),
+	})
+	for nl.ll.Len() > nl.cfg.MaxEntries {
+		c.remove(nl.ll.Back())
+	}
+}
+
+// invalidate drops the entry of cache key k, if any.
+func (c *nearCache) invalidate(k string) {
+	if c == nil {
+		return
+	}
+	c.l.Lock()
+	defer c.l.Unlock()
+	if el, ok := c.entries[k]; ok {
+		c.remove(el)
+	}
+	if f, ok := c.fills[k]; ok {
+		f.invalid = true
+	}
+}
+
+// purge drops every entry, e.g. when invalidations may have been missed.
+func (c *nearCache) purge() {
+	if c == nil {
+		return
+	}
+	c.l.Lock()
+	defer c.l.Unlock()
+	for _, nl := range c.lists {
+		nl.ll.Init()
+	}
+	c.entries = make(map[string]*list.Element)
+	for _, f := range c.fills {
+		f.invalid = true
+	}
+}
+
+// remove drops el, c.l must be held.
+func (c *nearCache) remove(el *list.Element) {
+	e := el.Value.(*nearEntry)
+	e.ns.ll.Remove(el)
+	delete(c.entries, e.key)
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	cspb "github.com/althk/ganache/cacheserver/proto"
+	csmpb "github.com/althk/ganache/csm/proto"
+	"github.com/althk/ganache/utils/sharding"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errNoTopology = status.Error(codes.Unavailable, "shard map has not been loaded yet")
+
+// Topology is one version of the shard map along with a cache client per
+// shard. It is never modified once built, a new shard map means a new
+// Topology.
+type Topology struct {
+	Version int64
+	Clients map[int]cspb.CacheClient // shards and previous shards
+	Picker  sharding.Picker
+	// PrevPicker is set while keys are being migrated, it maps keys to the
+	// shard that owned them before the migration.
+	PrevPicker sharding.Picker
+}
+
+// NewTopology returns the topology for shard map m, clients must have a
+// client for every shard in m, including its previous shards.
+func NewTopology(m *csmpb.ShardMap, clients map[int]cspb.CacheClient) (*Topology, error) {
+	if len(m.GetShards()) == 0 || len(clients) == 0 {
+		return nil, ErrShardOrClientsEmpty
+	}
+	t := &Topology{
+		Version: m.Version,
+		Clients: make(map[int]cspb.CacheClient, len(m.Shards)),
+	}
+	var err error
+	if t.Picker, err = t.picker(m, m.Shards, clients); err != nil {
+		return nil, err
+	}
+	if m.Migrating {
+		if t.PrevPicker, err = t.picker(m, m.PreviousShards, clients); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// picker builds the picker for shards and adds their clients to t.
+func (t *Topology) picker(m *csmpb.ShardMap, shards []*csmpb.Shard, clients map[int]cspb.CacheClient) (sharding.Picker, error) {
+	w := make(map[int]int, len(shards))
+	for _, sh := range shards {
+		cli, e := clients[int(sh.Id)]
+		if !e {
+			return nil, ErrMissingShardClient
+		}
+		w[int(sh.Id)] = int(sh.Weight)
+		t.Clients[int(sh.Id)] = cli
+	}
+	return sharding.NewPicker(m.Picker, w, int(m.Vnodes))
+}
+
+// owners returns the client of the shard that owns key and, while migrating,
+// the client of its previous owner if that is a different shard.
+func (t *Topology) owners(key string) (cspb.CacheClient, cspb.CacheClient) {
+	curr := t.Picker.Shard(key)
+	if t.PrevPicker == nil {
+		return t.Clients[curr], nil
+	}
+	prev := t.PrevPicker.Shard(key)
+	if prev == curr {
+		return t.Clients[curr], nil
+	}
+	return t.Clients[curr], t.Clients[prev]
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+
+	pb "github.com/althk/ganache/cfe/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MaxTrackedKeys is the most keys tracked for one WatchInvalidations stream.
+// When its client reads more, they are all dropped and it is told to flush.
+const MaxTrackedKeys = 100000
+
+// maxInvalidationKeys is the most keys sent in one InvalidationEvent.
+const maxInvalidationKeys = 1000
+
+// tracking remembers which keys the clients with a WatchInvalidations
+// stream have read.
+type tracking struct {
+	l       sync.Mutex
+	streams map[string]*trackedStream              // by tracking id
+	readers map[string]map[*trackedStream]struct{} // by cache key
+	stopped bool
+}
+
+// trackedStream is the tracking state of one WatchInvalidations stream.
+type trackedStream struct {
+	id      string
+	keys    map[string]struct{} // read and not changed since
+	pending map[string]struct{} // changed and not sent yet
+	flush   bool
+	notify  chan struct{} // has room for one wake up
+	done    chan struct{} // closed when the CFE stops tracking
+}
+
+func newTracking() *tracking {
+	return &tracking{
+		streams: make(map[string]*trackedStream),
+		readers: make(map[string]map[*trackedStream]struct{}),
+	}
+}
+
+func (t *tracking) register() (*trackedStream, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	ts := &trackedStream{
+		id:      hex.EncodeToString(b),
+		keys:    make(map[string]struct{}),
+		pending: make(map[string]struct{}),
+		notify:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	t.l.Lock()
+	defer t.l.Unlock()
+	if t.stopped {
+		return nil, status.Error(codes.Unavailable, "CFE is shutting down.")
+	}
+	t.streams[ts.id] = ts
+	return ts, nil
+}
+
+func (t *tracking) unregister(ts *trackedStream) {
+	t.l.Lock()
+	defer t.l.Unlock()
+	delete(t.streams, ts.id)
+	t.untrackAll(ts)
+}
+
+// track records that the stream with the given id has read cache key k, it
+// returns false if there is no such stream.
+func (t *tracking) track(id, k string) bool {
+	if t == nil || id == "" {
+		return false
+	}
+	t.l.Lock()
+	defer t.l.Unlock()
+	ts, ok := t.streams[id]
+	if !ok {
+		return false
+	}
+	if _, ok := ts.keys[k]; ok {
+		return true
+	}
+	if len(ts.keys) >= MaxTrackedKeys {
+		t.untrackAll(ts)
+		ts.flush = true
+		ts.wake()
+	}
+	ts.keys[k] = struct{}{}
+	r, ok := t.readers[k]
+	if !ok {
+		r = make(map[*trackedStream]struct{})
+		t.readers[k] = r
+	}
+	r[ts] = struct{}{}
+	return true
+}
+
+// invalidate queues cache key k for the streams that have read it and stops
+// tracking it for them.
+func (t *tracking) invalidate(k string) {
+	if t == nil {
+		return
+	}
+	t.l.Lock()
+	defer t.l.Unlock()
+	for ts := range t.readers[k] {
+		delete(ts.keys, k)
+		ts.pending[k] = struct{}{}
+		ts.wake()
+	}
+	delete(t.readers, k)
+}
+
+// flushAll tells every stream to drop all its keys.
+func (t *tracking) flushAll() {
+	if t == nil {
+		return
+	}
+	t.l.Lock()
+	defer t.l.Unlock()
+	for _, ts := range t.streams {
+		t.untrackAll(ts)
+		ts.flush = true
+		ts.wake()
+	}
+}
+
+// stop ends every stream and refuses new ones.
+func (t *tracking) stop() {
+	t.l.Lock()
+	defer t.l.Unlock()
+	if t.stopped {
+		return
+	}
+	t.stopped = true
+	for _, ts := range t.streams {
+		close(ts.done)
+	}
+}
+
+// take returns what is to be sent to ts and clears it.
+func (t *tracking) take(ts *trackedStream) (keys []string, flush bool) {
+	t.l.Lock()
+	defer t.l.Unlock()
+	flush, ts.flush = ts.flush, false
+	if !flush {
+		keys = make([]string, 0, len(ts.pending))
+		for k := range ts.pending {
+			keys = append(keys, k)
+		}
+	}
+	ts.pending = make(map[string]struct{})
+	return keys, flush
+}
+
+// untrackAll stops tracking every key of ts, t.l must be held.
+func (t *tracking) untrackAll(ts *trackedStream) {
+	for k := range ts.keys {
+		if r := t.readers[k]; r != nil {
+			delete(r, ts)
+			if len(r) == 0 {
+				delete(t.readers, k)
+			}
+		}
+	}
+	ts.keys = make(map[string]struct{})
+	ts.pending = make(map[string]struct{})
+}
+
+func (ts *trackedStream) wake() {
+	select {
+	case ts.notify <- struct{}{}:
+	default:
+	}
+}
+
+// WatchInvalidations streams the keys that changed after the client read
+// them with the tracking id sent in the first event. The stream ends with
+// Unavailable when the CFE shuts down, the client must then drop every key
+// it has read since it may miss changes.
+func (s *CFE) WatchInvalidations(_ *pb.WatchInvalidationsRequest, stream pb.CFE_WatchInvalidationsServer) error {
+	if s.tracking == nil {
+		return status.Error(codes.FailedPrecondition, "Client tracking is not enabled.")
+	}
+	ts, err := s.tracking.register()
+	if err != nil {
+		return err
+	}
+	defer s.tracking.unregister(ts)
+	if err := stream.Send(&pb.InvalidationEvent{TrackingId: ts.id}); err != nil {
+		return err
+	}
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ts.done:
+			return status.Error(codes.Unavailable, "CFE is shutting down.")
+		case <-ts.notify:
+		}
+		keys, flush := s.tracking.take(ts)
+		if flush {
+			if err := stream.Send(&pb.InvalidationEvent{Flush: true}); err != nil {
+				return err
+			}
+		}
+		for len(keys) > 0 {
+			n := len(keys)
+			if n > maxInvalidationKeys {
+				n = maxInvalidationKeys
+			}
+			if err := stream.Send(&pb.InvalidationEvent{Keys: keys[:n]}); err != nil {
+				return err
+			}
+			keys = keys[n:]
+		}
+	}
+}
+
+// EnableClientTracking lets clients watch for changes of the keys they have
+// read, it must be called before serving. Like with the near cache, changes
+// made through other CFEs must be reported with Invalidate.
+func (s *CFE) EnableClientTracking() {
+	s.tracking = newTracking()
+}
+
+// StopClientTracking ends the WatchInvalidations streams so that shutting
+// down does not wait for them.
+func (s *CFE) StopClientTracking(context.Context) error {
+	if s.tracking != nil {
+		s.tracking.stop()
+	}
+	return nil
+}
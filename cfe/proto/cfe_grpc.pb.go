@@ -25,6 +25,25 @@ const _ = grpc.SupportPackageIsVersion7
 type CFEClient interface {
 	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
 	Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// CompareAndSet sets the key only if its current version, as returned by
+	// Get, is the expected one. It fails with Aborted otherwise.
+	CompareAndSet(ctx context.Context, in *CompareAndSetRequest, opts ...grpc.CallOption) (*CompareAndSetResponse, error)
+	// Increment atomically adds delta to an int64 entry, creating it if it
+	// does not exist. Other entries fail with FailedPrecondition and a result
+	// that does not fit in an int64 with OutOfRange.
+	Increment(ctx context.Context, in *IncrementRequest, opts ...grpc.CallOption) (*IncrementResponse, error)
+	ClusterStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ClusterStatsResponse, error)
+	// MGet, MSet and MDelete apply the same operation to a batch of keys, the
+	// response has a result per key in request order. A key that fails does
+	// not fail the batch, its error is reported in its result.
+	MGet(ctx context.Context, in *MGetRequest, opts ...grpc.CallOption) (*MGetResponse, error)
+	MSet(ctx context.Context, in *MSetRequest, opts ...grpc.CallOption) (*MSetResponse, error)
+	MDelete(ctx context.Context, in *MDeleteRequest, opts ...grpc.CallOption) (*MDeleteResponse, error)
+	// WatchInvalidations tracks the keys read by Gets that carry the tracking
+	// id sent in the first event, and streams the keys that have changed
+	// since. A key is only reported once, until it is read again.
+	WatchInvalidations(ctx context.Context, in *WatchInvalidationsRequest, opts ...grpc.CallOption) (CFE_WatchInvalidationsClient, error)
 }
 
 type cFEClient struct {
@@ -53,12 +72,126 @@ func (c *cFEClient) Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOp
 	return out, nil
 }
 
+func (c *cFEClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/ganache.cfe.CFE/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cFEClient) CompareAndSet(ctx context.Context, in *CompareAndSetRequest, opts ...grpc.CallOption) (*CompareAndSetResponse, error) {
+	out := new(CompareAndSetResponse)
+	err := c.cc.Invoke(ctx, "/ganache.cfe.CFE/CompareAndSet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cFEClient) Increment(ctx context.Context, in *IncrementRequest, opts ...grpc.CallOption) (*IncrementResponse, error) {
+	out := new(IncrementResponse)
+	err := c.cc.Invoke(ctx, "/ganache.cfe.CFE/Increment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cFEClient) ClusterStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ClusterStatsResponse, error) {
+	out := new(ClusterStatsResponse)
+	err := c.cc.Invoke(ctx, "/ganache.cfe.CFE/ClusterStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cFEClient) MGet(ctx context.Context, in *MGetRequest, opts ...grpc.CallOption) (*MGetResponse, error) {
+	out := new(MGetResponse)
+	err := c.cc.Invoke(ctx, "/ganache.cfe.CFE/MGet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cFEClient) MSet(ctx context.Context, in *MSetRequest, opts ...grpc.CallOption) (*MSetResponse, error) {
+	out := new(MSetResponse)
+	err := c.cc.Invoke(ctx, "/ganache.cfe.CFE/MSet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cFEClient) MDelete(ctx context.Context, in *MDeleteRequest, opts ...grpc.CallOption) (*MDeleteResponse, error) {
+	out := new(MDeleteResponse)
+	err := c.cc.Invoke(ctx, "/ganache.cfe.CFE/MDelete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cFEClient) WatchInvalidations(ctx context.Context, in *WatchInvalidationsRequest, opts ...grpc.CallOption) (CFE_WatchInvalidationsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CFE_ServiceDesc.Streams[0], "/ganache.cfe.CFE/WatchInvalidations", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cFEWatchInvalidationsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CFE_WatchInvalidationsClient interface {
+	Recv() (*InvalidationEvent, error)
+	grpc.ClientStream
+}
+
+type cFEWatchInvalidationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *cFEWatchInvalidationsClient) Recv() (*InvalidationEvent, error) {
+	m := new(InvalidationEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // CFEServer is the server API for CFE service.
 // All implementations must embed UnimplementedCFEServer
 // for forward compatibility
 type CFEServer interface {
 	Get(context.Context, *GetRequest) (*GetResponse, error)
 	Set(context.Context, *SetRequest) (*emptypb.Empty, error)
+	Delete(context.Context, *DeleteRequest) (*emptypb.Empty, error)
+	// CompareAndSet sets the key only if its current version, as returned by
+	// Get, is the expected one. It fails with Aborted otherwise.
+	CompareAndSet(context.Context, *CompareAndSetRequest) (*CompareAndSetResponse, error)
+	// Increment atomically adds delta to an int64 entry, creating it if it
+	// does not exist. Other entries fail with FailedPrecondition and a result
+	// that does not fit in an int64 with OutOfRange.
+	Increment(context.Context, *IncrementRequest) (*IncrementResponse, error)
+	ClusterStats(context.Context, *emptypb.Empty) (*ClusterStatsResponse, error)
+	// MGet, MSet and MDelete apply the same operation to a batch of keys, the
+	// response has a result per key in request order. A key that fails does
+	// not fail the batch, its error is reported in its result.
+	MGet(context.Context, *MGetRequest) (*MGetResponse, error)
+	MSet(context.Context, *MSetRequest) (*MSetResponse, error)
+	MDelete(context.Context, *MDeleteRequest) (*MDeleteResponse, error)
+	// WatchInvalidations tracks the keys read by Gets that carry the tracking
+	// id sent in the first event, and streams the keys that have changed
+	// since. A key is only reported once, until it is read again.
+	WatchInvalidations(*WatchInvalidationsRequest, CFE_WatchInvalidationsServer) error
 	mustEmbedUnimplementedCFEServer()
 }
 
@@ -72,6 +205,30 @@ func (UnimplementedCFEServer) Get(context.Context, *GetRequest) (*GetResponse, e
 func (UnimplementedCFEServer) Set(context.Context, *SetRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Set not implemented")
 }
+func (UnimplementedCFEServer) Delete(context.Context, *DeleteRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedCFEServer) CompareAndSet(context.Context, *CompareAndSetRequest) (*CompareAndSetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompareAndSet not implemented")
+}
+func (UnimplementedCFEServer) Increment(context.Context, *IncrementRequest) (*IncrementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Increment not implemented")
+}
+func (UnimplementedCFEServer) ClusterStats(context.Context, *emptypb.Empty) (*ClusterStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClusterStats not implemented")
+}
+func (UnimplementedCFEServer) MGet(context.Context, *MGetRequest) (*MGetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MGet not implemented")
+}
+func (UnimplementedCFEServer) MSet(context.Context, *MSetRequest) (*MSetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MSet not implemented")
+}
+func (UnimplementedCFEServer) MDelete(context.Context, *MDeleteRequest) (*MDeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MDelete not implemented")
+}
+func (UnimplementedCFEServer) WatchInvalidations(*WatchInvalidationsRequest, CFE_WatchInvalidationsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchInvalidations not implemented")
+}
 func (UnimplementedCFEServer) mustEmbedUnimplementedCFEServer() {}
 
 // UnsafeCFEServer may be embedded to opt out of forward compatibility for this service.
@@ -121,6 +278,153 @@ func _CFE_Set_Handler(srv interface{}, ctx context.Context, dec func(interface{}
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CFE_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CFEServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cfe.CFE/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CFEServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CFE_CompareAndSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompareAndSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CFEServer).CompareAndSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cfe.CFE/CompareAndSet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CFEServer).CompareAndSet(ctx, req.(*CompareAndSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CFE_Increment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IncrementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CFEServer).Increment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cfe.CFE/Increment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CFEServer).Increment(ctx, req.(*IncrementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CFE_ClusterStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CFEServer).ClusterStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cfe.CFE/ClusterStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CFEServer).ClusterStats(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CFE_MGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CFEServer).MGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cfe.CFE/MGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CFEServer).MGet(ctx, req.(*MGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CFE_MSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CFEServer).MSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cfe.CFE/MSet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CFEServer).MSet(ctx, req.(*MSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CFE_MDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CFEServer).MDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.cfe.CFE/MDelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CFEServer).MDelete(ctx, req.(*MDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CFE_WatchInvalidations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchInvalidationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CFEServer).WatchInvalidations(m, &cFEWatchInvalidationsServer{stream})
+}
+
+type CFE_WatchInvalidationsServer interface {
+	Send(*InvalidationEvent) error
+	grpc.ServerStream
+}
+
+type cFEWatchInvalidationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *cFEWatchInvalidationsServer) Send(m *InvalidationEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // CFE_ServiceDesc is the grpc.ServiceDesc for CFE service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -136,7 +440,41 @@ var CFE_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Set",
 			Handler:    _CFE_Set_Handler,
 		},
+		{
+			MethodName: "Delete",
+			Handler:    _CFE_Delete_Handler,
+		},
+		{
+			MethodName: "CompareAndSet",
+			Handler:    _CFE_CompareAndSet_Handler,
+		},
+		{
+			MethodName: "Increment",
+			Handler:    _CFE_Increment_Handler,
+		},
+		{
+			MethodName: "ClusterStats",
+			Handler:    _CFE_ClusterStats_Handler,
+		},
+		{
+			MethodName: "MGet",
+			Handler:    _CFE_MGet_Handler,
+		},
+		{
+			MethodName: "MSet",
+			Handler:    _CFE_MSet_Handler,
+		},
+		{
+			MethodName: "MDelete",
+			Handler:    _CFE_MDelete_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchInvalidations",
+			Handler:       _CFE_WatchInvalidations_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "cfe.proto",
 }
@@ -15,6 +15,7 @@ var maxParallelism = flag.Int("max_parallelism", 2, "max parallelism, it is used
 var c CacheClient
 
 func BenchmarkCFEGetString(b *testing.B) {
+	requireCFE(b)
 	b.SetParallelism(*maxParallelism)
 	b.RunParallel(func(pb *testing.PB) {
 		ctx := context.TODO()
@@ -30,6 +31,7 @@ func BenchmarkCFEGetString(b *testing.B) {
 }
 
 func BenchmarkCFEGetInt64(b *testing.B) {
+	requireCFE(b)
 	b.SetParallelism(*maxParallelism)
 	b.RunParallel(func(pb *testing.PB) {
 		ctx := context.TODO()
@@ -44,8 +46,33 @@ func BenchmarkCFEGetInt64(b *testing.B) {
 	})
 }
 
+func BenchmarkCFEMGetString(b *testing.B) {
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = "teststrkey"
+	}
+	requireCFE(b)
+	b.SetParallelism(*maxParallelism)
+	b.RunParallel(func(pb *testing.PB) {
+		ctx := context.TODO()
+		for pb.Next() {
+			v, err := c.MGetString(ctx, keys...)
+			if err != nil || v["teststrkey"] != "some value" {
+				fmt.Printf("%v, %v\n", v, err)
+				b.Fail()
+			}
+		}
+
+	})
+}
+
+// TestMain sets up the benchmarks' keys in the CFE given with -cfe_server.
+// Without one only the unit tests run and the benchmarks are skipped.
 func TestMain(m *testing.M) {
 	flag.Parse()
+	if *cfeSpec == "" {
+		os.Exit(m.Run())
+	}
 	var err error
 	c, err = New(*cfeSpec, *rootCAPath)
 	if err != nil {
@@ -65,3 +92,9 @@ func TestMain(m *testing.M) {
 	}
 	os.Exit(m.Run())
 }
+
+func requireCFE(b *testing.B) {
+	if c == nil {
+		b.Skip("no -cfe_server given")
+	}
+}
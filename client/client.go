@@ -2,26 +2,139 @@ package client
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
 
 	pb "github.com/althk/ganache/cfe/proto"
+	"github.com/althk/ganache/utils/coalesce"
 	"github.com/althk/goeasy/grpcutils"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 type CacheClient interface {
 	Namespace(ns string)
-	SetString(ctx context.Context, k, v string) error
+	SetString(ctx context.Context, k, v string, opts ...SetOption) error
 	GetString(ctx context.Context, k string) (string, error)
-	SetInt64(ctx context.Context, k string, v int64) error
+	SetInt64(ctx context.Context, k string, v int64, opts ...SetOption) error
 	GetInt64(ctx context.Context, k string) (int64, error)
-	SetMessage(ctx context.Context, k string, msg proto.Message) error
+	SetMessage(ctx context.Context, k string, msg proto.Message, opts ...SetOption) error
 	GetMessage(ctx context.Context, k string, msg proto.Message) error
+	Delete(ctx context.Context, k string) error
+	// DeleteGlobal deletes k, set with Global, from every shard.
+	DeleteGlobal(ctx context.Context, k string) error
+	// GetMessageVersion reads k into msg and returns its version, to pass to
+	// CompareAndSwap.
+	GetMessageVersion(ctx context.Context, k string, msg proto.Message) (uint64, error)
+	// CompareAndSwap sets k to msg only if its version is still
+	// expectedVersion, 0 if k must not exist, and returns the new version.
+	// The error has code codes.Aborted if k was changed in the meantime.
+	CompareAndSwap(ctx context.Context, k string, expectedVersion uint64, msg proto.Message, opts ...SetOption) (uint64, error)
+	// GetOrLoad returns the value of k, loading and caching it on a miss.
+	GetOrLoad(ctx context.Context, k string, loader Loader, opts ...LoadOption) (proto.Message, error)
+	// Increment atomically adds delta to the int64 stored at k, as set by
+	// SetInt64, and returns the new value. A missing key starts at 0 unless
+	// WithInitialValue is given.
+	Increment(ctx context.Context, k string, delta int64, opts ...IncrementOption) (int64, error)
+	// Decrement is Increment with -delta.
+	Decrement(ctx context.Context, k string, delta int64, opts ...IncrementOption) (int64, error)
+	// Batch helpers send all keys in one request. Keys that are not found are
+	// left out of the returned map, other per-key failures are returned as a
+	// *BatchError along with the keys that succeeded. MGet does not use the
+	// local cache.
+	MGetString(ctx context.Context, keys ...string) (map[string]string, error)
+	MGetInt64(ctx context.Context, keys ...string) (map[string]int64, error)
+	MGetMessage(ctx context.Context, newMsg func() proto.Message, keys ...string) (map[string]proto.Message, error)
+	MSetString(ctx context.Context, kvs map[string]string, opts ...SetOption) error
+	MSetInt64(ctx context.Context, kvs map[string]int64, opts ...SetOption) error
+	MSetMessage(ctx context.Context, kvs map[string]proto.Message, opts ...SetOption) error
+	MDelete(ctx context.Context, keys ...string) error
 }
 
-func New(cfeSpec, caFilePath string) (CacheClient, error) {
+// BatchError holds the keys of a batch request that failed, along with
+// their error.
+type BatchError struct {
+	Errors map[string]error
+}
+
+func (e *BatchError) Error() string {
+	keys := make([]string, 0, len(e.Errors))
+	for k := range e.Errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return fmt.Sprintf("%d keys failed, first %v: %v", len(keys), keys[0], e.Errors[keys[0]])
+}
+
+var (
+	// ErrAlreadyExists is returned by sets with IfAbsent if the key exists.
+	ErrAlreadyExists = errors.New("key already exists")
+	// ErrNotFound is returned by sets with IfPresent if the key does not
+	// exist.
+	ErrNotFound = errors.New("key not found")
+)
+
+// SetOption customizes a single set request.
+type SetOption func(*pb.SetRequest)
+
+// WithTTL makes the key expire after ttl.
+func WithTTL(ttl time.Duration) SetOption {
+	return func(r *pb.SetRequest) {
+		r.Ttl = durationpb.New(ttl)
+	}
+}
+
+// IfAbsent only sets the key if it does not exist, the set fails with
+// ErrAlreadyExists otherwise.
+func IfAbsent() SetOption {
+	return func(r *pb.SetRequest) {
+		r.Mode = pb.WriteMode_IF_ABSENT
+	}
+}
+
+// IfPresent only sets the key if it exists, the set fails with ErrNotFound
+// otherwise.
+func IfPresent() SetOption {
+	return func(r *pb.SetRequest) {
+		r.Mode = pb.WriteMode_IF_PRESENT
+	}
+}
+
+// Global writes the key to every shard, so that every shard serves it
+// locally. A global key can only be changed by another global set and
+// removed by DeleteGlobal, and does not support IfAbsent and IfPresent.
+func Global() SetOption {
+	return func(r *pb.SetRequest) {
+		r.Global = true
+	}
+}
+
+// IncrementOption customizes a single increment request.
+type IncrementOption func(*pb.IncrementRequest)
+
+// WithInitialValue sets the value a missing key starts from.
+func WithInitialValue(v int64) IncrementOption {
+	return func(r *pb.IncrementRequest) {
+		r.Initial = v
+	}
+}
+
+// WithCounterTTL makes a key created by the increment expire after ttl, it
+// does not change the expiry of an existing key.
+func WithCounterTTL(ttl time.Duration) IncrementOption {
+	return func(r *pb.IncrementRequest) {
+		r.Ttl = durationpb.New(ttl)
+	}
+}
+
+func New(cfeSpec, caFilePath string, opts ...Option) (CacheClient, error) {
 	tlsCfg := &grpcutils.TLSConfig{
 		RootCAFilePath: caFilePath,
 		NoClientCert:   true,
@@ -30,31 +143,40 @@ func New(cfeSpec, caFilePath string) (CacheClient, error) {
 		TLSConfig:       tlsCfg,
 		KeepAliveConfig: &grpcutils.KeepAliveConfig{},
 	}
-	opts, err := grpcCfg.GetGRPCDialOpts()
+	dialOpts, err := grpcCfg.GetGRPCDialOpts()
 	if err != nil {
 		return nil, err
 	}
-	conn, err := grpc.Dial(cfeSpec, opts...)
+	conn, err := grpc.Dial(cfeSpec, dialOpts...)
 	if err != nil {
 		return nil, err
 	}
-	cli := pb.NewCFEClient(conn)
-	return &client{
-		cfe: cli,
-	}, err
+	c := &client{
+		cfe: pb.NewCFEClient(conn),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.local != nil {
+		go c.local.watch(context.Background(), c.cfe)
+	}
+	return c, err
 }
 
 // client implements CacheClient
 type client struct {
-	ns  string
-	cfe pb.CFEClient
+	ns       string
+	cfe      pb.CFEClient
+	loads    coalesce.Group // GetOrLoad calls in flight
+	negative negativeCache
+	local    *localCache // nil unless WithLocalCache
 }
 
 func (c *client) Namespace(ns string) {
 	c.ns = ns
 }
 
-func (c *client) set(ctx context.Context, k string, v proto.Message) error {
+func (c *client) set(ctx context.Context, k string, v proto.Message, opts []SetOption) error {
 	d, err := anypb.New(v)
 	if err != nil {
 		return err
@@ -65,48 +187,275 @@ func (c *client) set(ctx context.Context, k string, v proto.Message) error {
 		Data:      &anypb.Any{},
 	}
 	proto.Merge(req.Data, d)
+	for _, opt := range opts {
+		opt(req)
+	}
 	_, err = c.cfe.Set(ctx, req)
-	return err
+	c.local.invalidate(c.ns + k)
+	return setError(err)
 }
 
-func (c *client) get(ctx context.Context, k string, v proto.Message) error {
-	req := &pb.GetRequest{
-		Namespace: c.ns,
-		Key:       k,
+// setError maps the errors of a set's write mode to ErrAlreadyExists and
+// ErrNotFound.
+func setError(err error) error {
+	switch status.Code(err) {
+	case codes.AlreadyExists:
+		return ErrAlreadyExists
+	case codes.NotFound:
+		return ErrNotFound
+	default:
+		return err
 	}
-	resp, err := c.cfe.Get(ctx, req)
+}
+
+func (c *client) get(ctx context.Context, k string, v proto.Message) (uint64, error) {
+	resp, err := c.getResponse(ctx, k)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	err = resp.Data.UnmarshalTo(v)
-	return err
+	return resp.Version, err
 }
 
-func (c *client) SetString(ctx context.Context, k string, v string) error {
-	return c.set(ctx, k, wrapperspb.String(v))
+func (c *client) SetString(ctx context.Context, k string, v string, opts ...SetOption) error {
+	return c.set(ctx, k, wrapperspb.String(v), opts)
 }
 
 func (c *client) GetString(ctx context.Context, k string) (string, error) {
 	v := &wrapperspb.StringValue{}
-	err := c.get(ctx, k, v)
+	_, err := c.get(ctx, k, v)
 	return v.Value, err
 }
 
-func (c *client) SetInt64(ctx context.Context, k string, v int64) error {
-	return c.set(ctx, k, wrapperspb.Int64(v))
+func (c *client) SetInt64(ctx context.Context, k string, v int64, opts ...SetOption) error {
+	return c.set(ctx, k, wrapperspb.Int64(v), opts)
 }
 
 func (c *client) GetInt64(ctx context.Context, k string) (int64, error) {
 	v := &wrapperspb.Int64Value{}
-	err := c.get(ctx, k, v)
+	_, err := c.get(ctx, k, v)
 	return v.Value, err
 }
 
-func (c *client) SetMessage(ctx context.Context, k string, msg proto.Message) error {
-	return c.set(ctx, k, msg)
+func (c *client) SetMessage(ctx context.Context, k string, msg proto.Message, opts ...SetOption) error {
+	return c.set(ctx, k, msg, opts)
 }
 
 func (c *client) GetMessage(ctx context.Context, k string, msg proto.Message) error {
-	err := c.get(ctx, k, msg)
+	_, err := c.get(ctx, k, msg)
+	return err
+}
+
+func (c *client) Delete(ctx context.Context, k string) error {
+	return c.delete(ctx, k, false)
+}
+
+func (c *client) DeleteGlobal(ctx context.Context, k string) error {
+	return c.delete(ctx, k, true)
+}
+
+func (c *client) delete(ctx context.Context, k string, global bool) error {
+	_, err := c.cfe.Delete(ctx, &pb.DeleteRequest{
+		Namespace: c.ns,
+		Key:       k,
+		Global:    global,
+	})
+	c.local.invalidate(c.ns + k)
 	return err
 }
+
+func (c *client) GetMessageVersion(ctx context.Context, k string, msg proto.Message) (uint64, error) {
+	return c.get(ctx, k, msg)
+}
+
+func (c *client) CompareAndSwap(ctx context.Context, k string, expectedVersion uint64, msg proto.Message, opts ...SetOption) (uint64, error) {
+	d, err := anypb.New(msg)
+	if err != nil {
+		return 0, err
+	}
+	// options are written for SetRequest, the ones that apply are copied
+	sr := &pb.SetRequest{}
+	for _, opt := range opts {
+		opt(sr)
+	}
+	resp, err := c.cfe.CompareAndSet(ctx, &pb.CompareAndSetRequest{
+		Namespace:       c.ns,
+		Key:             k,
+		Data:            d,
+		Ttl:             sr.Ttl,
+		ExpectedVersion: expectedVersion,
+	})
+	c.local.invalidate(c.ns + k)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Version, nil
+}
+
+func (c *client) Increment(ctx context.Context, k string, delta int64, opts ...IncrementOption) (int64, error) {
+	req := &pb.IncrementRequest{
+		Namespace: c.ns,
+		Key:       k,
+		Delta:     delta,
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	resp, err := c.cfe.Increment(ctx, req)
+	c.local.invalidate(c.ns + k)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Value, nil
+}
+
+func (c *client) Decrement(ctx context.Context, k string, delta int64, opts ...IncrementOption) (int64, error) {
+	return c.Increment(ctx, k, -delta, opts...)
+}
+
+// mget returns the values of keys found by a single MGet, decoded with
+// newMsg.
+func (c *client) mget(ctx context.Context, keys []string, newMsg func() proto.Message) (map[string]proto.Message, error) {
+	req := &pb.MGetRequest{Keys: make([]*pb.GetRequest, len(keys))}
+	for i, k := range keys {
+		req.Keys[i] = &pb.GetRequest{
+			Namespace: c.ns,
+			Key:       k,
+		}
+	}
+	resp, err := c.cfe.MGet(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	vals := make(map[string]proto.Message, len(keys))
+	errs := make(map[string]error)
+	for i, r := range resp.Results {
+		code := codes.Code(r.Status.GetCode())
+		switch code {
+		case codes.OK:
+			v := newMsg()
+			if err := r.Data.UnmarshalTo(v); err != nil {
+				errs[keys[i]] = err
+				continue
+			}
+			vals[keys[i]] = v
+		case codes.NotFound:
+		default:
+			errs[keys[i]] = status.Error(code, r.Status.GetMessage())
+		}
+	}
+	return vals, batchError(errs)
+}
+
+// mset sets every item in a single MSet.
+func (c *client) mset(ctx context.Context, kvs map[string]proto.Message, opts []SetOption) error {
+	req := &pb.MSetRequest{Items: make([]*pb.SetRequest, 0, len(kvs))}
+	for k, v := range kvs {
+		d, err := anypb.New(v)
+		if err != nil {
+			return err
+		}
+		it := &pb.SetRequest{
+			Namespace: c.ns,
+			Key:       k,
+			Data:      d,
+		}
+		for _, opt := range opts {
+			opt(it)
+		}
+		req.Items = append(req.Items, it)
+	}
+	resp, err := c.cfe.MSet(ctx, req)
+	for k := range kvs {
+		c.local.invalidate(c.ns + k)
+	}
+	if err != nil {
+		return err
+	}
+	errs := make(map[string]error)
+	for i, st := range resp.Statuses {
+		if st.GetCode() != int32(codes.OK) {
+			errs[req.Items[i].Key] = setError(status.Error(codes.Code(st.Code), st.Message))
+		}
+	}
+	return batchError(errs)
+}
+
+func batchError(errs map[string]error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &BatchError{Errors: errs}
+}
+
+func (c *client) MGetString(ctx context.Context, keys ...string) (map[string]string, error) {
+	vals, err := c.mget(ctx, keys, func() proto.Message { return &wrapperspb.StringValue{} })
+	if vals == nil {
+		return nil, err
+	}
+	m := make(map[string]string, len(vals))
+	for k, v := range vals {
+		m[k] = v.(*wrapperspb.StringValue).Value
+	}
+	return m, err
+}
+
+func (c *client) MGetInt64(ctx context.Context, keys ...string) (map[string]int64, error) {
+	vals, err := c.mget(ctx, keys, func() proto.Message { return &wrapperspb.Int64Value{} })
+	if vals == nil {
+		return nil, err
+	}
+	m := make(map[string]int64, len(vals))
+	for k, v := range vals {
+		m[k] = v.(*wrapperspb.Int64Value).Value
+	}
+	return m, err
+}
+
+func (c *client) MGetMessage(ctx context.Context, newMsg func() proto.Message, keys ...string) (map[string]proto.Message, error) {
+	return c.mget(ctx, keys, newMsg)
+}
+
+func (c *client) MSetString(ctx context.Context, kvs map[string]string, opts ...SetOption) error {
+	m := make(map[string]proto.Message, len(kvs))
+	for k, v := range kvs {
+		m[k] = wrapperspb.String(v)
+	}
+	return c.mset(ctx, m, opts)
+}
+
+func (c *client) MSetInt64(ctx context.Context, kvs map[string]int64, opts ...SetOption) error {
+	m := make(map[string]proto.Message, len(kvs))
+	for k, v := range kvs {
+		m[k] = wrapperspb.Int64(v)
+	}
+	return c.mset(ctx, m, opts)
+}
+
+func (c *client) MSetMessage(ctx context.Context, kvs map[string]proto.Message, opts ...SetOption) error {
+	return c.mset(ctx, kvs, opts)
+}
+
+func (c *client) MDelete(ctx context.Context, keys ...string) error {
+	req := &pb.MDeleteRequest{Keys: make([]*pb.DeleteRequest, len(keys))}
+	for i, k := range keys {
+		req.Keys[i] = &pb.DeleteRequest{
+			Namespace: c.ns,
+			Key:       k,
+		}
+	}
+	resp, err := c.cfe.MDelete(ctx, req)
+	for _, k := range keys {
+		c.local.invalidate(c.ns + k)
+	}
+	if err != nil {
+		return err
+	}
+	errs := make(map[string]error)
+	for i, st := range resp.Statuses {
+		if st.GetCode() != int32(codes.OK) {
+			errs[keys[i]] = status.Error(codes.Code(st.Code), st.Message)
+		}
+	}
+	return batchError(errs)
+}
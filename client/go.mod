@@ -9,11 +9,19 @@ replace github.com/althk/ganache/cfe => ../cfe
 require (
 	github.com/althk/ganache/cfe v0.0.0-00010101000000-000000000000
 	github.com/althk/goeasy/grpcutils v0.0.0-20220712184942-d7de7754eb7f
+	github.com/stretchr/testify v1.8.0
 	google.golang.org/grpc v1.47.0
 	google.golang.org/protobuf v1.28.0
 )
 
 require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+require (
+	github.com/althk/ganache/utils v0.0.0-20220706175043-8ffe22299080
 	github.com/cenkalti/backoff/v4 v4.1.3 // indirect
 	github.com/go-logr/logr v1.2.3 // indirect
 	github.com/go-logr/stdr v1.2.2 // indirect
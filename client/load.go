@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxNegativeEntries bounds the loader errors remembered for WithNegativeTTL,
+// expired ones are dropped once there are more.
+const maxNegativeEntries = 10000
+
+// Loader loads the value of a key that is not cached, e.g. from a database.
+type Loader func(ctx context.Context) (proto.Message, error)
+
+// LoadOption customizes a single GetOrLoad call.
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	ttl         time.Duration
+	negativeTTL time.Duration
+}
+
+// WithLoadTTL makes a loaded value expire from the cache after ttl.
+func WithLoadTTL(ttl time.Duration) LoadOption {
+	return func(o *loadOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithNegativeTTL makes GetOrLoad return a loader's error for ttl without
+// calling the loader again. Errors are only remembered in this process.
+func WithNegativeTTL(ttl time.Duration) LoadOption {
+	return func(o *loadOptions) {
+		o.negativeTTL = ttl
+	}
+}
+
+// negativeCache remembers loader errors by key.
+type negativeCache struct {
+	l       sync.Mutex
+	entries map[string]negativeEntry
+}
+
+type negativeEntry struct {
+	err     error
+	expires time.Time
+}
+
+func (n *negativeCache) get(k string, now time.Time) error {
+	n.l.Lock()
+	defer n.l.Unlock()
+	e, ok := n.entries[k]
+	if !ok || !now.Before(e.expires) {
+		return nil
+	}
+	return e.err
+}
+
+func (n *negativeCache) put(k string, err error, expires time.Time) {
+	n.l.Lock()
+	defer n.l.Unlock()
+	if n.entries == nil {
+		n.entries = make(map[string]negativeEntry)
+	}
+	if len(n.entries) >= maxNegativeEntries {
+		now := time.Now()
+		for k, e := range n.entries {
+			if !now.Before(e.expires) {
+				delete(n.entries, k)
+			}
+		}
+	}
+	if len(n.entries) < maxNegativeEntries {
+		n.entries[k] = negativeEntry{err: err, expires: expires}
+	}
+}
+
+// GetOrLoad returns the cached value of k. On a miss it calls loader, caches
+// what it returns and returns it. Concurrent calls for the same key in this
+// process share a single Get and loader call, so a hot key that expires
+// causes one load instead of one per caller. The value's type must be
+// linked into the program, which it is if the loader returns it.
+//
+// A loaded value is returned even if caching it fails, cache errors other
+// than a miss are returned without calling loader.
+func (c *client) GetOrLoad(ctx context.Context, k string, loader Loader, opts ...LoadOption) (proto.Message, error) {
+	o := &loadOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	key := c.ns + k
+	if err := c.negative.get(key, time.Now()); err != nil {
+		return nil, err
+	}
+	v, err, _ := c.loads.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return c.load(ctx, k, loader, o)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// callers may modify their copy
+	return proto.Clone(v.(proto.Message)), nil
+}
+
+func (c *client) load(ctx context.Context, k string, loader Loader, o *loadOptions) (proto.Message, error) {
+	resp, err := c.getResponse(ctx, k)
+	if err == nil {
+		return resp.Data.UnmarshalNew()
+	}
+	if status.Code(err) != codes.NotFound {
+		return nil, err
+	}
+	v, err := loader(ctx)
+	if err != nil {
+		if o.negativeTTL > 0 {
+			c.negative.put(c.ns+k, err, time.Now().Add(o.negativeTTL))
+		}
+		return nil, err
+	}
+	var setOpts []SetOption
+	if o.ttl > 0 {
+		setOpts = append(setOpts, WithTTL(o.ttl))
+	}
+	c.set(ctx, k, v, setOpts)
+	return v, nil
+}
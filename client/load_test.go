@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/althk/ganache/cfe/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	cfe := &fakeCFE{}
+	cl := &client{ns: "ns", cfe: cfe}
+	ctx := context.Background()
+	var loads int32
+	loader := func(context.Context) (proto.Message, error) {
+		atomic.AddInt32(&loads, 1)
+		return wrapperspb.String("loaded"), nil
+	}
+
+	v, err := cl.GetOrLoad(ctx, "k1", loader, WithLoadTTL(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, "loaded", v.(*wrapperspb.StringValue).Value)
+	require.EqualValues(t, 1, loads)
+	require.Len(t, cfe.sets, 1)
+	require.Equal(t, time.Minute, cfe.sets[0].Ttl.AsDuration())
+
+	// cached now, the loader is not called again
+	v, err = cl.GetOrLoad(ctx, "k1", loader)
+	require.NoError(t, err)
+	require.Equal(t, "loaded", v.(*wrapperspb.StringValue).Value)
+	require.EqualValues(t, 1, loads)
+
+	// other cache errors are returned as they are
+	cfe.getErr = status.Error(codes.Unavailable, "down")
+	_, err = cl.GetOrLoad(ctx, "k2", loader)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.EqualValues(t, 1, loads)
+}
+
+func TestGetOrLoadCoalesces(t *testing.T) {
+	cfe := &fakeCFE{}
+	cl := &client{ns: "ns", cfe: cfe}
+	ctx := context.Background()
+	var loads int32
+	gate := make(chan struct{})
+	loader := func(context.Context) (proto.Message, error) {
+		atomic.AddInt32(&loads, 1)
+		<-gate
+		return wrapperspb.String("loaded"), nil
+	}
+
+	var wg sync.WaitGroup
+	vals := make([]proto.Message, 10)
+	for i := range vals {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := cl.GetOrLoad(ctx, "k1", loader)
+			if err != nil {
+				t.Error(err)
+			}
+			vals[i] = v
+		}(i)
+	}
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&loads) == 1
+	}, time.Second, time.Millisecond)
+	close(gate)
+	wg.Wait()
+	require.EqualValues(t, 1, loads)
+	require.EqualValues(t, 1, cfe.getCount())
+	for _, v := range vals {
+		require.Equal(t, "loaded", v.(*wrapperspb.StringValue).Value)
+	}
+	// every caller gets its own copy
+	vals[0].(*wrapperspb.StringValue).Value = "changed"
+	require.Equal(t, "loaded", vals[1].(*wrapperspb.StringValue).Value)
+}
+
+func TestGetOrLoadNegativeTTL(t *testing.T) {
+	cfe := &fakeCFE{}
+	cl := &client{ns: "ns", cfe: cfe}
+	ctx := context.Background()
+	errNope := errors.New("nope")
+	var loads int32
+	loader := func(context.Context) (proto.Message, error) {
+		atomic.AddInt32(&loads, 1)
+		return nil, errNope
+	}
+
+	_, err := cl.GetOrLoad(ctx, "k1", loader)
+	require.ErrorIs(t, err, errNope)
+	_, err = cl.GetOrLoad(ctx, "k1", loader, WithNegativeTTL(50*time.Millisecond))
+	require.ErrorIs(t, err, errNope)
+	require.EqualValues(t, 2, loads) // without a negative ttl nothing is remembered
+
+	// remembered until the negative ttl passes, also without asking CFE
+	gets := cfe.getCount()
+	_, err = cl.GetOrLoad(ctx, "k1", loader)
+	require.ErrorIs(t, err, errNope)
+	require.EqualValues(t, 2, loads)
+	require.Equal(t, gets, cfe.getCount())
+	_, err = cl.GetOrLoad(ctx, "k2", loader)
+	require.ErrorIs(t, err, errNope)
+	require.EqualValues(t, 3, loads) // only for that key
+
+	time.Sleep(50 * time.Millisecond)
+	_, err = cl.GetOrLoad(ctx, "k1", loader)
+	require.ErrorIs(t, err, errNope)
+	require.EqualValues(t, 4, loads)
+}
+
+func TestNegativeCache(t *testing.T) {
+	var n negativeCache
+	now := time.Now()
+	errNope := errors.New("nope")
+	require.NoError(t, n.get("k1", now))
+	n.put("k1", errNope, now.Add(time.Second))
+	require.ErrorIs(t, n.get("k1", now), errNope)
+	require.NoError(t, n.get("k1", now.Add(time.Second)))
+
+	// once full, expired entries make room and live ones are kept
+	for i := 0; i < maxNegativeEntries; i++ {
+		n.put(fmt.Sprintf("old%d", i), errNope, now.Add(-time.Second))
+	}
+	n.put("k2", errNope, now.Add(time.Minute))
+	require.ErrorIs(t, n.get("k1", now), errNope)
+	require.ErrorIs(t, n.get("k2", now), errNope)
+	require.Less(t, len(n.entries), 10)
+
+	// and nothing is added while the live ones fill it
+	for i := 0; i < maxNegativeEntries; i++ {
+		n.put(fmt.Sprintf("live%d", i), errNope, now.Add(time.Minute))
+	}
+	require.Len(t, n.entries, maxNegativeEntries)
+	require.ErrorIs(t, n.get("k2", now), errNope)
+}
+
+// fakeCFE serves Get and Set from a map and streams the invalidations sent
+// on events to WatchInvalidations.
+type fakeCFE struct {
+	pb.CFEClient
+
+	l      sync.Mutex
+	data   map[string]*pb.GetResponse // by namespace and key concatenated
+	sets   []*pb.SetRequest
+	gets   int
+	getErr error
+	// getHook, if set, is called by Get before it reads the key
+	getHook func(in *pb.GetRequest)
+	// tracking is the tracking id the streams start with, empty to fail
+	// WatchInvalidations with FailedPrecondition
+	tracking string
+	events   chan *pb.InvalidationEvent
+	watches  int // WatchInvalidations calls
+}
+
+func (f *fakeCFE) Get(_ context.Context, in *pb.GetRequest, _ ...grpc.CallOption) (*pb.GetResponse, error) {
+	if f.getHook != nil {
+		f.getHook(in)
+	}
+	f.l.Lock()
+	defer f.l.Unlock()
+	f.gets++
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	r, ok := f.data[in.Namespace+in.Key]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	return &pb.GetResponse{
+		Data:    r.Data,
+		Version: r.Version,
+		Tracked: in.TrackingId != "" && in.TrackingId == f.tracking,
+	}, nil
+}
+
+func (f *fakeCFE) Set(_ context.Context, in *pb.SetRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	f.l.Lock()
+	defer f.l.Unlock()
+	if f.data == nil {
+		f.data = make(map[string]*pb.GetResponse)
+	}
+	f.sets = append(f.sets, in)
+	r := f.data[in.Namespace+in.Key]
+	f.data[in.Namespace+in.Key] = &pb.GetResponse{Data: in.Data, Version: r.GetVersion() + 1}
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeCFE) getCount() int {
+	f.l.Lock()
+	defer f.l.Unlock()
+	return f.gets
+}
+
+func (f *fakeCFE) WatchInvalidations(ctx context.Context, _ *pb.WatchInvalidationsRequest, _ ...grpc.CallOption) (pb.CFE_WatchInvalidationsClient, error) {
+	f.l.Lock()
+	defer f.l.Unlock()
+	f.watches++
+	if f.tracking == "" {
+		return nil, status.Error(codes.FailedPrecondition, "client tracking is disabled")
+	}
+	return &fakeStream{ctx: ctx, id: f.tracking, events: f.events}, nil
+}
+
+// fakeStream sends the tracking id first and then the events, it breaks
+// when the events channel is closed.
+type fakeStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	id     string
+	sentID bool
+	events chan *pb.InvalidationEvent
+}
+
+func (s *fakeStream) Recv() (*pb.InvalidationEvent, error) {
+	if !s.sentID {
+		s.sentID = true
+		return &pb.InvalidationEvent{TrackingId: s.id}, nil
+	}
+	select {
+	case <-s.ctx.Done():
+		return nil, status.FromContextError(s.ctx.Err()).Err()
+	case ev, ok := <-s.events:
+		if !ok {
+			return nil, status.Error(codes.Unavailable, "stream broke")
+		}
+		return ev, nil
+	}
+}
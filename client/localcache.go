@@ -0,0 +1,226 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	pb "github.com/althk/ganache/cfe/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// watchRetryWait is how long the local cache waits before watching
+// invalidations again after the stream broke.
+const watchRetryWait = time.Second
+
+// Option customizes a client created with New.
+type Option func(*client)
+
+// WithLocalCache keeps up to maxEntries values read with the Get helpers in
+// process, each for at most ttl. CFE reports keys that change after they
+// were read, which drops them, so this needs CFEs run with
+// -client_tracking. Until the invalidation stream is up, e.g. while CFE is
+// unreachable, nothing is cached.
+func WithLocalCache(maxEntries int, ttl time.Duration) Option {
+	return func(c *client) {
+		c.local = newLocalCache(maxEntries, ttl)
+	}
+}
+
+// localCache is an LRU of Get responses by cache key, namespace and key
+// concatenated, front is most recently used.
+type localCache struct {
+	maxEntries int
+	ttl        time.Duration
+
+	l          sync.Mutex
+	trackingID string // empty while not watching invalidations
+	ll         *list.List
+	entries    map[string]*list.Element
+	fills      map[string]*localFill // Gets in flight, by cache key
+}
+
+type localEntry struct {
+	key     string
+	resp    *pb.GetResponse
+	expires time.Time
+}
+
+// localFill tracks the Gets of a key that may add it to the cache, they do
+// not if the key is invalidated before they are done.
+type localFill struct {
+	key     string
+	refs    int
+	invalid bool
+}
+
+func newLocalCache(maxEntries int, ttl time.Duration) *localCache {
+	return &localCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		ll:         list.New(),
+		entries:    make(map[string]*list.Element),
+		fills:      make(map[string]*localFill),
+	}
+}
+
+func (lc *localCache) get(k string, now time.Time) *pb.GetResponse {
+	if lc == nil {
+		return nil
+	}
+	lc.l.Lock()
+	defer lc.l.Unlock()
+	el, ok := lc.entries[k]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*localEntry)
+	if !now.Before(e.expires) {
+		lc.remove(el)
+		return nil
+	}
+	lc.ll.MoveToFront(el)
+	return e.resp
+}
+
+// begin is called before a Get of k and returns the tracking id to send
+// with it, the fill is nil if nothing can be cached.
+func (lc *localCache) begin(k string) (*localFill, string) {
+	if lc == nil {
+		return nil, ""
+	}
+	lc.l.Lock()
+	defer lc.l.Unlock()
+	if lc.trackingID == "" {
+		return nil, ""
+	}
+	f, ok := lc.fills[k]
+	if !ok || f.invalid {
+		f = &localFill{key: k}
+		lc.fills[k] = f
+	}
+	f.refs++
+	return f, lc.trackingID
+}
+
+// finish is called with the result of the Get started with begin, resp is
+// cached if CFE tracks its key and it was not invalidated since.
+func (lc *localCache) finish(f *localFill, resp *pb.GetResponse, now time.Time) {
+	if f == nil {
+		return
+	}
+	lc.l.Lock()
+	defer lc.l.Unlock()
+	f.refs--
+	if f.refs == 0 && lc.fills[f.key] == f {
+		delete(lc.fills, f.key)
+	}
+	if !resp.GetTracked() || f.invalid {
+		return
+	}
+	if el, ok := lc.entries[f.key]; ok {
+		lc.remove(el)
+	}
+	lc.entries[f.key] = lc.ll.PushFront(&localEntry{
+		key:     f.key,
+		resp:    resp,
+		expires: now.Add(lc.ttl),
+	})
+	for lc.ll.Len() > lc.maxEntries {
+		lc.remove(lc.ll.Back())
+	}
+}
+
+func (lc *localCache) invalidate(k string) {
+	if lc == nil {
+		return
+	}
+	lc.l.Lock()
+	defer lc.l.Unlock()
+	if el, ok := lc.entries[k]; ok {
+		lc.remove(el)
+	}
+	if f, ok := lc.fills[k]; ok {
+		f.invalid = true
+	}
+}
+
+// reset drops everything and switches to tracking id, empty to stop
+// caching.
+func (lc *localCache) reset(id string) {
+	lc.l.Lock()
+	defer lc.l.Unlock()
+	lc.trackingID = id
+	lc.ll.Init()
+	lc.entries = make(map[string]*list.Element)
+	for _, f := range lc.fills {
+		f.invalid = true
+	}
+}
+
+// remove drops el, lc.l must be held.
+func (lc *localCache) remove(el *list.Element) {
+	lc.ll.Remove(el)
+	delete(lc.entries, el.Value.(*localEntry).key)
+}
+
+// watch applies the invalidations streamed by CFE until ctx is done,
+// opening the stream again whenever it breaks. It gives up if CFE does not
+// track clients.
+func (lc *localCache) watch(ctx context.Context, cfe pb.CFEClient) {
+	for ctx.Err() == nil {
+		err := lc.watchOnce(ctx, cfe)
+		lc.reset("")
+		switch status.Code(err) {
+		case codes.FailedPrecondition, codes.Unimplemented:
+			return
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(watchRetryWait):
+		}
+	}
+}
+
+func (lc *localCache) watchOnce(ctx context.Context, cfe pb.CFEClient) error {
+	stream, err := cfe.WatchInvalidations(ctx, &pb.WatchInvalidationsRequest{})
+	if err != nil {
+		return err
+	}
+	ev, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	id := ev.TrackingId
+	lc.reset(id)
+	for {
+		ev, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if ev.Flush {
+			lc.reset(id)
+		}
+		for _, k := range ev.Keys {
+			lc.invalidate(k)
+		}
+	}
+}
+
+// getResponse reads k from the local cache, if enabled, or from CFE.
+func (c *client) getResponse(ctx context.Context, k string) (*pb.GetResponse, error) {
+	key := c.ns + k
+	if r := c.local.get(key, time.Now()); r != nil {
+		return r, nil
+	}
+	f, id := c.local.begin(key)
+	resp, err := c.cfe.Get(ctx, &pb.GetRequest{
+		Namespace:  c.ns,
+		Key:        k,
+		TrackingId: id,
+	})
+	c.local.finish(f, resp, time.Now())
+	return resp, err
+}
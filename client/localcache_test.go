@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/althk/ganache/cfe/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestLocalCache(t *testing.T) {
+	cfe := trackingCFE("k1", "k2")
+	cl := localClient(t, cfe, 10)
+	ctx := context.Background()
+
+	v, err := cl.GetString(ctx, "k1")
+	require.NoError(t, err)
+	require.Equal(t, "v1", v)
+	v, err = cl.GetString(ctx, "k1")
+	require.NoError(t, err)
+	require.Equal(t, "v1", v)
+	require.Equal(t, 1, cfe.getCount())
+
+	// a write through this client drops the key right away
+	require.NoError(t, cl.SetString(ctx, "k1", "v2"))
+	v, err = cl.GetString(ctx, "k1")
+	require.NoError(t, err)
+	require.Equal(t, "v2", v)
+	require.Equal(t, 2, cfe.getCount())
+
+	// so does an invalidation from CFE
+	cfe.events <- &pb.InvalidationEvent{Keys: []string{"nsk1"}}
+	require.Eventually(t, func() bool {
+		return !cached(cl, "nsk1")
+	}, time.Second, time.Millisecond)
+
+	// and a flush drops everything
+	cl.GetString(ctx, "k1")
+	cl.GetString(ctx, "k2")
+	require.True(t, cached(cl, "nsk1"))
+	require.True(t, cached(cl, "nsk2"))
+	cfe.events <- &pb.InvalidationEvent{Flush: true}
+	require.Eventually(t, func() bool {
+		return !cached(cl, "nsk1") && !cached(cl, "nsk2")
+	}, time.Second, time.Millisecond)
+}
+
+func TestLocalCacheInvalidatedDuringFill(t *testing.T) {
+	cfe := trackingCFE("k1")
+	cl := localClient(t, cfe, 10)
+	ctx := context.Background()
+
+	// the key changes while its Get is in flight, the response read before
+	// the change must not be cached
+	cfe.getHook = func(*pb.GetRequest) {
+		cfe.events <- &pb.InvalidationEvent{Keys: []string{"nsk1"}}
+		require.Eventually(t, func() bool {
+			cl.local.l.Lock()
+			defer cl.local.l.Unlock()
+			f, ok := cl.local.fills["nsk1"]
+			return ok && f.invalid
+		}, time.Second, time.Millisecond)
+	}
+	v, err := cl.GetString(ctx, "k1")
+	require.NoError(t, err)
+	require.Equal(t, "v1", v)
+	require.False(t, cached(cl, "nsk1"))
+
+	// the next Get starts a new fill
+	cfe.getHook = nil
+	cl.GetString(ctx, "k1")
+	require.True(t, cached(cl, "nsk1"))
+	require.Empty(t, cl.local.fills)
+}
+
+func TestLocalCacheReconnect(t *testing.T) {
+	cfe := trackingCFE("k1")
+	cl := localClient(t, cfe, 10)
+	ctx := context.Background()
+	cl.GetString(ctx, "k1")
+	require.True(t, cached(cl, "nsk1"))
+
+	// invalidations may be missed while the stream is down, so nothing is
+	// cached until it is back
+	cfe.l.Lock()
+	events := cfe.events
+	cfe.events = make(chan *pb.InvalidationEvent)
+	cfe.tracking = "id2"
+	cfe.l.Unlock()
+	close(events)
+	require.Eventually(t, func() bool {
+		return !cached(cl, "nsk1")
+	}, time.Second, time.Millisecond)
+	require.Equal(t, "", trackingID(cl))
+	cl.GetString(ctx, "k1")
+	require.False(t, cached(cl, "nsk1"))
+
+	require.Eventually(t, func() bool {
+		return trackingID(cl) == "id2"
+	}, 3*watchRetryWait, 10*time.Millisecond)
+	cl.GetString(ctx, "k1")
+	require.True(t, cached(cl, "nsk1"))
+}
+
+func TestLocalCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cfe := trackingCFE("k1", "k2", "k3")
+	cl := localClient(t, cfe, 2)
+	ctx := context.Background()
+
+	cl.GetString(ctx, "k1")
+	cl.GetString(ctx, "k2")
+	cl.GetString(ctx, "k1") // k2 is now the least recently used
+	cl.GetString(ctx, "k3")
+	require.True(t, cached(cl, "nsk1"))
+	require.False(t, cached(cl, "nsk2"))
+	require.True(t, cached(cl, "nsk3"))
+	require.Equal(t, 2, cl.local.ll.Len())
+
+	// entries also expire after the ttl
+	require.Nil(t, cl.local.get("nsk1", time.Now().Add(time.Minute)))
+	require.False(t, cached(cl, "nsk1"))
+}
+
+func TestLocalCacheWithoutTracking(t *testing.T) {
+	cfe := &fakeCFE{}
+	cfe.set("k1", wrapperspb.String("v1"))
+	cl := &client{ns: "ns", cfe: cfe, local: newLocalCache(10, time.Minute)}
+	// gives up on CFEs that do not track clients
+	cl.local.watch(context.Background(), cfe)
+	cl.GetString(context.Background(), "k1")
+	require.False(t, cached(cl, "nsk1"))
+	require.Equal(t, 1, cfe.watches)
+}
+
+// trackingCFE returns a fake CFE that tracks clients and has the keys, each
+// with value v<n> for key k<n>.
+func trackingCFE(keys ...string) *fakeCFE {
+	cfe := &fakeCFE{
+		tracking: "id1",
+		events:   make(chan *pb.InvalidationEvent),
+	}
+	for _, k := range keys {
+		cfe.set(k, wrapperspb.String("v"+k[1:]))
+	}
+	return cfe
+}
+
+// localClient returns a client of cfe with a local cache of maxEntries,
+// once it watches cfe's invalidations.
+func localClient(t *testing.T, cfe *fakeCFE, maxEntries int) *client {
+	cl := &client{ns: "ns", cfe: cfe, local: newLocalCache(maxEntries, time.Minute)}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go cl.local.watch(ctx, cfe)
+	require.Eventually(t, func() bool {
+		return trackingID(cl) != ""
+	}, time.Second, time.Millisecond)
+	return cl
+}
+
+func cached(cl *client, k string) bool {
+	cl.local.l.Lock()
+	defer cl.local.l.Unlock()
+	_, ok := cl.local.entries[k]
+	return ok
+}
+
+func trackingID(cl *client) string {
+	cl.local.l.Lock()
+	defer cl.local.l.Unlock()
+	return cl.local.trackingID
+}
+
+func (f *fakeCFE) set(k string, v *wrapperspb.StringValue) {
+	d, _ := anypb.New(v)
+	f.Set(context.Background(), &pb.SetRequest{Namespace: "ns", Key: k, Data: d})
+}
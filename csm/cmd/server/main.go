@@ -4,20 +4,35 @@ import (
 	"flag"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/althk/ganache/csm/internal/server"
 	pb "github.com/althk/ganache/csm/proto"
+	etcdutils "github.com/althk/ganache/utils/etcd"
+	"github.com/althk/ganache/utils/lifecycle"
+	"github.com/althk/ganache/utils/sharding"
 	"github.com/althk/goeasy/grpcutils"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 var port = flag.Int("port", 0, "cache server port, defaults to 0 which means any available port")
 var etcdSpec = flag.String("etcd_server", "localhost:2379", "address of etcd server in the form host:port")
 var csResolverPrefix = flag.String("cacheserver_resolver_prefix", "ganache/cacheserver", "key prefix for cache service resolver")
+var shardMapKey = flag.String("shard_map_key", etcdutils.ShardMapKey, "etcd key of the shard map")
+var shards = flag.Int("shards", 1, "number of shards in the initial shard map, ignored if a shard map exists")
+var shardPicker = flag.String("shard_picker", sharding.Ring, "how keys are mapped to shards, one of ring, rendezvous or mod")
+var vnodes = flag.Int("vnodes", sharding.DefaultVNodes, "virtual nodes per unit of shard weight on the hash ring")
+var shardWeights = flag.String("shard_weights", "", "comma separated shard=weight pairs for the initial shard map, shards not listed have weight 1")
+var migrationSettleDelay = flag.Duration("migration_settle_delay", 5*time.Second, "how long resharding waits for CFEs to pick up a new shard map before moving keys")
+var registrationTTL = flag.Duration("registration_ttl", 10*time.Second, "how long a cache server stays registered after it stops sending keep alives")
 var debug = flag.Bool("debug", false, "enable debug logging")
+var shutdownTimeout = flag.Duration("shutdown_timeout", 30*time.Second, "how long shutting down may take, RPCs still running after that are cancelled")
 var clientCAPath = flag.String("client_ca_file", "", "Path to CA cert file that can verify client certs")
+var rootCAPath = flag.String("root_ca_file", "", "Path to CA cert file that can verify cache server certs")
 var tlsCrtPath = flag.String("tls_cert_file", "", "Path to server's TLS cert file")
 var tlsKeyPath = flag.String("tls_key_file", "", "Path to server's TLS key file")
 var skipTLS = flag.Bool("skip_tls", false, "If server should skip TLS and use insecure creds")
@@ -35,9 +50,16 @@ func main() {
 		log.Fatal().Msgf("Error listening on port %v: %v", *port, err)
 	}
 
-	csmServer, err := server.New(*etcdSpec, *csResolverPrefix)
+	weights, err := sharding.ParseWeights(*shardWeights, *shards)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to create new CSM server.")
+		log.Fatal().Err(err).Msg("Invalid shard weights.")
+	}
+	initialMap := &pb.ShardMap{
+		Picker: *shardPicker,
+		Vnodes: int32(*vnodes),
+	}
+	for i := 0; i < *shards; i++ {
+		initialMap.Shards = append(initialMap.Shards, &pb.Shard{Id: int32(i), Weight: int32(weights[i])})
 	}
 	grpcCfg := &grpcutils.GRPCServerConfig{
 		TLSConfig: &grpcutils.TLSConfig{
@@ -45,8 +67,14 @@ func main() {
 			KeyFilePath:      *tlsKeyPath,
 			ClientCAFilePath: *clientCAPath,
 			SkipTLS:          *skipTLS,
+			RootCAFilePath:   *rootCAPath,
 		},
-		KeepAliveConfig: &grpcutils.KeepAliveConfig{}, // use defaults
+		KeepAliveConfig:  &grpcutils.KeepAliveConfig{}, // use defaults
+		SkipHealthServer: true,                         // registered below, drained on shutdown
+	}
+	csmServer, err := server.New(grpcCfg, *etcdSpec, *csResolverPrefix, *shardMapKey, initialMap, *migrationSettleDelay, *registrationTTL)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create new CSM server.")
 	}
 	s, err := grpcCfg.NewGRPCServer()
 	if err != nil {
@@ -56,6 +84,22 @@ func main() {
 	// register CSM server
 	pb.RegisterShardManagerServer(s, csmServer)
 
-	log.Info().Msgf("Running shard manager server on %v", lis.Addr().String())
-	s.Serve(lis)
+	hs := health.NewServer()
+	hs.SetServingStatus(pb.ShardManager_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, hs)
+
+	go func() {
+		log.Info().Msgf("Running shard manager server on %v", lis.Addr().String())
+		if err := s.Serve(lis); err != nil {
+			log.Fatal().Err(err).Msg("Shard manager server failed.")
+		}
+	}()
+
+	sig := lifecycle.WaitForSignal()
+	log.Info().Msgf("Received %v, shutting down", sig)
+	d := &lifecycle.Drainer{
+		Server: s,
+		Health: hs,
+	}
+	d.Shutdown(*shutdownTimeout)
 }
@@ -1,14 +1,55 @@
 package server
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	cspb "github.com/althk/ganache/cacheserver/proto"
 	"github.com/althk/ganache/csm/internal/service"
+	pb "github.com/althk/ganache/csm/proto"
 	etcdutils "github.com/althk/ganache/utils/etcd"
+	"github.com/althk/goeasy/grpcutils"
+	resolverv3 "go.etcd.io/etcd/client/v3/naming/resolver"
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/health" // enables client side health checking
 )
 
-func New(etcdSpec, resolverPrefix string) (*service.CSM, error) {
+// csServiceConfig makes key transfers skip cache servers that are not
+// SERVING, e.g. because they have not synced yet.
+var csServiceConfig = fmt.Sprintf(`{
+	"loadBalancingPolicy": "round_robin",
+	"healthCheckConfig": {"serviceName": %q}
+}`, cspb.Cache_ServiceDesc.ServiceName)
+
+// New returns a CSM server, publishing initialMap as the shard map if none
+// has been published yet.
+func New(grpcCfg *grpcutils.GRPCServerConfig, etcdSpec, resolverPrefix, shardMapKey string, initialMap *pb.ShardMap, settleDelay, registrationTTL time.Duration) (*service.CSM, error) {
 	etcdc, err := etcdutils.V3Client(etcdSpec)
 	if err != nil {
 		return nil, err
 	}
-	return service.NewCSM(etcdc, resolverPrefix)
+	r, err := resolverv3.NewBuilder(etcdc)
+	if err != nil {
+		return nil, err
+	}
+	s, err := service.NewCSM(etcdc, resolverPrefix, shardMapKey)
+	if err != nil {
+		return nil, err
+	}
+	s.MigrationSettleDelay = settleDelay
+	s.RegistrationTTL = registrationTTL
+	s.DialShard = func(shard int) (*grpc.ClientConn, error) {
+		ep := strings.Join([]string{"etcd://", resolverPrefix, fmt.Sprint(shard)}, "/")
+		opts, err := grpcCfg.GetGRPCDialOpts()
+		if err != nil {
+			return nil, err
+		}
+		return grpc.Dial(ep, append(opts, grpc.WithResolvers(r), grpc.WithDefaultServiceConfig(csServiceConfig))...)
+	}
+	if err = s.InitShardMap(context.Background(), initialMap); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
@@ -3,20 +3,33 @@ package service
 import (
 	"context"
 	"fmt"
+	"math"
 	"strings"
+	"time"
 
 	pb "github.com/althk/ganache/csm/proto"
 	"github.com/rs/zerolog/log"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/naming/endpoints"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 // csm implements ShardManagerServer service
 type CSM struct {
 	Etcd             *clientv3.Client
 	CSResolverPrefix string
+	ShardMapKey      string
+	// DialShard connects to the cache servers of a shard, used for resharding.
+	DialShard func(shard int) (*grpc.ClientConn, error)
+	// MigrationSettleDelay is how long resharding waits for CFEs to pick up a
+	// new shard map before moving keys.
+	MigrationSettleDelay time.Duration
+	// RegistrationTTL is how long a cache server stays registered after its
+	// last lease keep alive.
+	RegistrationTTL time.Duration
 	pb.UnimplementedShardManagerServer
 }
 
@@ -30,22 +43,51 @@ func (s *CSM) RegisterCacheServer(ctx context.Context, in *pb.RegisterCacheServe
 	log.Info().
 		Str("cs_spec", in.ServerSpec).
 		Str("key", shardPrefix).
+		Str("replication_mode", in.ReplicationMode).
 		Msgf("Registering new cache server %v", in.ServerSpec)
 
+	ttl := int64(math.Ceil(s.RegistrationTTL.Seconds()))
+	lease, err := s.Etcd.Grant(ctx, ttl)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Error granting registration lease: %v", err)
+	}
 	epKey := strings.Join([]string{shardPrefix, in.ServerSpec}, "/")
-	err = em.AddEndpoint(ctx, epKey, endpoints.Endpoint{Addr: in.ServerSpec})
+	ep := endpoints.Endpoint{Addr: in.ServerSpec, Metadata: in.ReplicationMode}
+	err = em.AddEndpoint(ctx, epKey, ep, clientv3.WithLease(lease.ID))
 	if err != nil {
 		return nil, status.Error(codes.Unavailable, err.Error())
 	}
 	log.Info().
 		Str("epKey", epKey).
+		Int64("lease_ttl", ttl).
 		Msg("Cache server endpoint resolver registration successful")
-	return &pb.RegisterCacheServerResponse{RegisteredPath: epKey}, nil
+	return &pb.RegisterCacheServerResponse{
+		RegisteredPath:  epKey,
+		LeaseId:         int64(lease.ID),
+		LeaseTtlSeconds: ttl,
+	}, nil
+}
+
+func (s *CSM) DeregisterCacheServer(ctx context.Context, in *pb.DeregisterCacheServerRequest) (*emptypb.Empty, error) {
+	shardPrefix := strings.Join([]string{s.CSResolverPrefix, fmt.Sprint(in.Shard)}, "/")
+	em, err := endpoints.NewManager(s.Etcd, shardPrefix)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Error getting endpoints mgr: %v", err)
+	}
+	epKey := strings.Join([]string{shardPrefix, in.ServerSpec}, "/")
+	if err = em.DeleteEndpoint(ctx, epKey); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	log.Info().
+		Str("epKey", epKey).
+		Msg("Cache server deregistered")
+	return &emptypb.Empty{}, nil
 }
 
-func NewCSM(etcdc *clientv3.Client, resolverPrefix string) (*CSM, error) {
+func NewCSM(etcdc *clientv3.Client, resolverPrefix, shardMapKey string) (*CSM, error) {
 	return &CSM{
 		Etcd:             etcdc,
 		CSResolverPrefix: resolverPrefix,
+		ShardMapKey:      shardMapKey,
 	}, nil
 }
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	cspb "github.com/althk/ganache/cacheserver/proto"
+	pb "github.com/althk/ganache/csm/proto"
+	etcdutils "github.com/althk/ganache/utils/etcd"
+	"github.com/rs/zerolog/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/naming/endpoints"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// peerReplication is the -replication_mode of cache servers that replicate
+// with peers instead of through etcd.
+const peerReplication = "peer"
+
+// importBatchSize is the most keys imported into, or deleted from, a shard
+// whose cache servers replicate with peers in one RPC.
+const importBatchSize = 500
+
+// AddShard adds a shard in three steps:
+//  1. publish a migrating shard map, CFE writes to the new owners and falls
+//     back to the old owners for reads that miss.
+//  2. after giving CFEs time to switch, stream the keys the new shard owns
+//     from each old shard and copy them to the new shard's etcd prefix, which
+//     its cache servers sync from.
+//  3. publish the final shard map and delete the moved keys from the old
+//     shards.
+//
+// If step 2 fails the shard map is left migrating, calling AddShard again
+// with the same shard resumes the migration.
+//
+// Shards whose cache servers replicate with peers, as they report when they
+// register, do not watch etcd: keys are imported into them with Import and
+// deleted from them with MDelete instead.
+func (s *CSM) AddShard(ctx context.Context, in *pb.AddShardRequest) (*pb.AddShardResponse, error) {
+	if in.Shard == nil {
+		return nil, status.Error(codes.InvalidArgument, "shard is required")
+	}
+	curr, rev, err := s.shardMap(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if curr == nil {
+		return nil, status.Error(codes.FailedPrecondition, "shard map has not been published")
+	}
+	if curr.Version != in.ExpectedVersion {
+		return nil, status.Errorf(codes.Aborted, "shard map is at version %d, not %d", curr.Version, in.ExpectedVersion)
+	}
+	m := curr
+	if curr.Migrating {
+		if !addsShard(curr, in.Shard.Id) {
+			return nil, status.Error(codes.FailedPrecondition, "another migration is in progress")
+		}
+		log.Info().Int32("shard", in.Shard.Id).Msg("Resuming migration to shard")
+	} else {
+		if hasShard(curr.Shards, in.Shard.Id) {
+			return nil, status.Errorf(codes.AlreadyExists, "shard %d is already in the shard map", in.Shard.Id)
+		}
+		if err := s.checkRegistered(ctx, in.Shard.Id); err != nil {
+			return nil, err
+		}
+		m = &pb.ShardMap{
+			Version:        curr.Version + 1,
+			Shards:         append(append([]*pb.Shard{}, curr.Shards...), in.Shard),
+			Picker:         curr.Picker,
+			Vnodes:         curr.Vnodes,
+			Migrating:      true,
+			PreviousShards: curr.Shards,
+		}
+		if err := validateShardMap(m); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if rev, err = s.putShardMap(ctx, m, rev); err != nil {
+			return nil, err
+		}
+	}
+
+	// CFEs still on the previous map would write moved keys to the old shards
+	select {
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case <-time.After(s.MigrationSettleDelay):
+	}
+
+	moved, err := s.moveKeys(ctx, m, int(in.Shard.Id))
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Moving keys to shard %d failed, shard map is left at migrating version %d: %v", in.Shard.Id, m.Version, err)
+	}
+	final := &pb.ShardMap{
+		Version: m.Version + 1,
+		Shards:  m.Shards,
+		Picker:  m.Picker,
+		Vnodes:  m.Vnodes,
+	}
+	if _, err := s.putShardMap(ctx, final, rev); err != nil {
+		return nil, err
+	}
+
+	var n int64
+	for from, keys := range moved {
+		if err := s.deleteMoved(ctx, from, keys); err != nil {
+			log.Warn().Err(err).Int("shard", from).Msg("Error deleting moved keys")
+		}
+		n += int64(len(keys))
+	}
+	log.Info().
+		Int32("shard", in.Shard.Id).
+		Int64("keys", n).
+		Msg("Shard added")
+	return &pb.AddShardResponse{ShardMap: final, MovedKeysCount: n}, nil
+}
+
+// moveKeys copies the keys that shard `to` owns under m, and the global keys,
+// from the previous shards and returns the copied keys by the shard they
+// came from. Global keys stay on the previous shards, so they are not
+// returned.
+func (s *CSM) moveKeys(ctx context.Context, m *pb.ShardMap, to int) (map[int][]string, error) {
+	req := &cspb.TransferRequest{
+		Shard:   int32(to),
+		Picker:  m.Picker,
+		Vnodes:  m.Vnodes,
+		Weights: make(map[int32]int32, len(m.Shards)),
+	}
+	for _, sh := range m.Shards {
+		req.Weights[sh.Id] = sh.Weight
+	}
+	imp, err := s.importer(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	defer imp.close()
+	moved := make(map[int][]string)
+	for _, sh := range m.PreviousShards {
+		from := int(sh.Id)
+		keys, err := s.transfer(ctx, from, req, imp)
+		if err != nil {
+			return nil, fmt.Errorf("shard %d: %w", from, err)
+		}
+		log.Info().Int("from", from).Int("to", to).Int("keys", len(keys)).Msg("Copied keys")
+		moved[from] = keys
+	}
+	return moved, nil
+}
+
+func (s *CSM) transfer(ctx context.Context, from int, req *cspb.TransferRequest, imp keyImporter) ([]string, error) {
+	conn, err := s.DialShard(from)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stream, err := cspb.NewCacheClient(conn).Transfer(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for {
+		km, err := stream.Recv()
+		if err == io.EOF {
+			if err := imp.flush(ctx); err != nil {
+				return nil, err
+			}
+			return keys, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ok, err := imp.add(ctx, from, km)
+		if err != nil {
+			return nil, err
+		}
+		if ok && !km.Value.GetGlobal() {
+			keys = append(keys, km.Key)
+		}
+	}
+}
+
+// importKey copies km into shard `to` unless the key was written or deleted
+// there already, which is always newer, or has been deleted from shard
+// `from` since it was streamed.
+func (s *CSM) importKey(ctx context.Context, from, to int, km *cspb.CacheKeyMetadata) (bool, error) {
+	src := etcdutils.CacheKeyPath(from, km.Key)
+	dst := etcdutils.CacheKeyPath(to, km.Key)
+	var opts []clientv3.OpOption
+	var lease clientv3.LeaseID
+	if exp := km.Value.GetExpiresAt(); exp != nil {
+		ttl := time.Until(exp.AsTime())
+		if ttl <= 0 {
+			return false, nil
+		}
+		l, err := s.Etcd.Grant(ctx, int64(math.Ceil(ttl.Seconds())))
+		if err != nil {
+			return false, err
+		}
+		lease = l.ID
+		opts = append(opts, clientv3.WithLease(lease))
+	}
+	data, err := proto.Marshal(km)
+	if err != nil {
+		return false, err
+	}
+	ok, err := s.putImported(ctx, src, dst, string(data), opts...)
+	if !ok && lease != clientv3.NoLease {
+		s.Etcd.Revoke(ctx, lease)
+	}
+	return ok, err
+}
+
+// putImported puts data at dst unless dst exists, a value or a tombstone, or
+// src is gone or has been replaced with a tombstone. It retries when src is
+// written in between.
+func (s *CSM) putImported(ctx context.Context, src, dst, data string, opts ...clientv3.OpOption) (bool, error) {
+	for {
+		g, err := s.Etcd.Get(ctx, src)
+		if err != nil {
+			return false, err
+		}
+		if len(g.Kvs) == 0 {
+			return false, nil
+		}
+		curr := &cspb.CacheKeyMetadata{}
+		if err := proto.Unmarshal(g.Kvs[0].Value, curr); err != nil || curr.DeletedAt != nil {
+			return false, err
+		}
+		r, err := s.Etcd.Txn(ctx).
+			If(
+				clientv3.Compare(clientv3.CreateRevision(dst), "=", 0),
+				clientv3.Compare(clientv3.ModRevision(src), "=", g.Kvs[0].ModRevision),
+			).
+			Then(clientv3.OpPut(dst, data, opts...)).
+			Else(clientv3.OpGet(dst, clientv3.WithCountOnly())).
+			Commit()
+		if err != nil {
+			return false, err
+		}
+		if r.Succeeded {
+			return true, nil
+		}
+		if r.Responses[0].GetResponseRange().Count > 0 {
+			return false, nil
+		}
+	}
+}
+
+// checkRegistered returns FailedPrecondition if no cache server has been
+// registered for the shard, CFE would have nowhere to send its requests.
+func (s *CSM) checkRegistered(ctx context.Context, shard int32) error {
+	prefix := fmt.Sprintf("%s/%d/", s.CSResolverPrefix, shard)
+	r, err := s.Etcd.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	if r.Count == 0 {
+		return status.Errorf(codes.FailedPrecondition, "no cache servers registered for shard %d", shard)
+	}
+	return nil
+}
+
+// addsShard reports whether migrating map m is adding shard id.
+func addsShard(m *pb.ShardMap, id int32) bool {
+	return hasShard(m.Shards, id) && !hasShard(m.PreviousShards, id)
+}
+
+func hasShard(shards []*pb.Shard, id int32) bool {
+	for _, sh := range shards {
+		if sh.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// peerReplicated reports whether the cache servers registered for shard
+// replicate with peers instead of through etcd.
+func (s *CSM) peerReplicated(ctx context.Context, shard int) (bool, error) {
+	r, err := s.Etcd.Get(ctx, fmt.Sprintf("%s/%d/", s.CSResolverPrefix, shard), clientv3.WithPrefix())
+	if err != nil {
+		return false, err
+	}
+	for _, kv := range r.Kvs {
+		var ep endpoints.Endpoint
+		if err := json.Unmarshal(kv.Value, &ep); err != nil {
+			log.Warn().Err(err).Msgf("Error reading cache server registration %s", kv.Key)
+			continue
+		}
+		if ep.Metadata == peerReplication {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// keyImporter copies the keys streamed from the old shards into the new
+// shard.
+type keyImporter interface {
+	// add imports km, streamed from shard from, and reports whether it is
+	// to be deleted from shard from once the new shard owns it.
+	add(ctx context.Context, from int, km *cspb.CacheKeyMetadata) (bool, error)
+	// flush imports the keys add may have buffered.
+	flush(ctx context.Context) error
+	close()
+}
+
+// importer returns the keyImporter for shard to, which writes to its etcd
+// prefix unless its cache servers replicate with peers.
+func (s *CSM) importer(ctx context.Context, to int) (keyImporter, error) {
+	peer, err := s.peerReplicated(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	if !peer {
+		return &etcdImporter{s: s, to: to}, nil
+	}
+	conn, err := s.DialShard(to)
+	if err != nil {
+		return nil, err
+	}
+	return &peerImporter{conn: conn, c: cspb.NewCacheClient(conn)}, nil
+}
+
+type etcdImporter struct {
+	s  *CSM
+	to int
+}
+
+func (i *etcdImporter) add(ctx context.Context, from int, km *cspb.CacheKeyMetadata) (bool, error) {
+	return i.s.importKey(ctx, from, i.to, km)
+}
+
+func (i *etcdImporter) flush(context.Context) error { return nil }
+func (i *etcdImporter) close()                      {}
+
+// peerImporter imports keys in batches with the Import RPC of one of the
+// new shard's cache servers, which replicates them to the others. The
+// server keeps the keys written or deleted on the new shard since the
+// migration started, so every key that was streamed can be deleted from
+// its old shard.
+type peerImporter struct {
+	conn  *grpc.ClientConn
+	c     cspb.CacheClient
+	batch []*cspb.CacheKeyMetadata
+}
+
+func (i *peerImporter) add(ctx context.Context, _ int, km *cspb.CacheKeyMetadata) (bool, error) {
+	i.batch = append(i.batch, km)
+	if len(i.batch) >= importBatchSize {
+		if err := i.flush(ctx); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (i *peerImporter) flush(ctx context.Context) error {
+	if len(i.batch) == 0 {
+		return nil
+	}
+	if _, err := i.c.Import(ctx, &cspb.ImportRequest{Entries: i.batch}); err != nil {
+		return err
+	}
+	i.batch = nil
+	return nil
+}
+
+func (i *peerImporter) close() {
+	i.conn.Close()
+}
+
+// deleteMoved deletes keys, which were moved to another shard, from shard
+// from with MDelete, so that its cache servers stamp the deletes with their
+// clock for the replicas to order them. Keys that cannot be deleted are
+// logged.
+func (s *CSM) deleteMoved(ctx context.Context, from int, keys []string) error {
+	conn, err := s.DialShard(from)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	c := cspb.NewCacheClient(conn)
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > importBatchSize {
+			n = importBatchSize
+		}
+		req := &cspb.MDeleteRequest{Keys: make([]*cspb.DeleteRequest, n)}
+		for j, k := range keys[:n] {
+			req.Keys[j] = &cspb.DeleteRequest{Key: k} // k already has its namespace
+		}
+		r, err := c.MDelete(ctx, req)
+		if err != nil {
+			return err
+		}
+		for j, st := range r.Statuses {
+			if codes.Code(st.Code) != codes.OK {
+				log.Warn().Int("shard", from).Str("error", st.Message).Msgf("Error deleting moved key %v", keys[j])
+			}
+		}
+		keys = keys[n:]
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+
+	cspb "github.com/althk/ganache/cacheserver/proto"
+	pb "github.com/althk/ganache/csm/proto"
+	etcdutils "github.com/althk/ganache/utils/etcd"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/naming/endpoints"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestPeerReplicated(t *testing.T) {
+	kv := &fakeKV{data: map[string][]byte{}}
+	s := &CSM{Etcd: &clientv3.Client{KV: kv}, CSResolverPrefix: "ganache/cacheserver"}
+	register(kv, "0", "cs1:1", "")
+	register(kv, "0", "cs2:1", "etcd")
+	register(kv, "1", "cs3:1", "peer")
+	register(kv, "10", "cs4:1", "etcd")
+
+	for shard, want := range map[int]bool{0: false, 1: true, 10: false, 2: false} {
+		got, err := s.peerReplicated(context.Background(), shard)
+		require.NoError(t, err)
+		require.Equal(t, want, got, "shard %d", shard)
+	}
+}
+
+func TestMoveKeysWithPeerReplication(t *testing.T) {
+	kv := &fakeKV{data: map[string][]byte{}}
+	register(kv, "0", "cs1:1", "peer")
+	register(kv, "1", "cs2:1", "peer")
+	old, added := &fakeCacheServer{}, &fakeCacheServer{}
+	for i := 0; i < 600; i++ {
+		old.entries = append(old.entries, &cspb.CacheKeyMetadata{Key: fmt.Sprintf("ns1key%d", i), Value: &cspb.CacheValue{}})
+	}
+	old.entries = append(old.entries, &cspb.CacheKeyMetadata{Key: "ns1global", Value: &cspb.CacheValue{Global: true}})
+	addrs := map[int]string{0: serveCache(t, old), 1: serveCache(t, added)}
+	s := &CSM{
+		Etcd:             &clientv3.Client{KV: kv},
+		CSResolverPrefix: "ganache/cacheserver",
+		DialShard: func(shard int) (*grpc.ClientConn, error) {
+			return grpc.Dial(addrs[shard], grpc.WithTransportCredentials(insecure.NewCredentials()))
+		},
+	}
+	m := &pb.ShardMap{
+		Version:        2,
+		Shards:         []*pb.Shard{{Id: 0, Weight: 1}, {Id: 1, Weight: 1}},
+		Picker:         "ring",
+		Migrating:      true,
+		PreviousShards: []*pb.Shard{{Id: 0, Weight: 1}},
+	}
+
+	moved, err := s.moveKeys(context.Background(), m, 1)
+	require.NoError(t, err)
+	// imported in batches, global keys too
+	require.Equal(t, []int{500, 101}, added.importSizes())
+	require.Len(t, moved[0], 600)
+	require.NotContains(t, moved[0], "ns1global")
+
+	require.NoError(t, s.deleteMoved(context.Background(), 0, moved[0]))
+	require.ElementsMatch(t, moved[0], old.deletedKeys())
+}
+
+func TestImportKey(t *testing.T) {
+	value, _ := proto.Marshal(&cspb.CacheKeyMetadata{Key: "ns1key", Value: &cspb.CacheValue{}})
+	tombstone, _ := proto.Marshal(&cspb.CacheKeyMetadata{Key: "ns1key", DeletedAt: &cspb.HLC{Physical: 1}})
+	src, dst := etcdutils.CacheKeyPath(0, "ns1key"), etcdutils.CacheKeyPath(1, "ns1key")
+	for name, tc := range map[string]struct {
+		data map[string][]byte
+		want bool
+	}{
+		"copied":                 {data: map[string][]byte{src: value}, want: true},
+		"deleted from old shard": {data: map[string][]byte{src: tombstone}},
+		"gone from old shard":    {data: map[string][]byte{}},
+		"deleted on new shard":   {data: map[string][]byte{src: value, dst: tombstone}},
+		"written on new shard":   {data: map[string][]byte{src: value, dst: value}},
+	} {
+		kv := &fakeKV{data: tc.data}
+		s := &CSM{Etcd: &clientv3.Client{KV: kv}}
+		ok, err := s.importKey(context.Background(), 0, 1, &cspb.CacheKeyMetadata{Key: "ns1key", Value: &cspb.CacheValue{}})
+		require.NoError(t, err, name)
+		require.Equal(t, tc.want, ok, name)
+		if tc.want {
+			require.Contains(t, kv.data, dst, name)
+		}
+	}
+}
+
+func register(kv *fakeKV, shard, addr, mode string) {
+	b, _ := json.Marshal(endpoints.Endpoint{Addr: addr, Metadata: mode})
+	kv.data["ganache/cacheserver/"+shard+"/"+addr] = b
+}
+
+// serveCache serves cs on a local port and returns its address.
+func serveCache(t *testing.T, cs cspb.CacheServer) string {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	cspb.RegisterCacheServer(srv, cs)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+// fakeCacheServer streams its entries from Transfer, whatever the request,
+// and records the Import and MDelete calls.
+type fakeCacheServer struct {
+	cspb.UnimplementedCacheServer
+	entries []*cspb.CacheKeyMetadata
+	l       sync.Mutex
+	imports []int // entries per Import
+	deleted []string
+}
+
+func (f *fakeCacheServer) Transfer(_ *cspb.TransferRequest, stream cspb.Cache_TransferServer) error {
+	for _, e := range f.entries {
+		if err := stream.Send(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeCacheServer) Import(_ context.Context, in *cspb.ImportRequest) (*emptypb.Empty, error) {
+	f.l.Lock()
+	defer f.l.Unlock()
+	f.imports = append(f.imports, len(in.Entries))
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeCacheServer) MDelete(_ context.Context, in *cspb.MDeleteRequest) (*cspb.MDeleteResponse, error) {
+	f.l.Lock()
+	defer f.l.Unlock()
+	r := &cspb.MDeleteResponse{}
+	for _, k := range in.Keys {
+		f.deleted = append(f.deleted, k.Namespace+k.Key)
+		r.Statuses = append(r.Statuses, &cspb.KeyStatus{})
+	}
+	return r, nil
+}
+
+func (f *fakeCacheServer) importSizes() []int {
+	f.l.Lock()
+	defer f.l.Unlock()
+	return append([]int(nil), f.imports...)
+}
+
+func (f *fakeCacheServer) deletedKeys() []string {
+	f.l.Lock()
+	defer f.l.Unlock()
+	return append([]string(nil), f.deleted...)
+}
+
+// fakeKV serves Get and the Txn of importKey from a map, where every key is
+// at revision 1, the other calls are not implemented.
+type fakeKV struct {
+	clientv3.KV
+	data map[string][]byte
+}
+
+func (kv *fakeKV) Txn(context.Context) clientv3.Txn {
+	return &fakeTxn{kv: kv}
+}
+
+type fakeTxn struct {
+	kv        *fakeKV
+	cmps      []clientv3.Cmp
+	then, els []clientv3.Op
+}
+
+func (t *fakeTxn) If(cs ...clientv3.Cmp) clientv3.Txn   { t.cmps = cs; return t }
+func (t *fakeTxn) Then(ops ...clientv3.Op) clientv3.Txn { t.then = ops; return t }
+func (t *fakeTxn) Else(ops ...clientv3.Op) clientv3.Txn { t.els = ops; return t }
+
+// Commit compares the create and mod revisions with "=", as importKey does.
+func (t *fakeTxn) Commit() (*clientv3.TxnResponse, error) {
+	ok := true
+	for _, c := range t.cmps {
+		var rev int64
+		if _, exists := t.kv.data[string(c.Key)]; exists {
+			rev = 1
+		}
+		cmp := etcdserverpb.Compare(c)
+		ok = ok && rev == cmp.GetCreateRevision()+cmp.GetModRevision()
+	}
+	ops := t.els
+	if ok {
+		ops = t.then
+	}
+	r := &clientv3.TxnResponse{Succeeded: ok}
+	for _, op := range ops {
+		if op.IsPut() {
+			t.kv.data[string(op.KeyBytes())] = op.ValueBytes()
+			r.Responses = append(r.Responses, &etcdserverpb.ResponseOp{Response: &etcdserverpb.ResponseOp_ResponsePut{}})
+			continue
+		}
+		g, _ := t.kv.Get(context.Background(), string(op.KeyBytes()), clientv3.WithCountOnly())
+		r.Responses = append(r.Responses, &etcdserverpb.ResponseOp{Response: &etcdserverpb.ResponseOp_ResponseRange{ResponseRange: &etcdserverpb.RangeResponse{Count: g.Count}}})
+	}
+	return r, nil
+}
+
+func (kv *fakeKV) Get(_ context.Context, k string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	op := clientv3.OpGet(k, opts...)
+	end := string(op.RangeBytes())
+	var keys []string
+	for key := range kv.data {
+		if key == k || (end != "" && key >= k && key < end) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	r := &clientv3.GetResponse{Count: int64(len(keys))}
+	if op.IsCountOnly() {
+		return r, nil
+	}
+	for _, key := range keys {
+		r.Kvs = append(r.Kvs, &mvccpb.KeyValue{Key: []byte(key), Value: kv.data[key], ModRevision: 1})
+	}
+	return r, nil
+}
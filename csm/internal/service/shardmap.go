@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/althk/ganache/csm/proto"
+	"github.com/althk/ganache/utils/sharding"
+	"github.com/rs/zerolog/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *CSM) GetShardMap(ctx context.Context, _ *emptypb.Empty) (*pb.ShardMap, error) {
+	m, _, err := s.shardMap(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if m == nil {
+		return nil, status.Error(codes.NotFound, "shard map has not been published")
+	}
+	return m, nil
+}
+
+func (s *CSM) UpdateShardMap(ctx context.Context, in *pb.UpdateShardMapRequest) (*pb.ShardMap, error) {
+	curr, rev, err := s.shardMap(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if curr == nil {
+		return nil, status.Error(codes.FailedPrecondition, "shard map has not been published")
+	}
+	if curr.Version != in.ExpectedVersion {
+		return nil, status.Errorf(codes.Aborted, "shard map is at version %d, not %d", curr.Version, in.ExpectedVersion)
+	}
+	m := &pb.ShardMap{
+		Version: curr.Version + 1,
+		Shards:  in.Shards,
+		Picker:  curr.Picker,
+		Vnodes:  curr.Vnodes,
+	}
+	if err := validateShardMap(m); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if _, err := s.putShardMap(ctx, m, rev); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// putShardMap stores m if the shard map is still at etcd mod revision rev
+// and returns the revision m was stored at.
+func (s *CSM) putShardMap(ctx context.Context, m *pb.ShardMap, rev int64) (int64, error) {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return 0, status.Error(codes.Internal, err.Error())
+	}
+	r, err := s.Etcd.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(s.ShardMapKey), "=", rev)).
+		Then(clientv3.OpPut(s.ShardMapKey, string(b))).
+		Commit()
+	if err != nil {
+		return 0, status.Error(codes.Unavailable, err.Error())
+	}
+	if !r.Succeeded {
+		return 0, status.Error(codes.Aborted, "shard map was updated concurrently")
+	}
+	log.Info().
+		Int64("version", m.Version).
+		Int("shards", len(m.Shards)).
+		Bool("migrating", m.Migrating).
+		Msg("Published new shard map")
+	return r.Header.Revision, nil
+}
+
+// InitShardMap publishes m as the first version of the shard map unless a
+// shard map already exists, in which case the existing one is kept.
+func (s *CSM) InitShardMap(ctx context.Context, m *pb.ShardMap) error {
+	if err := validateShardMap(m); err != nil {
+		return err
+	}
+	m = proto.Clone(m).(*pb.ShardMap)
+	m.Version = 1
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	r, err := s.Etcd.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(s.ShardMapKey), "=", 0)).
+		Then(clientv3.OpPut(s.ShardMapKey, string(b))).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if r.Succeeded {
+		log.Info().Int("shards", len(m.Shards)).Msg("Published initial shard map")
+	} else {
+		log.Info().Msg("Shard map already exists, not overwriting it")
+	}
+	return nil
+}
+
+// shardMap returns the current shard map and its etcd mod revision, or a nil
+// map if none has been published.
+func (s *CSM) shardMap(ctx context.Context) (*pb.ShardMap, int64, error) {
+	r, err := s.Etcd.Get(ctx, s.ShardMapKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(r.Kvs) == 0 {
+		return nil, 0, nil
+	}
+	m := &pb.ShardMap{}
+	if err := proto.Unmarshal(r.Kvs[0].Value, m); err != nil {
+		return nil, 0, err
+	}
+	return m, r.Kvs[0].ModRevision, nil
+}
+
+// validateShardMap checks that the shards are unique and that a picker can
+// be built for them, and for the previous shards if m is migrating.
+func validateShardMap(m *pb.ShardMap) error {
+	if _, err := picker(m, m.Shards); err != nil {
+		return err
+	}
+	if m.Migrating {
+		_, err := picker(m, m.PreviousShards)
+		return err
+	}
+	return nil
+}
+
+// picker returns the picker of m for the given shards.
+func picker(m *pb.ShardMap, shards []*pb.Shard) (sharding.Picker, error) {
+	w, err := weights(shards)
+	if err != nil {
+		return nil, err
+	}
+	return sharding.NewPicker(m.Picker, w, int(m.Vnodes))
+}
+
+func weights(shards []*pb.Shard) (map[int]int, error) {
+	w := make(map[int]int, len(shards))
+	for _, sh := range shards {
+		if _, e := w[int(sh.Id)]; e {
+			return nil, fmt.Errorf("shard %d is listed more than once", sh.Id)
+		}
+		w[int(sh.Id)] = int(sh.Weight)
+	}
+	return w, nil
+}
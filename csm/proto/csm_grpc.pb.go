@@ -11,6 +11,7 @@ import (
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
 // This is a compile-time assertion to ensure that this generated file
@@ -22,7 +23,18 @@ const _ = grpc.SupportPackageIsVersion7
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ShardManagerClient interface {
+	// RegisterCacheServer adds the server to its shard's resolver under a
+	// lease that the server must keep alive, it is removed once the lease
+	// expires.
 	RegisterCacheServer(ctx context.Context, in *RegisterCacheServerRequest, opts ...grpc.CallOption) (*RegisterCacheServerResponse, error)
+	DeregisterCacheServer(ctx context.Context, in *DeregisterCacheServerRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetShardMap(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ShardMap, error)
+	// UpdateShardMap replaces the shards of the map if its version is still
+	// expected_version, it fails with ABORTED otherwise.
+	UpdateShardMap(ctx context.Context, in *UpdateShardMapRequest, opts ...grpc.CallOption) (*ShardMap, error)
+	// AddShard adds a shard to the map and moves the keys it now owns over
+	// from the existing shards. It returns once the new map is in use.
+	AddShard(ctx context.Context, in *AddShardRequest, opts ...grpc.CallOption) (*AddShardResponse, error)
 }
 
 type shardManagerClient struct {
@@ -42,11 +54,58 @@ func (c *shardManagerClient) RegisterCacheServer(ctx context.Context, in *Regist
 	return out, nil
 }
 
+func (c *shardManagerClient) DeregisterCacheServer(ctx context.Context, in *DeregisterCacheServerRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/ganache.csm.ShardManager/DeregisterCacheServer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardManagerClient) GetShardMap(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ShardMap, error) {
+	out := new(ShardMap)
+	err := c.cc.Invoke(ctx, "/ganache.csm.ShardManager/GetShardMap", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardManagerClient) UpdateShardMap(ctx context.Context, in *UpdateShardMapRequest, opts ...grpc.CallOption) (*ShardMap, error) {
+	out := new(ShardMap)
+	err := c.cc.Invoke(ctx, "/ganache.csm.ShardManager/UpdateShardMap", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardManagerClient) AddShard(ctx context.Context, in *AddShardRequest, opts ...grpc.CallOption) (*AddShardResponse, error) {
+	out := new(AddShardResponse)
+	err := c.cc.Invoke(ctx, "/ganache.csm.ShardManager/AddShard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ShardManagerServer is the server API for ShardManager service.
 // All implementations must embed UnimplementedShardManagerServer
 // for forward compatibility
 type ShardManagerServer interface {
+	// RegisterCacheServer adds the server to its shard's resolver under a
+	// lease that the server must keep alive, it is removed once the lease
+	// expires.
 	RegisterCacheServer(context.Context, *RegisterCacheServerRequest) (*RegisterCacheServerResponse, error)
+	DeregisterCacheServer(context.Context, *DeregisterCacheServerRequest) (*emptypb.Empty, error)
+	GetShardMap(context.Context, *emptypb.Empty) (*ShardMap, error)
+	// UpdateShardMap replaces the shards of the map if its version is still
+	// expected_version, it fails with ABORTED otherwise.
+	UpdateShardMap(context.Context, *UpdateShardMapRequest) (*ShardMap, error)
+	// AddShard adds a shard to the map and moves the keys it now owns over
+	// from the existing shards. It returns once the new map is in use.
+	AddShard(context.Context, *AddShardRequest) (*AddShardResponse, error)
 	mustEmbedUnimplementedShardManagerServer()
 }
 
@@ -57,6 +116,18 @@ type UnimplementedShardManagerServer struct {
 func (UnimplementedShardManagerServer) RegisterCacheServer(context.Context, *RegisterCacheServerRequest) (*RegisterCacheServerResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RegisterCacheServer not implemented")
 }
+func (UnimplementedShardManagerServer) DeregisterCacheServer(context.Context, *DeregisterCacheServerRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeregisterCacheServer not implemented")
+}
+func (UnimplementedShardManagerServer) GetShardMap(context.Context, *emptypb.Empty) (*ShardMap, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetShardMap not implemented")
+}
+func (UnimplementedShardManagerServer) UpdateShardMap(context.Context, *UpdateShardMapRequest) (*ShardMap, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateShardMap not implemented")
+}
+func (UnimplementedShardManagerServer) AddShard(context.Context, *AddShardRequest) (*AddShardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddShard not implemented")
+}
 func (UnimplementedShardManagerServer) mustEmbedUnimplementedShardManagerServer() {}
 
 // UnsafeShardManagerServer may be embedded to opt out of forward compatibility for this service.
@@ -88,6 +159,78 @@ func _ShardManager_RegisterCacheServer_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ShardManager_DeregisterCacheServer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeregisterCacheServerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardManagerServer).DeregisterCacheServer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.csm.ShardManager/DeregisterCacheServer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardManagerServer).DeregisterCacheServer(ctx, req.(*DeregisterCacheServerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardManager_GetShardMap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardManagerServer).GetShardMap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.csm.ShardManager/GetShardMap",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardManagerServer).GetShardMap(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardManager_UpdateShardMap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateShardMapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardManagerServer).UpdateShardMap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.csm.ShardManager/UpdateShardMap",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardManagerServer).UpdateShardMap(ctx, req.(*UpdateShardMapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardManager_AddShard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddShardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardManagerServer).AddShard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ganache.csm.ShardManager/AddShard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardManagerServer).AddShard(ctx, req.(*AddShardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ShardManager_ServiceDesc is the grpc.ServiceDesc for ShardManager service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -99,6 +242,22 @@ var ShardManager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RegisterCacheServer",
 			Handler:    _ShardManager_RegisterCacheServer_Handler,
 		},
+		{
+			MethodName: "DeregisterCacheServer",
+			Handler:    _ShardManager_DeregisterCacheServer_Handler,
+		},
+		{
+			MethodName: "GetShardMap",
+			Handler:    _ShardManager_GetShardMap_Handler,
+		},
+		{
+			MethodName: "UpdateShardMap",
+			Handler:    _ShardManager_UpdateShardMap_Handler,
+		},
+		{
+			MethodName: "AddShard",
+			Handler:    _ShardManager_AddShard_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "csm.proto",
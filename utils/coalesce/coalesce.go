@@ -0,0 +1,91 @@
+// Package coalesce merges concurrent calls for the same key into one call.
+package coalesce
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Group coalesces calls by key, the zero value is ready to use.
+type Group struct {
+	l     sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done    chan struct{}
+	val     interface{}
+	err     error
+	waiters int // callers still waiting for the result
+	cancel  context.CancelFunc
+}
+
+// Do calls fn once for all the concurrent callers with the same key and
+// returns its result to each of them, shared reports whether the result
+// went to other callers too.
+//
+// fn runs with a context that keeps the values of the first caller's
+// context but not its deadline, it is cancelled once every caller has
+// stopped waiting. A caller whose context is done stops waiting and gets
+// the context's error, the others are not affected.
+func (g *Group) Do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (v interface{}, err error, shared bool) {
+	g.l.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	c, ok := g.calls[key]
+	if !ok {
+		cctx, cancel := context.WithCancel(detached{ctx})
+		c = &call{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go g.run(cctx, key, c, fn)
+	}
+	c.waiters++
+	g.l.Unlock()
+
+	select {
+	case <-c.done:
+		g.l.Lock()
+		shared = c.waiters > 1
+		g.l.Unlock()
+		return c.val, c.err, shared
+	case <-ctx.Done():
+		g.l.Lock()
+		defer g.l.Unlock()
+		c.waiters--
+		if c.waiters == 0 {
+			// nobody wants the result, later callers start a new call
+			c.cancel()
+			g.forget(key, c)
+		}
+		return nil, ctx.Err(), false
+	}
+}
+
+func (g *Group) run(ctx context.Context, key string, c *call, fn func(ctx context.Context) (interface{}, error)) {
+	c.val, c.err = fn(ctx)
+	g.l.Lock()
+	g.forget(key, c)
+	g.l.Unlock()
+	c.cancel()
+	close(c.done)
+}
+
+// forget removes c from the group if it still is the call for key, g.l
+// must be held.
+func (g *Group) forget(key string, c *call) {
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
+
+// detached keeps the values of its context, e.g. trace spans, but not its
+// deadline or cancellation.
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detached) Done() <-chan struct{}       { return nil }
+func (detached) Err() error                  { return nil }
@@ -0,0 +1,117 @@
+package coalesce
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDo(t *testing.T) {
+	var g Group
+	var calls int32
+	gate := make(chan struct{})
+	fn := func(context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-gate
+		return "val", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err, shared := g.Do(context.Background(), "key", fn)
+			require.NoError(t, err)
+			require.Equal(t, "val", v)
+			require.True(t, shared)
+		}()
+	}
+	require.Eventually(t, func() bool { return waiters(&g, "key") == 10 }, time.Second, time.Millisecond)
+	close(gate)
+	wg.Wait()
+	require.EqualValues(t, 1, calls)
+
+	// the call is over, the next one runs fn again
+	errNope := errors.New("nope")
+	_, err, shared := g.Do(context.Background(), "key", func(context.Context) (interface{}, error) {
+		return nil, errNope
+	})
+	require.ErrorIs(t, err, errNope)
+	require.False(t, shared)
+}
+
+func TestDoCallerCancelled(t *testing.T) {
+	var g Group
+	gate := make(chan struct{})
+	fnErr := make(chan error, 1)
+	fn := func(ctx context.Context) (interface{}, error) {
+		_, ok := ctx.Deadline()
+		require.False(t, ok, "the caller's deadline must not apply")
+		<-gate
+		fnErr <- ctx.Err()
+		return "val", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err, _ := g.Do(ctx, "key", fn)
+		require.ErrorIs(t, err, context.Canceled)
+	}()
+	require.Eventually(t, func() bool { return waiters(&g, "key") == 1 }, time.Second, time.Millisecond)
+	res := make(chan interface{})
+	go func() {
+		v, _, _ := g.Do(context.Background(), "key", fn)
+		res <- v
+	}()
+	require.Eventually(t, func() bool { return waiters(&g, "key") == 2 }, time.Second, time.Millisecond)
+
+	// the first caller leaving does not cancel the call for the second
+	cancel()
+	<-done
+	close(gate)
+	require.Equal(t, "val", <-res)
+	require.NoError(t, <-fnErr)
+}
+
+func TestDoAllCallersCancelled(t *testing.T) {
+	var g Group
+	started := make(chan struct{})
+	fnCtx := make(chan context.Context, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err, _ := g.Do(ctx, "key", func(ctx context.Context) (interface{}, error) {
+		fnCtx <- ctx
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	// the call is cancelled and forgotten
+	fctx := <-fnCtx
+	require.Eventually(t, func() bool { return fctx.Err() != nil }, time.Second, time.Millisecond)
+	v, err, _ := g.Do(context.Background(), "key", func(context.Context) (interface{}, error) {
+		return "new", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "new", v)
+}
+
+func waiters(g *Group, key string) int {
+	g.l.Lock()
+	defer g.l.Unlock()
+	if c, ok := g.calls[key]; ok {
+		return c.waiters
+	}
+	return 0
+}
@@ -1,11 +1,33 @@
 package etcd
 
 import (
+	"fmt"
 	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
+// ShardMapKey is the default etcd key of the shard map published by CSM.
+const ShardMapKey = "ganache/shardmap"
+
+// CachePrefix is the etcd key prefix under which cache servers replicate
+// their entries, one sub-prefix per shard.
+const CachePrefix = "ganache/cache"
+
+// TombstoneTTL is how long the tombstone a cache server writes in place of a
+// deleted key stays in etcd, as long as cache servers remember deletes.
+const TombstoneTTL = 10 * time.Minute
+
+// CacheShardPrefix returns the etcd key prefix of the given shard's entries.
+func CacheShardPrefix(shard int) string {
+	return fmt.Sprintf("%s/%d", CachePrefix, shard)
+}
+
+// CacheKeyPath returns the etcd key of cache key k in the given shard.
+func CacheKeyPath(shard int, k string) string {
+	return fmt.Sprintf("%s/%s", CacheShardPrefix(shard), k)
+}
+
 func V3Client(etcdSpec string) (*clientv3.Client, error) {
 	cli, err := clientv3.New(clientv3.Config{
 		Endpoints:   []string{etcdSpec},
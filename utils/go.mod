@@ -2,19 +2,26 @@ module github.com/althk/ganache/utils
 
 go 1.18
 
-require go.etcd.io/etcd/client/v3 v3.5.4
+require (
+	github.com/rs/zerolog v1.27.0
+	go.etcd.io/etcd/client/v3 v3.5.4
+)
 
 require (
 	github.com/google/go-cmp v0.5.8 // indirect
-	github.com/stretchr/testify v1.7.1 // indirect
-	google.golang.org/grpc v1.47.0 // indirect
+	github.com/stretchr/testify v1.8.0
+	google.golang.org/grpc v1.47.0
 )
 
 require (
 	github.com/coreos/go-semver v0.3.0 // indirect
 	github.com/coreos/go-systemd/v22 v22.3.3-0.20220203105225-a9a7ef127534 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/mattn/go-colorable v0.1.12 // indirect
+	github.com/mattn/go-isatty v0.0.14 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	go.etcd.io/etcd/api/v3 v3.5.4 // indirect
 	go.etcd.io/etcd/client/pkg/v3 v3.5.4 // indirect
 	go.uber.org/atomic v1.7.0 // indirect
@@ -25,4 +32,5 @@ require (
 	golang.org/x/text v0.3.7 // indirect
 	google.golang.org/genproto v0.0.0-20220630174209-ad1d48641aa7 // indirect
 	google.golang.org/protobuf v1.28.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
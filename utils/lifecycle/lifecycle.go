@@ -0,0 +1,83 @@
+// Package lifecycle shuts gRPC servers down gracefully.
+package lifecycle
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+)
+
+// Step is one part of shutting a server down, e.g. deregistering it.
+type Step func(ctx context.Context) error
+
+// Drainer drains and stops a gRPC server. Shutting down goes through:
+//  1. every service of Health turns NOT_SERVING.
+//  2. the Drain steps run, then the server keeps serving for DrainDelay so
+//     that clients notice it is going away.
+//  3. the server stops accepting RPCs and waits for in-flight ones.
+//  4. the Flush steps run, e.g. waiting for background writes.
+//
+// Everything is bounded by the timeout given to Shutdown, RPCs still running
+// at that point are cancelled.
+type Drainer struct {
+	Server     *grpc.Server
+	Health     *health.Server
+	DrainDelay time.Duration
+	Drain      []Step
+	Flush      []Step
+}
+
+// WaitForSignal blocks until the process receives SIGTERM or SIGINT.
+func WaitForSignal() os.Signal {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(ch)
+	return <-ch
+}
+
+// Shutdown drains and stops the server, it returns once the server has
+// stopped and the flush steps are done, or when timeout runs out.
+func (d *Drainer) Shutdown(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	log.Info().Dur("timeout", timeout).Msg("Draining server")
+	if d.Health != nil {
+		d.Health.Shutdown()
+	}
+	runSteps(ctx, "drain", d.Drain)
+	select {
+	case <-ctx.Done():
+	case <-time.After(d.DrainDelay):
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		d.Server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Warn().Msg("Timed out waiting for in-flight RPCs, stopping")
+		d.Server.Stop()
+		<-stopped
+	}
+
+	runSteps(ctx, "flush", d.Flush)
+	log.Info().Msg("Server stopped")
+}
+
+func runSteps(ctx context.Context, stage string, steps []Step) {
+	for _, step := range steps {
+		if err := step(ctx); err != nil {
+			log.Error().Err(err).Str("stage", stage).Msg("Shutdown step failed")
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package lifecycle
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestShutdown(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(s, hs)
+	go s.Serve(lis)
+
+	var steps []string
+	d := &Drainer{
+		Server: s,
+		Health: hs,
+		Drain: []Step{func(ctx context.Context) error {
+			r, err := hs.Check(ctx, &healthpb.HealthCheckRequest{})
+			require.NoError(t, err)
+			require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, r.Status)
+			steps = append(steps, "drain")
+			return nil
+		}},
+		Flush: []Step{func(context.Context) error {
+			if conn, err := net.Dial("tcp", lis.Addr().String()); err == nil {
+				conn.Close()
+				t.Error("flush ran before the server stopped")
+			}
+			steps = append(steps, "flush")
+			return nil
+		}},
+	}
+	d.Shutdown(time.Second)
+	require.Equal(t, []string{"drain", "flush"}, steps)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	s := grpc.NewServer()
+	start := time.Now()
+	d := &Drainer{
+		Server:     s,
+		DrainDelay: time.Minute,
+		Flush: []Step{func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	}
+	d.Shutdown(50 * time.Millisecond)
+	require.Less(t, time.Since(start), time.Second)
+}
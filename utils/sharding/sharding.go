@@ -0,0 +1,215 @@
+// Package sharding maps cache keys to shards.
+package sharding
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Picker kinds.
+const (
+	Mod        = "mod"        // fnv32(key) % shards, remaps almost all keys on resize
+	Ring       = "ring"       // consistent hash ring with virtual nodes
+	Rendezvous = "rendezvous" // weighted highest random weight hashing
+)
+
+const DefaultVNodes = 160
+
+var (
+	ErrNoShards      = fmt.Errorf("at least one shard is required")
+	ErrUnknownPicker = fmt.Errorf("unknown shard picker, must be one of %v", []string{Ring, Rendezvous, Mod})
+	ErrInvalidWeight = fmt.Errorf("shard weights must be positive")
+	ErrShardGap      = fmt.Errorf("mod picker needs shards numbered 0..n-1")
+)
+
+// Picker maps a cache key to the shard that owns it.
+type Picker interface {
+	Shard(key string) int
+}
+
+// NewPicker returns a picker of the given kind for shards with the
+// given weights (shard number -> weight). A shard with weight 2 gets about
+// twice the keys of a shard with weight 1; the mod picker ignores weights
+// and expects shards 0..n-1.
+func NewPicker(kind string, weights map[int]int, vnodes int) (Picker, error) {
+	if len(weights) == 0 {
+		return nil, ErrNoShards
+	}
+	for _, w := range weights {
+		if w <= 0 {
+			return nil, ErrInvalidWeight
+		}
+	}
+	switch kind {
+	case Mod:
+		for i := 0; i < len(weights); i++ {
+			if _, e := weights[i]; !e {
+				return nil, ErrShardGap
+			}
+		}
+		return modPicker(len(weights)), nil
+	case Ring:
+		return newHashRing(weights, vnodes), nil
+	case Rendezvous:
+		return newRendezvous(weights), nil
+	}
+	return nil, ErrUnknownPicker
+}
+
+// EqualWeights returns weights of 1 for shards 0..shardCount-1.
+func EqualWeights(shardCount int) map[int]int {
+	w := make(map[int]int, shardCount)
+	for i := 0; i < shardCount; i++ {
+		w[i] = 1
+	}
+	return w
+}
+
+// ParseWeights parses shard weights given as "shard=weight,..." on top of
+// equal weights for shards 0..shardCount-1.
+func ParseWeights(s string, shardCount int) (map[int]int, error) {
+	w := EqualWeights(shardCount)
+	if s == "" {
+		return w, nil
+	}
+	for _, kv := range strings.Split(s, ",") {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid shard weight %q, want shard=weight", kv)
+		}
+		shard, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid shard in %q: %v", kv, err)
+		}
+		if _, e := w[shard]; !e {
+			return nil, fmt.Errorf("shard %d in %q is out of range", shard, kv)
+		}
+		weight, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight in %q: %v", kv, err)
+		}
+		w[shard] = weight
+	}
+	return w, nil
+}
+
+type modPicker int
+
+func (m modPicker) Shard(key string) int {
+	return int(fnv32(key)) % int(m)
+}
+
+// hashRing places vnodes*weight points per shard on a ring of 64 bit
+// hashes, a key belongs to the shard of the first point at or after its
+// hash. Adding a shard only takes over the keys right before its points,
+// i.e. about 1/N of all keys.
+type hashRing struct {
+	points []uint64 // sorted
+	shards []int    // shards[i] owns points[i]
+}
+
+func newHashRing(weights map[int]int, vnodes int) *hashRing {
+	if vnodes <= 0 {
+		vnodes = DefaultVNodes
+	}
+	type point struct {
+		hash  uint64
+		shard int
+	}
+	var ps []point
+	for shard, w := range weights {
+		for i := 0; i < vnodes*w; i++ {
+			ps = append(ps, point{hash: hash64(fmt.Sprintf("%d-%d", shard, i)), shard: shard})
+		}
+	}
+	// ties are broken by shard so that all CFEs build the same ring
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].hash != ps[j].hash {
+			return ps[i].hash < ps[j].hash
+		}
+		return ps[i].shard < ps[j].shard
+	})
+	r := &hashRing{
+		points: make([]uint64, len(ps)),
+		shards: make([]int, len(ps)),
+	}
+	for i, p := range ps {
+		r.points[i], r.shards[i] = p.hash, p.shard
+	}
+	return r
+}
+
+func (r *hashRing) Shard(key string) int {
+	h := hash64(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0 // wrap around
+	}
+	return r.shards[i]
+}
+
+// rendezvous scores every shard for a key and picks the highest, so
+// removing a shard only moves its own keys and adding one only takes the
+// keys it now scores highest for. Lookups are O(shards).
+type rendezvous struct {
+	shards  []int
+	weights []float64
+}
+
+func newRendezvous(weights map[int]int) *rendezvous {
+	r := &rendezvous{}
+	for shard := range weights {
+		r.shards = append(r.shards, shard)
+	}
+	sort.Ints(r.shards)
+	for _, shard := range r.shards {
+		r.weights = append(r.weights, float64(weights[shard]))
+	}
+	return r
+}
+
+func (r *rendezvous) Shard(key string) int {
+	best, bestScore := 0, math.Inf(-1)
+	for i, shard := range r.shards {
+		h := hash64(key + "/" + strconv.Itoa(shard))
+		// uniform in (0, 1), weighted as in Schindelhauer & Schomaker
+		u := (float64(h>>11) + 0.5) / (1 << 53)
+		score := -r.weights[i] / math.Log(u)
+		if score > bestScore {
+			best, bestScore = shard, score
+		}
+	}
+	return best
+}
+
+// hash64 returns a well mixed 64 bit hash of s.
+func hash64(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	// fnv alone clusters similar keys, finish with the splitmix64 mixer
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// fnv32 computes and returns a hash for the given key.
+// lifted as-is from
+// https://github.com/orcaman/concurrent-map/blob/v2.0.0/concurrent_map.go
+func fnv32(key string) uint32 {
+	hash := uint32(2166136261)
+	const prime32 = uint32(16777619)
+	keyLength := len(key)
+	for i := 0; i < keyLength; i++ {
+		hash *= prime32
+		hash ^= uint32(key[i])
+	}
+	return hash
+}
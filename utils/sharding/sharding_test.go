@@ -0,0 +1,133 @@
+package sharding
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testKeys = 100000
+
+func TestNewPickerErrors(t *testing.T) {
+	_, err := NewPicker("nope", EqualWeights(2), DefaultVNodes)
+	require.ErrorIs(t, err, ErrUnknownPicker)
+	_, err = NewPicker(Ring, nil, DefaultVNodes)
+	require.ErrorIs(t, err, ErrNoShards)
+	_, err = NewPicker(Ring, map[int]int{0: 1, 1: 0}, DefaultVNodes)
+	require.ErrorIs(t, err, ErrInvalidWeight)
+	_, err = NewPicker(Mod, map[int]int{0: 1, 2: 1}, 0)
+	require.ErrorIs(t, err, ErrShardGap)
+}
+
+func TestParseWeights(t *testing.T) {
+	w, err := ParseWeights("", 3)
+	require.NoError(t, err)
+	require.Equal(t, map[int]int{0: 1, 1: 1, 2: 1}, w)
+
+	w, err = ParseWeights("0=3,2=2", 3)
+	require.NoError(t, err)
+	require.Equal(t, map[int]int{0: 3, 1: 1, 2: 2}, w)
+
+	for _, s := range []string{"3=1", "0", "a=1", "0=b"} {
+		_, err = ParseWeights(s, 3)
+		require.Error(t, err, s)
+	}
+}
+
+func TestModPickerMatchesFnv32(t *testing.T) {
+	p, err := NewPicker(Mod, EqualWeights(4), 0)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		k := fmt.Sprintf("key%d", i)
+		require.Equal(t, int(fnv32(k))%4, p.Shard(k))
+	}
+}
+
+func TestPickersAreDeterministic(t *testing.T) {
+	for _, kind := range []string{Ring, Rendezvous} {
+		a, _ := NewPicker(kind, EqualWeights(8), DefaultVNodes)
+		b, _ := NewPicker(kind, EqualWeights(8), DefaultVNodes)
+		for i := 0; i < 1000; i++ {
+			k := fmt.Sprintf("key%d", i)
+			require.Equal(t, a.Shard(k), b.Shard(k), kind)
+		}
+	}
+}
+
+func TestPickersBalanceKeys(t *testing.T) {
+	for _, kind := range []string{Ring, Rendezvous} {
+		p, _ := NewPicker(kind, EqualWeights(8), DefaultVNodes)
+		counts := shardCounts(p)
+		require.Len(t, counts, 8, kind)
+		for shard, n := range counts {
+			// within 25% of a perfect split
+			require.InDelta(t, testKeys/8, n, testKeys/8/4, "%v shard %d", kind, shard)
+		}
+	}
+}
+
+func TestPickersHonourWeights(t *testing.T) {
+	for _, kind := range []string{Ring, Rendezvous} {
+		p, _ := NewPicker(kind, map[int]int{0: 1, 1: 1, 2: 2}, DefaultVNodes)
+		counts := shardCounts(p)
+		require.InDelta(t, testKeys/2, counts[2], testKeys/10, kind)
+		require.InDelta(t, testKeys/4, counts[0], testKeys/10, kind)
+	}
+}
+
+func TestKeyMovementOnShardAdded(t *testing.T) {
+	for _, kind := range []string{Ring, Rendezvous} {
+		before, _ := NewPicker(kind, EqualWeights(10), DefaultVNodes)
+		after, _ := NewPicker(kind, EqualWeights(11), DefaultVNodes)
+		moved := 0
+		for i := 0; i < testKeys; i++ {
+			k := fmt.Sprintf("key%d", i)
+			if b, a := before.Shard(k), after.Shard(k); b != a {
+				require.Equal(t, 10, a, "%v moved a key between old shards", kind)
+				moved++
+			}
+		}
+		// ~1/11 of the keys should move to the new shard
+		require.InDelta(t, float64(testKeys)/11, moved, float64(testKeys)/11/4, kind)
+	}
+}
+
+func TestKeyMovementOnShardRemoved(t *testing.T) {
+	for _, kind := range []string{Ring, Rendezvous} {
+		before, _ := NewPicker(kind, EqualWeights(10), DefaultVNodes)
+		w := EqualWeights(10)
+		delete(w, 3)
+		after, _ := NewPicker(kind, w, DefaultVNodes)
+		moved := 0
+		for i := 0; i < testKeys; i++ {
+			k := fmt.Sprintf("key%d", i)
+			if b, a := before.Shard(k), after.Shard(k); b != a {
+				require.Equal(t, 3, b, "%v moved a key off a remaining shard", kind)
+				moved++
+			}
+		}
+		require.InDelta(t, float64(testKeys)/10, moved, float64(testKeys)/10/4, kind)
+	}
+}
+
+func TestModMovesMostKeys(t *testing.T) {
+	before, _ := NewPicker(Mod, EqualWeights(10), 0)
+	after, _ := NewPicker(Mod, EqualWeights(11), 0)
+	moved := 0
+	for i := 0; i < testKeys; i++ {
+		k := fmt.Sprintf("key%d", i)
+		if before.Shard(k) != after.Shard(k) {
+			moved++
+		}
+	}
+	require.Greater(t, moved, testKeys*8/10)
+}
+
+func shardCounts(p Picker) map[int]int {
+	counts := make(map[int]int)
+	for i := 0; i < testKeys; i++ {
+		counts[p.Shard(fmt.Sprintf("key%d", i))]++
+	}
+	return counts
+}